@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/infrastructure/auth"
+	"weather-mcp-server/internal/infrastructure/health"
+	"weather-mcp-server/internal/infrastructure/logging"
+	"weather-mcp-server/internal/infrastructure/weather"
+)
+
+// 支持的传输方式
+const (
+	transportStdio = "stdio"
+	transportHTTP  = "http" // Streamable HTTP，端点 /mcp
+	transportSSE   = "sse"  // HTTP+SSE，端点 /sse 和 /message
+)
+
+// newHTTPHandler 创建HTTP传输的处理器
+// MCP端点、完整状态报告 /status 和Prometheus指标 /metrics 在 requireAuth 为 true 时要求 Bearer 令牌，
+// /healthz 和只返回就绪状态的 /readyz 无需认证；
+// allowClientKeys 为 true 时客户端可通过请求头自带上游API密钥。
+func newHTTPHandler(mcpServer *server.MCPServer, transport string, authn *auth.Authenticator, requireAuth, allowClientKeys bool, checker *health.Checker, metricsHandler http.Handler) http.Handler {
+	protect := func(h http.Handler) http.Handler {
+		if !requireAuth {
+			return h
+		}
+		return authn.Middleware(h)
+	}
+
+	contextFunc := func(ctx context.Context, r *http.Request) context.Context {
+		ctx = authn.HTTPContextFunc(ctx, r)
+		if allowClientKeys {
+			ctx = tenantContext(ctx, r)
+		}
+		return ctx
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", checker.HealthzHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+	mux.Handle("/status", protect(checker.ReadyzHandler()))
+	mux.Handle("/metrics", protect(metricsHandler))
+	if transport == transportSSE {
+		sseServer := server.NewSSEServer(mcpServer, server.WithSSEContextFunc(contextFunc))
+		mux.Handle("/sse", protect(sseServer.SSEHandler()))
+		mux.Handle("/message", protect(sseServer.MessageHandler()))
+	} else {
+		mux.Handle("/mcp", protect(server.NewStreamableHTTPServer(mcpServer, server.WithHTTPContextFunc(contextFunc))))
+	}
+	return mux
+}
+
+// tenantContext 读取请求头中客户端自带的上游API密钥，设置请求的租户
+func tenantContext(ctx context.Context, r *http.Request) context.Context {
+	apiKey := strings.TrimSpace(r.Header.Get(weather.TenantHeader))
+	if apiKey == "" {
+		return ctx
+	}
+	ctx = weather.WithAPIKey(ctx, apiKey)
+	return services.WithTenant(ctx, weather.TenantID(apiKey))
+}
+
+// serveHTTP 启动HTTP传输，收到 SIGINT 或 SIGTERM 时优雅关闭
+func serveHTTP(addr string, handler http.Handler) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("MCP HTTP server listening", "addr", addr)
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// newAuthenticator 根据环境变量创建认证器
+// 令牌来自 WEATHER_AUTH_TOKENS（id:token 列表）和 WEATHER_AUTH_TOKEN_FILE（令牌摘要文件），
+// 审计日志写入 WEATHER_AUDIT_LOG（未设置时写入服务器日志）
+func newAuthenticator() (*auth.Authenticator, int, func() error) {
+	clients, err := auth.ParseStaticTokens(os.Getenv("WEATHER_AUTH_TOKENS"))
+	if err != nil {
+		fatal("Invalid WEATHER_AUTH_TOKENS", err)
+	}
+	if path := os.Getenv("WEATHER_AUTH_TOKEN_FILE"); path != "" {
+		fileClients, err := auth.LoadTokenFile(path)
+		if err != nil {
+			fatal("Failed to load token file", err)
+		}
+		clients = append(clients, fileClients...)
+	}
+
+	opts := []auth.Option{auth.WithDefaultQuota(intEnv("WEATHER_AUTH_DAILY_QUOTA", 0))}
+	closeAudit := func() error { return nil }
+	if path := os.Getenv("WEATHER_AUDIT_LOG"); path != "" {
+		handler, closer, err := logging.New(logging.Config{File: path})
+		if err != nil {
+			fatal("Failed to open audit log", err)
+		}
+		opts = append(opts, auth.WithAuditLogger(slog.New(handler)))
+		closeAudit = closer.Close
+	}
+
+	authn, err := auth.New(clients, opts...)
+	if err != nil {
+		fatal("Failed to configure authentication", err)
+	}
+	return authn, len(clients), closeAudit
+}
@@ -1,48 +1,470 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+	_ "time/tzdata" // 内嵌IANA时区数据库，保证各部署环境下时区解析一致
 
+	mcpproto "github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	"weather-mcp-server/internal/application/services"
+	domain "weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/activity"
+	"weather-mcp-server/internal/infrastructure/health"
+	"weather-mcp-server/internal/infrastructure/logging"
 	"weather-mcp-server/internal/infrastructure/mcp"
+	"weather-mcp-server/internal/infrastructure/metrics"
+	"weather-mcp-server/internal/infrastructure/mock"
+	"weather-mcp-server/internal/infrastructure/preferences"
+	"weather-mcp-server/internal/infrastructure/tracing"
+	"weather-mcp-server/internal/infrastructure/watch"
 	"weather-mcp-server/internal/infrastructure/weather"
 )
 
+// 服务器名称和版本，用于MCP初始化信息和追踪资源属性
+const (
+	serverName    = "weather-mcp-server"
+	serverVersion = "1.0.0"
+	// defaultHTTPAddr HTTP传输的默认监听地址
+	defaultHTTPAddr = ":8080"
+)
+
 func main() {
-	// 获取OpenWeatherMap API密钥
-	apiKey := os.Getenv("OPENWEATHER_API_KEY")
-	if apiKey == "" {
-		log.Fatal("OPENWEATHER_API_KEY environment variable is required")
+	// 配置结构化日志：写入标准错误或 WEATHER_LOG_FILE
+	// stdio 传输使用标准输出传递协议数据，日志不得写入标准输出
+	logHandler, logCloser, err := logging.New(logging.Config{
+		Level:  os.Getenv("WEATHER_LOG_LEVEL"),
+		Format: os.Getenv("WEATHER_LOG_FORMAT"),
+		File:   os.Getenv("WEATHER_LOG_FILE"),
+	})
+	if err != nil {
+		fatal("Failed to configure logging", err)
+	}
+	defer logCloser.Close()
+	slog.SetDefault(slog.New(logHandler))
+
+	// 创建指标，客户端自带上游API密钥的租户请求不计入服务器的每日配额
+	appMetrics := metrics.New(metrics.WithTenantScope(services.TenantFromContext))
+	if v := os.Getenv("WEATHER_API_DAILY_QUOTA"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			fatal("WEATHER_API_DAILY_QUOTA must be a positive integer", nil)
+		}
+		appMetrics.TrackDailyQuota(weather.ProviderName, n)
+	}
+
+	// 配置链路追踪（可选）：WEATHER_TRACE_EXPORTER 为 otlp、file 或 stdout 时启用
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:       os.Getenv("WEATHER_TRACE_EXPORTER"),
+		File:           os.Getenv("WEATHER_TRACE_FILE"),
+		ServiceName:    serverName,
+		ServiceVersion: serverVersion,
+	})
+	if err != nil {
+		fatal("Failed to configure tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	// 创建天气数据源（WEATHER_PROVIDER），上游请求经过追踪和统计指标的传输层
+	monitor := health.NewMonitor()
+	upstreamTransport := tracing.InstrumentTransport(weather.ProviderName,
+		appMetrics.InstrumentTransport(weather.ProviderName, monitor.InstrumentTransport(http.DefaultTransport)))
+	weatherRepo, supportsClientKeys := newWeatherBackend(upstreamTransport)
+
+	// 批量查询的最大并发数（可选）
+	maxConcurrency := services.DefaultMaxConcurrency
+	if v := os.Getenv("WEATHER_MAX_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			fatal("WEATHER_MAX_CONCURRENCY must be a positive integer", nil)
+		}
+		maxConcurrency = n
 	}
 
-	// 创建天气客户端
-	weatherClient := weather.NewOpenWeatherClient(apiKey)
+	// 订阅轮询间隔和监测规则检查间隔、冷却时间（可选）
+	subscriptionInterval := durationEnv("WEATHER_SUBSCRIPTION_INTERVAL", mcp.DefaultSubscriptionInterval)
+	watchInterval := durationEnv("WEATHER_WATCH_INTERVAL", services.DefaultWatchInterval)
+	watchCooldown := durationEnv("WEATHER_WATCH_COOLDOWN", services.DefaultWatchCooldown)
+
+	// 加载活动适宜度规则（可通过 WEATHER_ACTIVITIES_FILE 覆盖或新增）
+	activityRules, err := activity.LoadRules(os.Getenv("WEATHER_ACTIVITIES_FILE"))
+	if err != nil {
+		fatal("Failed to load activity rules", err)
+	}
+
+	// 用户偏好设置存储（未配置 WEATHER_PREFERENCES_FILE 时仅保存在内存中）
+	preferenceStore, err := preferences.NewFileStore(os.Getenv("WEATHER_PREFERENCES_FILE"))
+	if err != nil {
+		fatal("Failed to load preferences", err)
+	}
 
 	// 创建天气应用服务
-	weatherService := services.NewWeatherApplicationService(weatherClient)
+	weatherService := services.NewWeatherApplicationService(weatherRepo,
+		services.WithMaxConcurrency(maxConcurrency),
+		services.WithActivityRules(activityRules),
+		services.WithPreferences(preferenceStore),
+		services.WithIdentity(mcp.ClientIdentity),
+		services.WithCacheObserver(appMetrics),
+	)
 
-	// 创建MCP工具
+	// 创建MCP工具、资源和提示词
 	weatherTools := mcp.NewWeatherTools(weatherService)
+	weatherResources := mcp.NewWeatherResources(weatherService)
+	weatherPrompts := mcp.NewWeatherPrompts(weatherService)
+
+	// 会话注册后允许订阅，初始化后记录客户端身份用于推送监测通知；会话结束时清理订阅
+	var subscriptions *mcp.SubscriptionManager
+	var watchNotifier *mcp.WatchNotifier
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptions.RegisterSession(session.SessionID())
+	})
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcpproto.InitializeRequest, result *mcpproto.InitializeResult) {
+		watchNotifier.TrackSession(ctx)
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptions.UnsubscribeSession(session.SessionID())
+		watchNotifier.ForgetSession(session.SessionID())
+	})
+
+	// HTTP传输的认证和按客户端的调用配额、审计日志（stdio 传输下不生效）
+	authn, tokenCount, closeAudit := newAuthenticator()
+	defer closeAudit()
 
 	// 创建MCP服务器
 	mcpServer := server.NewMCPServer(
-		"weather-mcp-server",
-		"1.0.0",
+		serverName,
+		serverVersion,
 		server.WithInstructions("这是一个天气查询MCP服务器，提供实时天气信息查询功能。"),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
 		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
+		// 先注册的中间件在外层：链路追踪的根 span 覆盖其余所有中间件，包括被配额拒绝的调用
+		server.WithToolHandlerMiddleware(tracing.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(appMetrics.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(monitor.ToolMiddleware()),
+		server.WithToolHandlerMiddleware(mcp.RequestIDMiddleware()),
+		server.WithToolHandlerMiddleware(authn.ToolMiddleware()),
 	)
 
-	// 注册工具
+	// 服务器创建后，请求处理过程中的日志同时转发给支持日志的MCP客户端
+	slog.SetDefault(slog.New(logging.Fanout(logHandler, mcp.NewLogForwarder(mcpServer))))
+
+	// 创建订阅管理器
+	subscriptions = mcp.NewSubscriptionManager(weatherService, mcpServer, subscriptionInterval)
+	defer subscriptions.Close()
+
+	// 创建监测规则引擎：规则保存在 WEATHER_WATCH_FILE（未配置时仅在内存中），
+	// 触发时通过MCP日志通知推送，配置 WEATHER_WATCH_WEBHOOK 时同时发送到Webhook
+	watchNotifier = mcp.NewWatchNotifier(mcpServer)
+	publishers := []domain.WatchEventPublisher{watchNotifier}
+	if webhookURL := os.Getenv("WEATHER_WATCH_WEBHOOK"); webhookURL != "" {
+		publishers = append(publishers, watch.NewWebhookPublisher(webhookURL))
+	}
+	var watchStore domain.WatchRuleRepository
+	if path := os.Getenv("WEATHER_WATCH_FILE"); path != "" {
+		watchStore = watch.NewFileStore(path)
+	}
+	watchEngine, err := services.NewWatchEngine(weatherService, watchStore,
+		services.WithWatchCooldown(watchCooldown),
+		services.WithWatchPublishers(publishers...),
+	)
+	if err != nil {
+		fatal("Failed to create watch engine", err)
+	}
+	watchEngine.Run(watchInterval)
+	defer watchEngine.Close()
+	watchTools := mcp.NewWatchTools(watchEngine, watchNotifier)
+
+	// 服务状态检查：数据源探测结果缓存一分钟，避免频繁探测消耗配额
+	checker := health.NewChecker(weatherRepo, monitor,
+		health.WithVersion(serverVersion),
+		health.WithProbeScope(services.TenantFromContext),
+		health.WithConfigSummary(configSummary()),
+	)
+	statusTools := mcp.NewStatusTools(checker)
+
+	// 注册工具、资源和提示词
 	mcpServer.AddTools(weatherTools.GetTools()...)
+	mcpServer.AddTools(subscriptions.GetTools()...)
+	mcpServer.AddTools(watchTools.GetTools()...)
+	mcpServer.AddTools(statusTools.GetTools()...)
+
+	// 资源和提示词同样查询上游，与工具调用共用客户端配额和审计
+	resources := weatherResources.GetResources()
+	for i := range resources {
+		resources[i].Handler = authn.ResourceHandler(resources[i].Handler)
+	}
+	templates := weatherResources.GetResourceTemplates()
+	for i := range templates {
+		templates[i].Handler = authn.ResourceTemplateHandler(templates[i].Handler)
+	}
+	prompts := weatherPrompts.GetPrompts()
+	for i := range prompts {
+		prompts[i].Handler = authn.PromptHandler(prompts[i].Handler)
+	}
+	mcpServer.AddResources(resources...)
+	mcpServer.AddResourceTemplates(templates...)
+	mcpServer.AddPrompts(prompts...)
+
+	// 管理端口（可选），以Prometheus文本格式提供 /metrics，并提供 /healthz 和 /readyz
+	// 管理端口不做认证，/readyz 只返回就绪状态，不公开配置摘要和上游错误
+	if addr := os.Getenv("WEATHER_ADMIN_ADDR"); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", appMetrics.Handler())
+		mux.Handle("/healthz", checker.HealthzHandler())
+		mux.Handle("/readyz", checker.ReadinessHandler())
+		adminServer := &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			slog.Info("Admin server listening", "addr", addr)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Admin server error", err)
+			}
+		}()
+		defer adminServer.Close()
+	}
+
+	// 启动服务器：默认使用标准输入输出，WEATHER_TRANSPORT 为 http 或 sse 时通过HTTP提供服务
+	transport := os.Getenv("WEATHER_TRANSPORT")
+	slog.Info("Starting weather MCP server", "transport", transport)
+	switch transport {
+	case "", transportStdio:
+		err = server.ServeStdio(mcpServer)
+	case transportHTTP, transportSSE:
+		// 暴露到网络时默认要求认证，避免任何能访问端口的人消耗上游配额
+		requireAuth := os.Getenv("WEATHER_AUTH_DISABLED") != "true"
+		if requireAuth && tokenCount == 0 {
+			fatal("HTTP transport requires WEATHER_AUTH_TOKENS or WEATHER_AUTH_TOKEN_FILE (set WEATHER_AUTH_DISABLED=true to allow anonymous access)", nil)
+		}
+		if !requireAuth {
+			slog.Warn("HTTP transport authentication is disabled")
+		}
+		addr := os.Getenv("WEATHER_HTTP_ADDR")
+		if addr == "" {
+			addr = defaultHTTPAddr
+		}
+		allowClientKeys := supportsClientKeys && os.Getenv("WEATHER_ALLOW_CLIENT_KEYS") != "false"
+		err = serveHTTP(addr, newHTTPHandler(mcpServer, transport, authn, requireAuth, allowClientKeys, checker, appMetrics.Handler()))
+	default:
+		fatal("WEATHER_TRANSPORT must be one of stdio, http, sse", nil)
+	}
+	if err != nil {
+		fatal("Server error", err)
+	}
+}
+
+// weatherBackend 天气数据源，同时提供状态检查使用的探测
+type weatherBackend interface {
+	domain.WeatherRepository
+	domain.ProviderProber
+}
+
+// newWeatherBackend 根据 WEATHER_PROVIDER 创建天气数据源，并返回是否支持客户端自带上游API密钥
+// openweathermap（默认）访问 OpenWeatherMap API；mock 使用 WEATHER_MOCK_SCENARIO 指定的模拟场景，无需网络和API密钥
+func newWeatherBackend(upstreamTransport http.RoundTripper) (weatherBackend, bool) {
+	switch provider := os.Getenv("WEATHER_PROVIDER"); provider {
+	case "", weather.ProviderName:
+		// 获取OpenWeatherMap API密钥：OPENWEATHER_API_KEY（可逗号分隔多个）和/或 OPENWEATHER_API_KEY_FILE
+		apiKeys := newKeyProvider()
+		// 城市名映射：内置城市数据，可通过 WEATHER_CITIES_FILE 补充或覆盖
+		cities, err := weather.LoadCityMapping(os.Getenv("WEATHER_CITIES_FILE"))
+		if err != nil {
+			fatal("Failed to load cities", err)
+		}
+		newClient := func(key string) *weather.OpenWeatherClient {
+			return weather.NewOpenWeatherClient(key,
+				weather.WithTransport(upstreamTransport),
+				weather.WithCityMapping(cities),
+			)
+		}
+
+		// HTTP传输下客户端可通过请求头自带上游API密钥，每个密钥使用独立的客户端和限流
+		defaultClient := weather.NewOpenWeatherClient("",
+			weather.WithTransport(upstreamTransport),
+			weather.WithKeyProvider(apiKeys),
+			weather.WithCityMapping(cities),
+		)
+		// 后台探测默认密钥可用的付费功能，可用 One Call API 3.0 时实时天气包含紫外线指数
+		go func() {
+			features := defaultClient.Features(context.Background())
+			slog.Info("Detected API key features", "features", features)
+		}()
+		return weather.NewTenantClients(defaultClient, newClient,
+			weather.WithTenantRateLimit(intEnv("WEATHER_TENANT_RATE_LIMIT", weather.DefaultTenantRateLimit)),
+		), true
+	case mock.ProviderName:
+		scenario, err := mock.LoadScenario(os.Getenv("WEATHER_MOCK_SCENARIO"))
+		if err != nil {
+			fatal("Failed to load mock scenario", err)
+		}
+		var opts []mock.Option
+		if os.Getenv("WEATHER_MOCK_STEP") != "" {
+			opts = append(opts, mock.WithStep(durationEnv("WEATHER_MOCK_STEP", 0)))
+		}
+		slog.Info("Using mock weather provider", "scenario", scenario.Name, "start", scenario.Start)
+		return mock.NewProvider(scenario, opts...), false
+	default:
+		fatal("WEATHER_PROVIDER must be one of "+weather.ProviderName+", "+mock.ProviderName, nil)
+		return nil, false
+	}
+}
+
+// newKeyProvider 根据环境变量创建上游API密钥提供者
+// 只有一个密钥时直接使用该密钥；有多个密钥或配置了密钥文件时使用密钥池，
+// 密钥文件在收到 SIGHUP 时重新加载，无需重启即可轮换密钥
+func newKeyProvider() weather.KeyProvider {
+	keys := weather.ParseKeys(os.Getenv("OPENWEATHER_API_KEY"))
+	keyFile := os.Getenv("OPENWEATHER_API_KEY_FILE")
+	loadKeys := func() ([]string, error) {
+		if keyFile == "" {
+			return keys, nil
+		}
+		fileKeys, err := weather.LoadKeyFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]string(nil), keys...), fileKeys...), nil
+	}
+
+	initial, err := loadKeys()
+	if err != nil {
+		fatal("Failed to load API keys", err)
+	}
+	if len(initial) == 0 {
+		fatal("OPENWEATHER_API_KEY or OPENWEATHER_API_KEY_FILE environment variable is required", nil)
+	}
+	if len(initial) == 1 && keyFile == "" {
+		return weather.StaticKey(initial[0])
+	}
+
+	strategy := os.Getenv("WEATHER_KEY_STRATEGY")
+	if strategy == "" {
+		strategy = weather.StrategyRoundRobin
+	}
+	pool, err := weather.NewKeyPool(initial, weather.WithStrategy(strategy))
+	if err != nil {
+		fatal("Failed to create API key pool", err)
+	}
+	slog.Info("Using API key pool", "keys", len(initial), "strategy", strategy)
 
-	log.Println("Starting weather MCP server...")
+	if keyFile != "" {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go func() {
+			for range reload {
+				reloaded, err := loadKeys()
+				if err == nil {
+					err = pool.Replace(reloaded)
+				}
+				if err != nil {
+					slog.Error("Failed to reload API keys, keeping the current keys", "error", err)
+					continue
+				}
+				slog.Info("Reloaded API keys", "keys", len(reloaded))
+			}
+		}()
+	}
+	return pool
+}
+
+// intEnv 读取非负整数类型的环境变量，未设置时返回默认值
+func intEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		fatal(name+" must be a non-negative integer", nil)
+	}
+	return n
+}
+
+// durationEnv 读取时长类型的环境变量，未设置时返回默认值
+func durationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		fatal(name+" must be a positive duration such as 5m", nil)
+	}
+	return d
+}
+
+// configEnv 状态报告中展示的配置项
+var configEnv = []string{
+	"WEATHER_MAX_CONCURRENCY",
+	"WEATHER_SUBSCRIPTION_INTERVAL",
+	"WEATHER_WATCH_INTERVAL",
+	"WEATHER_WATCH_COOLDOWN",
+	"WEATHER_WATCH_FILE",
+	"WEATHER_PREFERENCES_FILE",
+	"WEATHER_ACTIVITIES_FILE",
+	"WEATHER_ADMIN_ADDR",
+	"WEATHER_API_DAILY_QUOTA",
+	"WEATHER_LOG_LEVEL",
+	"WEATHER_LOG_FORMAT",
+	"WEATHER_LOG_FILE",
+	"WEATHER_TRACE_EXPORTER",
+	"WEATHER_TRACE_FILE",
+	"WEATHER_TRANSPORT",
+	"WEATHER_HTTP_ADDR",
+	"WEATHER_AUTH_TOKEN_FILE",
+	"WEATHER_AUTH_DAILY_QUOTA",
+	"WEATHER_AUTH_DISABLED",
+	"WEATHER_AUDIT_LOG",
+	"WEATHER_ALLOW_CLIENT_KEYS",
+	"WEATHER_TENANT_RATE_LIMIT",
+	"OPENWEATHER_API_KEY_FILE",
+	"WEATHER_KEY_STRATEGY",
+	"WEATHER_PROVIDER",
+	"WEATHER_MOCK_SCENARIO",
+	"WEATHER_MOCK_STEP",
+	"WEATHER_CITIES_FILE",
+}
+
+// configSummary 生成状态报告中的配置摘要，API密钥和Webhook地址已脱敏
+func configSummary() map[string]string {
+	summary := map[string]string{
+		"OPENWEATHER_API_KEY":   health.MaskSecret(os.Getenv("OPENWEATHER_API_KEY")),
+		"WEATHER_WATCH_WEBHOOK": health.MaskSecret(os.Getenv("WEATHER_WATCH_WEBHOOK")),
+		"WEATHER_AUTH_TOKENS":   health.MaskSecret(os.Getenv("WEATHER_AUTH_TOKENS")),
+	}
+	for _, name := range configEnv {
+		if v := os.Getenv(name); v != "" {
+			summary[name] = v
+		}
+	}
+	return summary
+}
 
-	// 启动服务器（使用标准输入输出）
-	if err := server.ServeStdio(mcpServer); err != nil {
-		log.Fatal("Server error:", err)
+// fatal 记录错误日志后退出进程
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
 	}
+	os.Exit(1)
 }
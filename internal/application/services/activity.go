@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// ActivityAdviceResult 活动适宜度建议结果
+type ActivityAdviceResult struct {
+	Location weather.Location         `json:"location"`
+	Hours    int                      `json:"hours"`
+	Advice   []weather.ActivityAdvice `json:"advice"`
+}
+
+// WithActivityRules 设置活动适宜度规则
+func WithActivityRules(rules []weather.ActivityRule) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		s.activityRules = rules
+	}
+}
+
+// ActivityRules 获取所有活动规则
+func (s *WeatherApplicationService) ActivityRules() []weather.ActivityRule {
+	return s.activityRules
+}
+
+// AdviseActivities 评估未来hours小时内各活动的适宜度
+// names 为空时评估所有活动
+func (s *WeatherApplicationService) AdviseActivities(ctx context.Context, location string, names []string, hours int) (*ActivityAdviceResult, error) {
+	rules, err := s.selectActivityRules(names)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多获取 dry_hours_after 小时的预报，用于确认请求范围内最后的时段之后无降水
+	fetch := hours
+	for _, rule := range rules {
+		fetch = max(fetch, hours+rule.DryHoursAfter)
+	}
+	hw, err := s.GetHourlyWeatherByLocation(ctx, location, min(fetch, MaxForecastHours))
+	if err != nil {
+		return nil, err
+	}
+
+	slots := 0
+	if len(hw.Hourly) > 0 {
+		end := hw.Hourly[0].Date.Add(time.Duration(hours) * time.Hour)
+		for slots < len(hw.Hourly) && hw.Hourly[slots].Date.Before(end) {
+			slots++
+		}
+	}
+
+	result := &ActivityAdviceResult{
+		Location: hw.Location,
+		Hours:    hours,
+		Advice:   make([]weather.ActivityAdvice, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		result.Advice = append(result.Advice, weather.AssessActivity(rule, hw.Hourly, slots))
+	}
+	return result, nil
+}
+
+// selectActivityRules 按名称选择活动规则，保持请求顺序
+func (s *WeatherApplicationService) selectActivityRules(names []string) ([]weather.ActivityRule, error) {
+	if len(s.activityRules) == 0 {
+		return nil, fmt.Errorf("no activity rules configured")
+	}
+	if len(names) == 0 {
+		return s.activityRules, nil
+	}
+
+	byName := make(map[string]weather.ActivityRule, len(s.activityRules))
+	for _, r := range s.activityRules {
+		byName[r.Name] = r
+	}
+	rules := make([]weather.ActivityRule, 0, len(names))
+	for _, name := range names {
+		rule, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown activity: %s", name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// FormatActivityAdviceResponse 格式化活动适宜度建议响应
+func (s *WeatherApplicationService) FormatActivityAdviceResponse(result *ActivityAdviceResult) string {
+	if result == nil || len(result.Advice) == 0 {
+		return "无法获取活动建议"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📍 %s, %s 未来%d小时活动建议\n", result.Location.City, result.Location.Country, result.Hours))
+	for _, a := range result.Advice {
+		label := a.Label
+		if label == "" {
+			label = a.Activity
+		}
+		if len(a.BestSlots) == 0 {
+			sb.WriteString(fmt.Sprintf("❌ %s: 不适宜\n", label))
+			if reasons := commonReasons(a.Slots); reasons != "" {
+				sb.WriteString(fmt.Sprintf("  原因: %s\n", reasons))
+			}
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("✅ %s: 推荐时段\n", label))
+		for _, slot := range a.BestSlots {
+			sb.WriteString(fmt.Sprintf("  %s 得分%d (%s)\n",
+				result.Location.LocalTime(slot.Time).Format(slotLayout), slot.Score, strings.Join(slot.Reasons, ", ")))
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// commonReasons 汇总不适宜时段的原因（去重，保持出现顺序）
+func commonReasons(slots []weather.SlotAssessment) string {
+	seen := make(map[string]bool)
+	var reasons []string
+	for _, slot := range slots {
+		for _, r := range slot.Reasons {
+			// 去掉具体数值，只按原因类别去重
+			kind := strings.SplitN(r, " ", 2)[0]
+			if !seen[kind] {
+				seen[kind] = true
+				reasons = append(reasons, r)
+			}
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
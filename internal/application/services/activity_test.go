@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestAdviseActivitiesFetchesDryHoursAfterWindow(t *testing.T) {
+	start := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	var slots []weather.HourlyWeather
+	for i := 0; i < 40; i++ {
+		slots = append(slots, weather.HourlyWeather{Date: start.Add(time.Duration(3*i) * time.Hour), Temperature: 15})
+	}
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"Hangzhou": {Location: weather.Location{City: "Hangzhou", Country: "CN"}, Hourly: slots},
+		},
+	}
+	maxPrecip, minTemp := 0.2, 3.0
+	carWashing := weather.ActivityRule{Name: "car_washing", Label: "洗车", MaxPrecipProbability: &maxPrecip, MinTemperature: &minTemp, DryHoursAfter: 24}
+	service := NewWeatherApplicationService(repo, WithActivityRules([]weather.ActivityRule{carWashing}))
+
+	result, err := service.AdviseActivities(context.Background(), "Hangzhou", nil, 24)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	advice := result.Advice[0]
+	if len(advice.Slots) != 8 {
+		t.Fatalf("Expected only the 8 slots within 24 hours to be scored, got %d", len(advice.Slots))
+	}
+	for i, slot := range advice.Slots {
+		if !slot.Suitable {
+			t.Errorf("Slot %d: expected suitable for a dry forecast, got %v", i, slot.Reasons)
+		}
+	}
+	if len(advice.BestSlots) == 0 {
+		t.Error("Expected best slots for car washing")
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// BatchWeatherResult 批量查询中单个位置的结果
+type BatchWeatherResult struct {
+	Location string
+	Weather  *weather.Weather
+	Hourly   *weather.HourlyWeatherResult
+	Err      error
+}
+
+// GetWeatherBatch 并发获取多个位置的天气
+// hours为0时查询实时天气，否则查询未来小时预报。
+// 并发数受服务全局的 maxConcurrency 限制，单个位置失败不会影响其他位置，结果顺序与输入一致。
+func (s *WeatherApplicationService) GetWeatherBatch(ctx context.Context, locations []string, hours int) []BatchWeatherResult {
+	results := make([]BatchWeatherResult, len(locations))
+	s.runConcurrently(ctx, len(locations), func(i int) {
+		results[i] = s.fetchBatchItem(ctx, locations[i], hours)
+	})
+
+	return results
+}
+
+// runConcurrently 并发地对 [0, n) 中的每个下标执行 fn
+// 所有调用共享服务的并发名额，同时进行的批量、对比和路线查询合计不超过 maxConcurrency 个上游请求；
+// fn 不能再调用 runConcurrently，否则可能因名额耗尽而死锁
+func (s *WeatherApplicationService) runConcurrently(ctx context.Context, n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case s.limiter <- struct{}{}:
+				defer func() { <-s.limiter }()
+			case <-ctx.Done():
+				// 请求已取消，不再等待名额，fn 会因上下文取消立即失败
+			}
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchBatchItem 获取批量查询中单个位置的天气
+func (s *WeatherApplicationService) fetchBatchItem(ctx context.Context, location string, hours int) BatchWeatherResult {
+	result := BatchWeatherResult{Location: location}
+	if hours == 0 {
+		result.Weather, result.Err = s.GetWeatherByLocation(ctx, location)
+	} else {
+		result.Hourly, result.Err = s.GetHourlyWeatherByLocation(ctx, location, hours)
+	}
+	return result
+}
+
+// FormatBatchWeatherResponse 格式化批量天气响应为对比表格
+func (s *WeatherApplicationService) FormatBatchWeatherResponse(results []BatchWeatherResult, hours int) string {
+	if len(results) == 0 {
+		return "无法获取天气信息"
+	}
+
+	var sb strings.Builder
+	succeeded := 0
+	if hours == 0 {
+		sb.WriteString("| 位置 | 温度 | 体感 | 湿度 | 风速 | 天气 |\n")
+		sb.WriteString("|---|---|---|---|---|---|\n")
+		for _, r := range results {
+			if r.Err != nil || r.Weather == nil {
+				sb.WriteString(fmt.Sprintf("| %s | ❌ %s | | | | |\n", r.Location, batchErrorText(r.Err)))
+				continue
+			}
+			succeeded++
+			c := r.Weather.Current
+			sb.WriteString(fmt.Sprintf("| %s | %.1f°C | %.1f°C | %d%% | %.1fm/s(%s) | %s |\n",
+				batchLocationName(r.Location, r.Weather.Location), c.Temperature, c.FeelsLike, c.Humidity,
+				c.WindSpeed, c.WindDir, c.Description))
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("未来%d小时预报对比\n", hours))
+		sb.WriteString("| 位置 | 最低温 | 最高温 | 累计降水 | 最高降水概率 | 最大风速 |\n")
+		sb.WriteString("|---|---|---|---|---|---|\n")
+		for _, r := range results {
+			if r.Err != nil || r.Hourly == nil || len(r.Hourly.Hourly) == 0 {
+				sb.WriteString(fmt.Sprintf("| %s | ❌ %s | | | | |\n", r.Location, batchErrorText(r.Err)))
+				continue
+			}
+			succeeded++
+			minTemp, maxTemp, maxWind := hourlyExtremes(r.Hourly.Hourly)
+			precip := weather.SummarizePrecipitation(r.Hourly.Hourly)
+			sb.WriteString(fmt.Sprintf("| %s | %.1f°C | %.1f°C | %.1fmm | %.0f%% | %.1fm/s |\n",
+				batchLocationName(r.Location, r.Hourly.Location), minTemp, maxTemp,
+				precip.TotalAmount, precip.PeakProbability*100, maxWind))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("✅ 成功: %d, ❌ 失败: %d", succeeded, len(results)-succeeded))
+
+	return sb.String()
+}
+
+// hourlyExtremes 计算预报时段内的最低温、最高温和最大风速
+func hourlyExtremes(hourly []weather.HourlyWeather) (minTemp, maxTemp, maxWind float64) {
+	for i, h := range hourly {
+		if i == 0 || h.Temperature < minTemp {
+			minTemp = h.Temperature
+		}
+		if i == 0 || h.Temperature > maxTemp {
+			maxTemp = h.Temperature
+		}
+		if h.WindSpeed > maxWind {
+			maxWind = h.WindSpeed
+		}
+	}
+	return minTemp, maxTemp, maxWind
+}
+
+// batchLocationName 获取对比表格中展示的位置名称
+func batchLocationName(query string, loc weather.Location) string {
+	if loc.City == "" {
+		return query
+	}
+	return fmt.Sprintf("%s (%s, %s)", query, loc.City, loc.Country)
+}
+
+// batchErrorText 获取对比表格中展示的错误信息
+func batchErrorText(err error) string {
+	if err == nil {
+		return "无数据"
+	}
+	return strings.ReplaceAll(err.Error(), "|", "/")
+}
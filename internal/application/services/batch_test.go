@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// fakeRepository 测试用天气仓储
+type fakeRepository struct {
+	mu       sync.Mutex
+	inFlight int32
+	peak     int32
+	delay    time.Duration
+	weathers map[string]*weather.Weather
+	hourly   map[string]*weather.HourlyWeatherResult
+}
+
+func (r *fakeRepository) enter() func() {
+	n := atomic.AddInt32(&r.inFlight, 1)
+	r.mu.Lock()
+	if n > r.peak {
+		r.peak = n
+	}
+	r.mu.Unlock()
+	time.Sleep(r.delay)
+	return func() { atomic.AddInt32(&r.inFlight, -1) }
+}
+
+func (r *fakeRepository) GetCurrentWeather(ctx context.Context, lat, lon float64) (*weather.Weather, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeRepository) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	defer r.enter()()
+	if w, ok := r.weathers[city]; ok {
+		return w, nil
+	}
+	return nil, errors.New("API request failed with status: 404")
+}
+
+func (r *fakeRepository) GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*weather.HourlyWeatherResult, error) {
+	return r.GetHourlyWeatherByCity(ctx, fmt.Sprintf("%g,%g", lat, lon), hours)
+}
+
+func (r *fakeRepository) GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*weather.HourlyWeatherResult, error) {
+	defer r.enter()()
+	if hw, ok := r.hourly[city]; ok {
+		// 与 OpenWeatherMap 客户端一致，按3小时间隔返回覆盖hours小时的时段
+		if n := (hours + 2) / 3; n < len(hw.Hourly) {
+			trimmed := *hw
+			trimmed.Hourly = hw.Hourly[:n]
+			return &trimmed, nil
+		}
+		return hw, nil
+	}
+	return nil, errors.New("API request failed with status: 404")
+}
+
+func TestGetWeatherBatch(t *testing.T) {
+	repo := &fakeRepository{
+		delay: 10 * time.Millisecond,
+		weathers: map[string]*weather.Weather{
+			"Shanghai": {Location: weather.Location{City: "Shanghai", Country: "CN"}, Current: weather.CurrentWeather{Temperature: 28}},
+			"Chengdu":  {Location: weather.Location{City: "Chengdu", Country: "CN"}, Current: weather.CurrentWeather{Temperature: 24}},
+			"Xian":     {Location: weather.Location{City: "Xi'an", Country: "CN"}, Current: weather.CurrentWeather{Temperature: 31}},
+		},
+	}
+	service := NewWeatherApplicationService(repo, WithMaxConcurrency(2))
+
+	locations := []string{"Shanghai", "Atlantis", "Chengdu", "Xian", "Shanghai"}
+	results := service.GetWeatherBatch(context.Background(), locations, 0)
+
+	if len(results) != len(locations) {
+		t.Fatalf("Expected %d results, got %d", len(locations), len(results))
+	}
+	for i, r := range results {
+		if r.Location != locations[i] {
+			t.Errorf("Expected result %d for %s, got %s", i, locations[i], r.Location)
+		}
+	}
+	if results[1].Err == nil {
+		t.Errorf("Expected error for unknown location")
+	}
+	if results[3].Err != nil || results[3].Weather.Current.Temperature != 31 {
+		t.Errorf("Expected weather for Xian, got %+v", results[3])
+	}
+	if repo.peak > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", repo.peak)
+	}
+}
+
+func TestConcurrencyLimitIsSharedAcrossRequests(t *testing.T) {
+	repo := &fakeRepository{
+		delay:    10 * time.Millisecond,
+		weathers: map[string]*weather.Weather{"Shanghai": {Location: weather.Location{City: "Shanghai"}}},
+	}
+	service := NewWeatherApplicationService(repo, WithMaxConcurrency(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service.GetWeatherBatch(context.Background(), []string{"Shanghai", "Shanghai", "Shanghai"}, 0)
+		}()
+	}
+	wg.Wait()
+	if repo.peak > 2 {
+		t.Errorf("Expected at most 2 concurrent requests across batches, got %d", repo.peak)
+	}
+
+	// 已取消的请求不等待名额
+	service.limiter <- struct{}{}
+	service.limiter <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		service.GetWeatherBatch(ctx, []string{"Shanghai"}, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected cancelled batch not to wait for a concurrency slot")
+	}
+}
+
+func TestFormatBatchWeatherResponse(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{})
+	results := []BatchWeatherResult{
+		{
+			Location: "上海",
+			Hourly: &weather.HourlyWeatherResult{
+				Location: weather.Location{City: "Shanghai", Country: "CN"},
+				Hourly: []weather.HourlyWeather{
+					{Temperature: 22, WindSpeed: 3, PrecipProbability: 0.4, PrecipAmount: 1},
+					{Temperature: 27, WindSpeed: 5, PrecipProbability: 0.7, PrecipAmount: 2},
+				},
+			},
+		},
+		{Location: "Atlantis", Err: errors.New("API request failed with status: 404")},
+	}
+
+	output := service.FormatBatchWeatherResponse(results, 6)
+
+	expected := []string{
+		"| 上海 (Shanghai, CN) | 22.0°C | 27.0°C | 3.0mm | 70% | 5.0m/s |",
+		"| Atlantis | ❌ API request failed with status: 404 | | | | |",
+		"✅ 成功: 1, ❌ 失败: 1",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
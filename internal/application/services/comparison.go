@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// ComparisonResult 多位置比较结果
+type ComparisonResult struct {
+	Criterion weather.RankCriterion    `json:"criterion"`
+	Hours     int                      `json:"hours"`
+	Rankings  []weather.RankedLocation `json:"rankings"`
+	Errors    map[string]string        `json:"errors,omitempty"` // 获取数据失败的位置及原因
+}
+
+// CompareLocations 获取多个位置未来hours小时的天气并按标准排名
+// 重复的位置只查询和排名一次；单个位置获取失败时该位置记为未排名，不影响其他位置。
+func (s *WeatherApplicationService) CompareLocations(ctx context.Context, locations []string, criterion weather.RankCriterion, hours int) (*ComparisonResult, error) {
+	if _, err := weather.ParseRankCriterion(string(criterion)); err != nil {
+		return nil, err
+	}
+	if hours <= 0 {
+		return nil, fmt.Errorf("hours must be positive")
+	}
+
+	batch := s.GetWeatherBatch(ctx, uniqueLocations(locations), hours)
+	conditions := make([]weather.LocationConditions, len(batch))
+	errs := make(map[string]string)
+	for i, r := range batch {
+		conditions[i] = weather.LocationConditions{Name: r.Location}
+		if r.Err != nil {
+			errs[r.Location] = r.Err.Error()
+			continue
+		}
+		conditions[i].Location = r.Hourly.Location
+		conditions[i].Hourly = r.Hourly.Hourly
+	}
+
+	if criterion == weather.CriterionBestAQI {
+		var mu sync.Mutex
+		s.runConcurrently(ctx, len(conditions), func(i int) {
+			if batch[i].Err != nil {
+				return
+			}
+			aq, err := s.getAirQuality(ctx, conditions[i].Location)
+			if err != nil {
+				mu.Lock()
+				errs[conditions[i].Name] = err.Error()
+				mu.Unlock()
+				return
+			}
+			conditions[i].AirQuality = aq
+		})
+	}
+
+	rankings, err := weather.RankLocations(conditions, criterion)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ComparisonResult{
+		Criterion: criterion,
+		Hours:     hours,
+		Rankings:  rankings,
+	}
+	if len(errs) > 0 {
+		result.Errors = errs
+	}
+	return result, nil
+}
+
+// uniqueLocations 按输入顺序去除重复的位置
+// 排名和错误均以位置名称区分，重复的位置会互相覆盖
+func uniqueLocations(locations []string) []string {
+	seen := make(map[string]bool, len(locations))
+	unique := make([]string, 0, len(locations))
+	for _, l := range locations {
+		if !seen[l] {
+			seen[l] = true
+			unique = append(unique, l)
+		}
+	}
+	return unique
+}
+
+// FormatComparisonResponse 格式化多位置比较响应
+func (s *WeatherApplicationService) FormatComparisonResponse(result *ComparisonResult) string {
+	if result == nil || len(result.Rankings) == 0 {
+		return "无法比较天气信息"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🏆 未来%d小时%s排名\n", result.Hours, criterionLabel(result.Criterion)))
+	for _, r := range result.Rankings {
+		name := batchLocationName(r.Name, r.Location)
+		if r.Unranked {
+			reason := r.Detail
+			if msg, ok := result.Errors[r.Name]; ok {
+				reason = msg
+			}
+			sb.WriteString(fmt.Sprintf("—. %s: ❌ %s\n", name, reason))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s: %s\n", r.Rank, name, r.Detail))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// criterionLabel 获取排名标准的中文名称
+func criterionLabel(c weather.RankCriterion) string {
+	switch c {
+	case weather.CriterionWarmest:
+		return "最温暖"
+	case weather.CriterionLeastRainy:
+		return "降水最少"
+	case weather.CriterionLowestWind:
+		return "风力最小"
+	case weather.CriterionBestAQI:
+		return "空气质量最好"
+	case weather.CriterionMostComfortable:
+		return "体感最舒适"
+	default:
+		return string(c)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestCompareLocations(t *testing.T) {
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"Shanghai": {
+				Location: weather.Location{City: "Shanghai", Country: "CN"},
+				Hourly:   []weather.HourlyWeather{{Temperature: 28}, {Temperature: 30}},
+			},
+			"Chengdu": {
+				Location: weather.Location{City: "Chengdu", Country: "CN"},
+				Hourly:   []weather.HourlyWeather{{Temperature: 22}, {Temperature: 24}},
+			},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	result, err := service.CompareLocations(context.Background(), []string{"Chengdu", "Atlantis", "Shanghai"}, weather.CriterionWarmest, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Rankings) != 3 {
+		t.Fatalf("Expected 3 rankings, got %d", len(result.Rankings))
+	}
+	if result.Rankings[0].Name != "Shanghai" || result.Rankings[0].Value != 30 {
+		t.Errorf("Expected Shanghai first with 30°C, got %+v", result.Rankings[0])
+	}
+	if !result.Rankings[2].Unranked || result.Errors["Atlantis"] == "" {
+		t.Errorf("Expected Atlantis unranked with error, got %+v, errors %v", result.Rankings[2], result.Errors)
+	}
+
+	output := service.FormatComparisonResponse(result)
+	for _, line := range []string{
+		"🏆 未来6小时最温暖排名",
+		"1. Shanghai (Shanghai, CN): 最高温 30.0°C, 平均 29.0°C",
+		"2. Chengdu (Chengdu, CN): 最高温 24.0°C, 平均 23.0°C",
+		"—. Atlantis: ❌ API request failed with status: 404",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestCompareLocationsDeduplicates(t *testing.T) {
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"Shanghai": {Hourly: []weather.HourlyWeather{{Temperature: 28}}},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	result, err := service.CompareLocations(context.Background(), []string{"Atlantis", "Shanghai", "Atlantis", "Shanghai"}, weather.CriterionWarmest, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Rankings) != 2 {
+		t.Fatalf("Expected duplicates to be ranked once, got %+v", result.Rankings)
+	}
+	if result.Rankings[0].Name != "Shanghai" || result.Rankings[0].Rank != 1 {
+		t.Errorf("Expected Shanghai ranked first, got %+v", result.Rankings[0])
+	}
+	if len(result.Errors) != 1 || result.Errors["Atlantis"] == "" {
+		t.Errorf("Expected one error for Atlantis, got %v", result.Errors)
+	}
+
+	output := service.FormatComparisonResponse(result)
+	if n := strings.Count(output, "Atlantis"); n != 1 {
+		t.Errorf("Expected Atlantis to appear once, got %d times:\n%s", n, output)
+	}
+}
+
+func TestCompareLocationsAirQualityUnsupported(t *testing.T) {
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"Shanghai": {Hourly: []weather.HourlyWeather{{Temperature: 28}}},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	result, err := service.CompareLocations(context.Background(), []string{"Shanghai"}, weather.CriterionBestAQI, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Rankings[0].Unranked {
+		t.Errorf("Expected location to be unranked without air quality data")
+	}
+	if !strings.Contains(result.Errors["Shanghai"], "not supported") {
+		t.Errorf("Expected unsupported provider error, got %v", result.Errors)
+	}
+}
+
+func TestCompareLocationsInvalidCriterion(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{})
+	if _, err := service.CompareLocations(context.Background(), []string{"Shanghai"}, "sunniest", 3); err == nil {
+		t.Errorf("Expected error for unsupported criterion")
+	}
+}
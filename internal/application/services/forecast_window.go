@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// MaxForecastHours 预报数据覆盖的最大小时数（5天，3小时间隔共40个时段）
+const MaxForecastHours = 120
+
+// dateLayout 日期格式
+const dateLayout = "2006-01-02"
+
+// GetForecastForDates 获取位置在指定日期范围内的预报
+// 日期按位置所在时区解释，from 和 to 均包含在内。超出预报覆盖范围的日期没有对应时段。
+func (s *WeatherApplicationService) GetForecastForDates(ctx context.Context, location string, from, to time.Time) (*weather.HourlyWeatherResult, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("end date must not be before start date")
+	}
+
+	hw, err := s.GetHourlyWeatherByLocation(ctx, location, MaxForecastHours)
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := from.Format(dateLayout), to.Format(dateLayout)
+	result := &weather.HourlyWeatherResult{
+		Location:    hw.Location,
+		LastUpdated: hw.LastUpdated,
+	}
+	for _, h := range hw.Hourly {
+		day := hw.Location.LocalTime(h.Date).Format(dateLayout)
+		if day >= first && day <= last {
+			result.Hourly = append(result.Hourly, h)
+		}
+	}
+	return result, nil
+}
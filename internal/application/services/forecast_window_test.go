@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestGetForecastForDates(t *testing.T) {
+	// 2026-10-19 21:00 UTC 起每3小时一个时段，对应纽约时间 17:00 起
+	location := weather.Location{City: "New York", Country: "US", UTCOffset: -4 * 3600}
+	start := time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)
+	var slots []weather.HourlyWeather
+	for i := 0; i < 16; i++ {
+		slots = append(slots, weather.HourlyWeather{Date: start.Add(time.Duration(3*i) * time.Hour)})
+	}
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"New York": {Location: location, Hourly: slots},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	day := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	result, err := service.GetForecastForDates(context.Background(), "New York", day, day)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Hourly) != 8 {
+		t.Fatalf("Expected 8 slots on 2026-10-20 local time, got %d", len(result.Hourly))
+	}
+	first := location.LocalTime(result.Hourly[0].Date).Format("2006-01-02 15:04")
+	if first != "2026-10-20 02:00" {
+		t.Errorf("Expected first local slot 2026-10-20 02:00, got %s", first)
+	}
+
+	if _, err := service.GetForecastForDates(context.Background(), "New York", day, day.AddDate(0, 0, -1)); err == nil {
+		t.Errorf("Expected error for reversed date range")
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// supportedLanguages 偏好设置支持的语言
+var supportedLanguages = []string{"zh", "en"}
+
+// WithPreferences 设置用户偏好设置仓储
+func WithPreferences(repo weather.PreferencesRepository) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		s.preferences = repo
+	}
+}
+
+// WithIdentity 设置从请求上下文识别用户的方法
+// 设置后所有按位置查询的方法都会按请求用户保存的偏好解析位置别名（如"家"）
+func WithIdentity(identify func(context.Context) string) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		s.identify = identify
+	}
+}
+
+// GetPreferences 获取用户偏好设置，未设置或未配置仓储时返回空设置
+func (s *WeatherApplicationService) GetPreferences(user string) (*weather.Preferences, error) {
+	if s.preferences == nil {
+		return &weather.Preferences{}, nil
+	}
+	prefs, err := s.preferences.GetPreferences(user)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		prefs = &weather.Preferences{}
+	}
+	return prefs, nil
+}
+
+// ResolveLocation 将用户保存的位置别名解析为实际位置，不是别名时原样返回
+func (s *WeatherApplicationService) ResolveLocation(user, location string) (string, error) {
+	prefs, err := s.GetPreferences(user)
+	if err != nil {
+		return "", err
+	}
+	resolved, _ := prefs.ResolveAlias(location)
+	return resolved, nil
+}
+
+// resolveAlias 按请求用户保存的偏好将位置别名解析为实际位置
+// 不是别名、无法识别用户或读取偏好失败时原样返回
+func (s *WeatherApplicationService) resolveAlias(ctx context.Context, location string) string {
+	if s.identify == nil || s.preferences == nil {
+		return location
+	}
+	user := s.identify(ctx)
+	if user == "" {
+		return location
+	}
+	resolved, err := s.ResolveLocation(user, location)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load preferences for alias resolution", "error", err)
+		return location
+	}
+	return resolved
+}
+
+// SaveLocation 保存位置别名，location 为空时删除该别名
+func (s *WeatherApplicationService) SaveLocation(user, name, location string) (*weather.Preferences, error) {
+	if err := weather.ValidateAlias(name); err != nil {
+		return nil, err
+	}
+	location = strings.TrimSpace(location)
+	if location != "" {
+		if _, err := parseLocationQuery(location); err != nil {
+			return nil, err
+		}
+	}
+
+	alias := weather.NormalizeAlias(name)
+	return s.updatePreferences(user, func(p *weather.Preferences) error {
+		if location == "" {
+			delete(p.Locations, alias)
+			return nil
+		}
+		if p.Locations == nil {
+			p.Locations = make(map[string]string)
+		}
+		if _, exists := p.Locations[alias]; !exists && len(p.Locations) >= weather.MaxSavedLocations {
+			return fmt.Errorf("at most %d saved locations are allowed", weather.MaxSavedLocations)
+		}
+		p.Locations[alias] = location
+		return nil
+	})
+}
+
+// SetUnits 设置偏好的单位制
+func (s *WeatherApplicationService) SetUnits(user, units string) (*weather.Preferences, error) {
+	u, err := weather.ParseUnits(units)
+	if err != nil {
+		return nil, err
+	}
+	return s.updatePreferences(user, func(p *weather.Preferences) error {
+		p.Units = u
+		return nil
+	})
+}
+
+// SetLanguage 设置偏好的语言，只作为提示词未指定 lang 时的语言，不影响天气查询结果
+func (s *WeatherApplicationService) SetLanguage(user, lang string) (*weather.Preferences, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	supported := false
+	for _, l := range supportedLanguages {
+		supported = supported || l == lang
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported language: %s (supported: %s)", lang, strings.Join(supportedLanguages, ", "))
+	}
+	return s.updatePreferences(user, func(p *weather.Preferences) error {
+		p.Language = lang
+		return nil
+	})
+}
+
+// SetDefaultHours 设置 get_weather 默认的预报小时数，0表示默认查询实时天气
+func (s *WeatherApplicationService) SetDefaultHours(user string, hours int) (*weather.Preferences, error) {
+	if hours < 0 || hours > 12 {
+		return nil, fmt.Errorf("default hours must be between 0 and 12")
+	}
+	return s.updatePreferences(user, func(p *weather.Preferences) error {
+		p.DefaultHours = hours
+		return nil
+	})
+}
+
+// updatePreferences 读取、修改并保存用户偏好设置
+func (s *WeatherApplicationService) updatePreferences(user string, fn func(*weather.Preferences) error) (*weather.Preferences, error) {
+	if s.preferences == nil {
+		return nil, fmt.Errorf("preferences are not supported by the current configuration")
+	}
+
+	s.preferencesMu.Lock()
+	defer s.preferencesMu.Unlock()
+
+	prefs, err := s.GetPreferences(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(prefs); err != nil {
+		return nil, err
+	}
+	if err := s.preferences.SavePreferences(user, prefs); err != nil {
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// FormatPreferencesResponse 格式化用户偏好设置
+func (s *WeatherApplicationService) FormatPreferencesResponse(prefs *weather.Preferences) string {
+	var sb strings.Builder
+	sb.WriteString("📌 已保存的位置\n")
+	if len(prefs.Locations) == 0 {
+		sb.WriteString("  （无）\n")
+	}
+	aliases := make([]string, 0, len(prefs.Locations))
+	for alias := range prefs.Locations {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		sb.WriteString(fmt.Sprintf("  %s → %s\n", alias, prefs.Locations[alias]))
+	}
+
+	language := prefs.Language
+	if language == "" {
+		language = supportedLanguages[0]
+	}
+	sb.WriteString(fmt.Sprintf("📏 单位: %s\n", prefs.EffectiveUnits()))
+	sb.WriteString(fmt.Sprintf("🌐 提示词语言: %s\n", language))
+	if prefs.DefaultHours > 0 {
+		sb.WriteString(fmt.Sprintf("⏱️ 默认预报: 未来%d小时", prefs.DefaultHours))
+	} else {
+		sb.WriteString("⏱️ 默认预报: 实时天气")
+	}
+	return sb.String()
+}
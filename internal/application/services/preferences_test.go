@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// memoryPreferences 测试用偏好设置仓储
+type memoryPreferences map[string]*weather.Preferences
+
+func (m memoryPreferences) GetPreferences(user string) (*weather.Preferences, error) {
+	if p, ok := m[user]; ok {
+		c := *p
+		c.Locations = make(map[string]string)
+		for k, v := range p.Locations {
+			c.Locations[k] = v
+		}
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (m memoryPreferences) SavePreferences(user string, prefs *weather.Preferences) error {
+	m[user] = prefs
+	return nil
+}
+
+func TestLocationAliases(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{}, WithPreferences(memoryPreferences{}))
+
+	if _, err := service.SaveLocation("alice", " Home ", "北京海淀"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		user     string
+		location string
+		expected string
+	}{
+		{"alice", "home", "北京海淀"},
+		{"alice", "HOME", "北京海淀"},
+		{"alice", "上海", "上海"},
+		{"bob", "home", "home"},
+	}
+	for _, tt := range tests {
+		got, err := service.ResolveLocation(tt.user, tt.location)
+		if err != nil || got != tt.expected {
+			t.Errorf("Expected %s/%s to resolve to %s, got %s (%v)", tt.user, tt.location, tt.expected, got, err)
+		}
+	}
+
+	if _, err := service.SaveLocation("alice", "home", ""); err != nil {
+		t.Fatalf("Expected no error removing alias, got %v", err)
+	}
+	if got, _ := service.ResolveLocation("alice", "home"); got != "home" {
+		t.Errorf("Expected removed alias to be unresolved, got %s", got)
+	}
+}
+
+// userKey 测试用的请求用户上下文键
+type userKey struct{}
+
+func TestQueriesResolveRequestUserAliases(t *testing.T) {
+	repo := &fakeRepository{
+		weathers: map[string]*weather.Weather{"Beijing": {Location: weather.Location{City: "Beijing", Lat: 39.9, Lon: 116.4}}},
+		hourly:   map[string]*weather.HourlyWeatherResult{"Beijing": {Location: weather.Location{City: "Beijing"}}},
+	}
+	prefs := memoryPreferences{"alice": {Locations: map[string]string{"home": "Beijing"}}}
+	service := NewWeatherApplicationService(repo, WithPreferences(prefs), WithIdentity(func(ctx context.Context) string {
+		user, _ := ctx.Value(userKey{}).(string)
+		return user
+	}))
+	alice := context.WithValue(context.Background(), userKey{}, "alice")
+
+	for _, r := range service.GetWeatherBatch(alice, []string{"home"}, 0) {
+		if r.Err != nil || r.Weather.Location.City != "Beijing" {
+			t.Errorf("Expected batch query to resolve home, got %+v", r)
+		}
+	}
+	if w, err := service.GetCurrentSnapshot(alice, "home"); err != nil || w.Location.City != "Beijing" {
+		t.Errorf("Expected snapshot to resolve home, got %v (%v)", w, err)
+	}
+	if _, err := service.GetForecastSnapshot(alice, "home", 3); err != nil {
+		t.Errorf("Expected forecast snapshot to resolve home, got %v", err)
+	}
+
+	// 快照按解析后的位置缓存，其他用户的同名别名不会命中 alice 的快照
+	for _, known := range service.KnownLocations(alice) {
+		if known.Name == "home" {
+			t.Errorf("Expected snapshots keyed by resolved location, got %+v", known)
+		}
+	}
+	if _, err := service.GetCurrentSnapshot(context.Background(), "home"); err == nil {
+		t.Error("Expected unresolved alias to fail for another user")
+	}
+}
+
+func TestSaveLocationValidation(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{}, WithPreferences(memoryPreferences{}))
+	for i := 0; i < weather.MaxSavedLocations; i++ {
+		if _, err := service.SaveLocation("alice", fmt.Sprintf("place%d", i), "上海"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		alias    string
+		location string
+	}{
+		{"too many locations", "office", "上海"},
+		{"empty alias", " ", "上海"},
+		{"comma in alias", "a,b", "上海"},
+		{"invalid coordinates", "place0", "north,116.4"},
+		{"invalid city ID", "place0", "id:abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.SaveLocation("alice", tt.alias, tt.location); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	// 更新已有别名不受数量限制
+	if _, err := service.SaveLocation("alice", "place0", "广州"); err != nil {
+		t.Errorf("Expected updating existing alias to succeed, got %v", err)
+	}
+}
+
+func TestSetPreferences(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{}, WithPreferences(memoryPreferences{}))
+
+	if _, err := service.SetUnits("alice", "Imperial"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := service.SetLanguage("alice", "en"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	prefs, err := service.SetDefaultHours("alice", 6)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if prefs.Units != weather.UnitsImperial || prefs.Language != "en" || prefs.DefaultHours != 6 {
+		t.Errorf("Expected all preferences to be saved, got %+v", prefs)
+	}
+
+	if _, err := service.SetUnits("alice", "kelvin"); err == nil {
+		t.Error("Expected error for unsupported units")
+	}
+	if _, err := service.SetLanguage("alice", "fr"); err == nil {
+		t.Error("Expected error for unsupported language")
+	}
+	if _, err := service.SetDefaultHours("alice", 13); err == nil {
+		t.Error("Expected error for out of range default hours")
+	}
+}
+
+func TestPreferencesNotConfigured(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{})
+
+	prefs, err := service.GetPreferences("alice")
+	if err != nil || prefs == nil {
+		t.Fatalf("Expected empty preferences, got %+v (%v)", prefs, err)
+	}
+	if _, err := service.SaveLocation("alice", "home", "北京"); err == nil {
+		t.Error("Expected error when preferences are not configured")
+	}
+}
+
+func TestFormatWeatherResponseInUnits(t *testing.T) {
+	service := NewWeatherApplicationService(&fakeRepository{})
+	w := &weather.Weather{
+		Location: weather.Location{City: "Beijing", Country: "CN"},
+		Current:  weather.CurrentWeather{Temperature: 25, FeelsLike: 20, Humidity: 50, WindSpeed: 10},
+	}
+
+	output := service.FormatWeatherResponseInUnits(w, weather.UnitsImperial)
+	for _, line := range []string{"温度: 77.0°F (体感: 68.0°F)", "风速: 22.4 mph"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// RouteWaypoint 路线途经点请求
+type RouteWaypoint struct {
+	Location string
+	ETA      *time.Time // 指定的到达时间，为空时按平均速度推算
+}
+
+// RouteStop 途经点的预计到达时间及天气
+type RouteStop struct {
+	Name       string                 `json:"name"`
+	Location   weather.Location       `json:"location"`
+	ETA        time.Time              `json:"eta"`
+	DistanceKm float64                `json:"distance_km"` // 与上一途经点的大圆距离
+	Forecast   *weather.HourlyWeather `json:"forecast,omitempty"`
+	Hazards    []string               `json:"hazards,omitempty"`
+}
+
+// RouteSegment 相邻途经点之间的路段
+type RouteSegment struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	DistanceKm float64  `json:"distance_km"`
+	Hazardous  bool     `json:"hazardous"`
+	Hazards    []string `json:"hazards,omitempty"`
+}
+
+// RouteWeatherResult 路线天气结果
+type RouteWeatherResult struct {
+	TotalDistanceKm float64        `json:"total_distance_km"`
+	Stops           []RouteStop    `json:"stops"`
+	Segments        []RouteSegment `json:"segments"`
+}
+
+// PlanRouteWeather 获取路线各途经点在预计到达时间的天气
+// 各途经点的预报并发获取；未指定到达时间的途经点按 departure 和平均速度 speedKmh 推算。
+// 以坐标指定的途经点保留输入坐标，而不是预报城市的中心点。
+// 路段两端任一途经点存在危险天气时该路段标记为危险。
+func (s *WeatherApplicationService) PlanRouteWeather(ctx context.Context, waypoints []RouteWaypoint, departure time.Time, speedKmh float64) (*RouteWeatherResult, error) {
+	if len(waypoints) < 2 {
+		return nil, fmt.Errorf("at least 2 waypoints are required")
+	}
+
+	forecasts := make([]*weather.HourlyWeatherResult, len(waypoints))
+	locations := make([]weather.Location, len(waypoints))
+	errs := make([]error, len(waypoints))
+	s.runConcurrently(ctx, len(waypoints), func(i int) {
+		location := s.resolveAlias(ctx, waypoints[i].Location)
+		if forecasts[i], errs[i] = s.hourlyWeatherByLocation(ctx, location, MaxForecastHours); errs[i] != nil {
+			return
+		}
+		locations[i] = forecasts[i].Location
+		if q, err := parseLocationQuery(location); err == nil && q.kind == locationByCoords {
+			locations[i].Lat, locations[i].Lon = q.lat, q.lon
+		}
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get forecast for waypoint %d (%s): %w", i+1, waypoints[i].Location, err)
+		}
+	}
+
+	points := make([]weather.RoutePoint, len(waypoints))
+	for i, wp := range waypoints {
+		points[i] = weather.RoutePoint{Lat: locations[i].Lat, Lon: locations[i].Lon, ETA: wp.ETA}
+	}
+	etas, distances, err := weather.ComputeETAs(points, departure, speedKmh)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RouteWeatherResult{
+		Stops:    make([]RouteStop, len(waypoints)),
+		Segments: make([]RouteSegment, 0, len(waypoints)-1),
+	}
+	for i, wp := range waypoints {
+		loc := locations[i]
+		stop := RouteStop{
+			Name:       wp.Location,
+			Location:   loc,
+			ETA:        loc.LocalTime(etas[i]),
+			DistanceKm: distances[i],
+		}
+		if slot, ok := weather.SlotAt(forecasts[i].Hourly, etas[i]); ok {
+			stop.Forecast = &slot
+			stop.Hazards = weather.Hazards(slot)
+		}
+		result.Stops[i] = stop
+		result.TotalDistanceKm += distances[i]
+	}
+
+	for i := 1; i < len(result.Stops); i++ {
+		from, to := result.Stops[i-1], result.Stops[i]
+		segment := RouteSegment{From: from.Name, To: to.Name, DistanceKm: to.DistanceKm}
+		for _, stop := range []RouteStop{from, to} {
+			for _, h := range stop.Hazards {
+				segment.Hazards = append(segment.Hazards, fmt.Sprintf("%s: %s", stop.Name, h))
+			}
+		}
+		segment.Hazardous = len(segment.Hazards) > 0
+		result.Segments = append(result.Segments, segment)
+	}
+	return result, nil
+}
+
+// FormatRouteWeatherResponse 格式化路线天气响应
+func (s *WeatherApplicationService) FormatRouteWeatherResponse(result *RouteWeatherResult) string {
+	if result == nil || len(result.Stops) == 0 {
+		return "无法获取路线天气"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧭 路线天气（全程约 %.0f km）\n", result.TotalDistanceKm))
+	for i, stop := range result.Stops {
+		name := batchLocationName(stop.Name, stop.Location)
+		sb.WriteString(fmt.Sprintf("\n%d. 📍 %s\n", i+1, name))
+		sb.WriteString(fmt.Sprintf("   🕐 预计到达: %s", stop.ETA.Format(slotLayout)))
+		if i > 0 {
+			sb.WriteString(fmt.Sprintf("（距上一站 %.0f km）", stop.DistanceKm))
+		}
+		sb.WriteString("\n")
+		if stop.Forecast == nil {
+			sb.WriteString("   ❔ 到达时间超出预报范围\n")
+			continue
+		}
+		f := stop.Forecast
+		sb.WriteString(fmt.Sprintf("   🌡️  %.1f°C, %s, 风速 %.1f m/s, 降水概率 %.0f%%\n",
+			f.Temperature, f.Description, f.WindSpeed, f.PrecipProbability*100))
+		if len(stop.Hazards) > 0 {
+			sb.WriteString(fmt.Sprintf("   ⚠️  %s\n", strings.Join(stop.Hazards, "、")))
+		}
+	}
+
+	var hazardous []string
+	for _, seg := range result.Segments {
+		if seg.Hazardous {
+			hazardous = append(hazardous, fmt.Sprintf("%s → %s", seg.From, seg.To))
+		}
+	}
+	if len(hazardous) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠️ 危险路段: %s", strings.Join(hazardous, "；")))
+	} else {
+		sb.WriteString("\n✅ 沿途未发现危险天气")
+	}
+	return sb.String()
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestPlanRouteWeather(t *testing.T) {
+	departure := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	visibility := 10000
+	slots := func(temp, wind float64) []weather.HourlyWeather {
+		var hourly []weather.HourlyWeather
+		for i := 0; i < 8; i++ {
+			hourly = append(hourly, weather.HourlyWeather{
+				Date:        departure.Add(time.Duration(i*3) * time.Hour),
+				Temperature: temp + float64(i),
+				WindSpeed:   wind,
+				Visibility:  &visibility,
+			})
+		}
+		return hourly
+	}
+
+	// 沿赤道每1度约111.2km
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"A": {Location: weather.Location{City: "A", Lat: 0, Lon: 0}, Hourly: slots(10, 3)},
+			"B": {Location: weather.Location{City: "B", Lat: 0, Lon: 3}, Hourly: slots(20, 16)},
+			"C": {Location: weather.Location{City: "C", Lat: 0, Lon: 6}, Hourly: slots(30, 3)},
+			"D": {Location: weather.Location{City: "D", Lat: 0, Lon: 7}, Hourly: slots(30, 3)},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	late := departure.Add(30 * time.Hour)
+	waypoints := []RouteWaypoint{{Location: "A"}, {Location: "B"}, {Location: "C"}, {Location: "D", ETA: &late}}
+	result, err := service.PlanRouteWeather(context.Background(), waypoints, departure, 111.19)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Stops) != 4 || len(result.Segments) != 3 {
+		t.Fatalf("Expected 4 stops and 3 segments, got %d and %d", len(result.Stops), len(result.Segments))
+	}
+	if result.TotalDistanceKm < 778 || result.TotalDistanceKm > 779 {
+		t.Errorf("Expected total distance about 778.3 km, got %.1f", result.TotalDistanceKm)
+	}
+
+	// B 在出发3小时后到达，对应第2个时段
+	b := result.Stops[1]
+	if b.Forecast == nil || b.Forecast.Temperature != 21 {
+		t.Errorf("Expected B forecast at +3h, got %+v", b.Forecast)
+	}
+	if len(b.Hazards) != 1 {
+		t.Errorf("Expected wind hazard at B, got %v", b.Hazards)
+	}
+	if result.Stops[3].Forecast != nil {
+		t.Errorf("Expected no forecast beyond coverage, got %+v", result.Stops[3].Forecast)
+	}
+
+	expectedHazardous := []bool{true, true, false}
+	for i, seg := range result.Segments {
+		if seg.Hazardous != expectedHazardous[i] {
+			t.Errorf("Expected segment %s → %s hazardous %v, got %v", seg.From, seg.To, expectedHazardous[i], seg.Hazardous)
+		}
+	}
+
+	output := service.FormatRouteWeatherResponse(result)
+	for _, line := range []string{"全程约 778 km", "到达时间超出预报范围", "危险路段: A → B；B → C"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestPlanRouteWeatherCoordinateWaypoints(t *testing.T) {
+	now := time.Date(2026, 10, 20, 10, 5, 0, 0, time.UTC)
+	firstSlot := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	hourly := []weather.HourlyWeather{
+		{Date: firstSlot, Temperature: 12},
+		{Date: firstSlot.Add(3 * time.Hour), Temperature: 15},
+	}
+
+	// 坐标途经点的预报按所在城市返回，城市中心点与输入坐标不同
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"0.5,0.5": {Location: weather.Location{City: "A", Lat: 0, Lon: 0}, Hourly: hourly},
+			"0.5,1.5": {Location: weather.Location{City: "B", Lat: 0, Lon: 2}, Hourly: hourly},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	waypoints := []RouteWaypoint{{Location: "0.5,0.5"}, {Location: "0.5,1.5"}}
+	result, err := service.PlanRouteWeather(context.Background(), waypoints, now, 60)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	first := result.Stops[0]
+	if first.Location.Lat != 0.5 || first.Location.Lon != 0.5 {
+		t.Errorf("Expected input coordinates 0.5,0.5, got %v,%v", first.Location.Lat, first.Location.Lon)
+	}
+	if first.Location.City != "A" {
+		t.Errorf("Expected city A, got %q", first.Location.City)
+	}
+	if result.TotalDistanceKm < 111 || result.TotalDistanceKm > 112 {
+		t.Errorf("Expected distance between input coordinates about 111.2 km, got %.1f", result.TotalDistanceKm)
+	}
+	// 立即出发时首个时段尚未开始，应使用首个时段
+	if first.Forecast == nil || first.Forecast.Temperature != 12 {
+		t.Errorf("Expected first slot for departure now, got %+v", first.Forecast)
+	}
+}
+
+func TestPlanRouteWeatherErrors(t *testing.T) {
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"A": {Location: weather.Location{City: "A"}},
+			"B": {Location: weather.Location{City: "B", Lon: 1}},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+	departure := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		waypoints []RouteWaypoint
+		speed     float64
+	}{
+		{"single waypoint", []RouteWaypoint{{Location: "A"}}, 60},
+		{"unknown waypoint", []RouteWaypoint{{Location: "A"}, {Location: "Atlantis"}}, 60},
+		{"missing speed", []RouteWaypoint{{Location: "A"}, {Location: "B"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.PlanRouteWeather(context.Background(), tt.waypoints, departure, tt.speed); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultSnapshotTTL 天气快照默认有效期
+	DefaultSnapshotTTL = 10 * time.Minute
+	// maxRecentLocations 最多记录的最近查询位置数
+	maxRecentLocations = 50
+)
+
+// KnownLocation 已知或最近查询过的位置
+type KnownLocation struct {
+	Name        string            `json:"name"`
+	Location    *weather.Location `json:"location,omitempty"`
+	LastQueried *time.Time        `json:"last_queried,omitempty"` // 内置位置未查询过时为空
+}
+
+// snapshotEntry 单个位置的天气快照
+type snapshotEntry struct {
+	current     *weather.Weather
+	currentAt   time.Time
+	hourly      *weather.HourlyWeatherResult
+	hourlyAt    time.Time
+	lastQueried time.Time
+}
+
+// snapshotStore 最近查询位置的天气快照缓存
+type snapshotStore struct {
+	mu      sync.Mutex
+	entries map[string]*snapshotEntry
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{entries: make(map[string]*snapshotEntry)}
+}
+
+// entry 获取或创建位置的快照记录，调用方需持有锁
+func (st *snapshotStore) entry(location string, now time.Time) *snapshotEntry {
+	e, ok := st.entries[location]
+	if !ok {
+		e = &snapshotEntry{}
+		st.entries[location] = e
+		st.evict()
+	}
+	e.lastQueried = now
+	return e
+}
+
+// evict 超出容量时淘汰最久未查询的位置，调用方需持有锁
+func (st *snapshotStore) evict() {
+	for len(st.entries) > maxRecentLocations {
+		var oldest string
+		var oldestAt time.Time
+		for name, e := range st.entries {
+			if oldest == "" || e.lastQueried.Before(oldestAt) || (e.lastQueried.Equal(oldestAt) && name < oldest) {
+				oldest, oldestAt = name, e.lastQueried
+			}
+		}
+		delete(st.entries, oldest)
+	}
+}
+
+func (st *snapshotStore) putCurrent(location string, w *weather.Weather, now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e := st.entry(location, now)
+	e.current, e.currentAt = w, now
+}
+
+func (st *snapshotStore) putHourly(location string, hw *weather.HourlyWeatherResult, now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e := st.entry(location, now)
+	e.hourly, e.hourlyAt = hw, now
+}
+
+func (st *snapshotStore) getCurrent(location string, now time.Time, ttl time.Duration) (*weather.Weather, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.entries[location]
+	if !ok || e.current == nil || now.Sub(e.currentAt) > ttl {
+		return nil, false
+	}
+	return e.current, true
+}
+
+func (st *snapshotStore) getHourly(location string, hours int, now time.Time, ttl time.Duration) (*weather.HourlyWeatherResult, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.entries[location]
+	if !ok || e.hourly == nil || now.Sub(e.hourlyAt) > ttl {
+		return nil, false
+	}
+	// 缓存的时段不足以覆盖请求的小时数时视为未命中
+	points := (hours + 2) / 3
+	if len(e.hourly.Hourly) < points {
+		return nil, false
+	}
+	// 缓存可能来自更长时间的查询，只返回覆盖请求小时数的时段
+	trimmed := *e.hourly
+	trimmed.Hourly = e.hourly.Hourly[:points:points]
+	return &trimmed, true
+}
+
+// recent 返回最近查询过的位置，按查询时间倒序
+func (st *snapshotStore) recent() []KnownLocation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	locations := make([]KnownLocation, 0, len(st.entries))
+	for name, e := range st.entries {
+		lastQueried := e.lastQueried
+		known := KnownLocation{Name: name, LastQueried: &lastQueried}
+		switch {
+		case e.current != nil:
+			loc := e.current.Location
+			known.Location = &loc
+		case e.hourly != nil:
+			loc := e.hourly.Location
+			known.Location = &loc
+		}
+		locations = append(locations, known)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if !locations[i].LastQueried.Equal(*locations[j].LastQueried) {
+			return locations[i].LastQueried.After(*locations[j].LastQueried)
+		}
+		return locations[i].Name < locations[j].Name
+	})
+	return locations
+}
+
+// snapshotKey 规范化位置作为快照键
+func snapshotKey(location string) string {
+	return strings.TrimSpace(location)
+}
+
+// CacheObserver 快照缓存查询的观察者，用于统计命中率
+type CacheObserver interface {
+	ObserveCacheLookup(cache string, hit bool)
+}
+
+// 快照缓存名称
+const (
+	currentCacheName  = "current"
+	forecastCacheName = "forecast"
+)
+
+// WithCacheObserver 设置快照缓存查询的观察者
+func WithCacheObserver(observer CacheObserver) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		s.cacheObserver = observer
+	}
+}
+
+// observeCache 通知观察者一次缓存查询
+func (s *WeatherApplicationService) observeCache(cache string, hit bool) {
+	if s.cacheObserver != nil {
+		s.cacheObserver.ObserveCacheLookup(cache, hit)
+	}
+}
+
+// GetCurrentSnapshot 获取位置的实时天气快照，位置可以是请求用户保存的别名
+// 快照按解析后的位置缓存，未过期时直接返回缓存，否则重新查询
+func (s *WeatherApplicationService) GetCurrentSnapshot(ctx context.Context, location string) (*weather.Weather, error) {
+	location = s.resolveAlias(ctx, location)
+	_, span := startSpan(ctx, "cache_lookup", attribute.String("cache", currentCacheName), attribute.String("weather.location", location))
+	w, ok := s.snapshotsFor(ctx).getCurrent(snapshotKey(location), s.now(), s.snapshotTTL)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	span.End()
+	s.observeCache(currentCacheName, ok)
+	if ok {
+		return w, nil
+	}
+	return s.weatherByLocation(ctx, location)
+}
+
+// GetForecastSnapshot 获取位置的小时预报快照，位置可以是请求用户保存的别名
+// 快照未过期且覆盖请求的小时数时返回截取到请求小时数的缓存，否则重新查询
+func (s *WeatherApplicationService) GetForecastSnapshot(ctx context.Context, location string, hours int) (*weather.HourlyWeatherResult, error) {
+	location = s.resolveAlias(ctx, location)
+	_, span := startSpan(ctx, "cache_lookup", attribute.String("cache", forecastCacheName), attribute.String("weather.location", location))
+	hw, ok := s.snapshotsFor(ctx).getHourly(snapshotKey(location), hours, s.now(), s.snapshotTTL)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	span.End()
+	s.observeCache(forecastCacheName, ok)
+	if ok {
+		return hw, nil
+	}
+	return s.hourlyWeatherByLocation(ctx, location, hours)
+}
+
+// KnownLocations 获取已知位置列表
+// 包括最近查询过的位置（按查询时间倒序），以及数据源内置的位置
+func (s *WeatherApplicationService) KnownLocations(ctx context.Context) []KnownLocation {
+	locations := s.snapshotsFor(ctx).recent()
+	seen := make(map[string]bool, len(locations))
+	for _, l := range locations {
+		seen[l.Name] = true
+	}
+
+	if catalog, ok := s.weatherRepo.(weather.LocationCatalog); ok {
+		names := catalog.KnownLocations()
+		sort.Strings(names)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				locations = append(locations, KnownLocation{Name: name})
+			}
+		}
+	}
+	return locations
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// countingRepository 记录查询次数的测试仓储
+type countingRepository struct {
+	fakeRepository
+	calls int
+}
+
+func (r *countingRepository) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	r.calls++
+	return r.fakeRepository.GetWeatherByCity(ctx, city)
+}
+
+func (r *countingRepository) KnownLocations() []string {
+	return []string{"深圳", "北京"}
+}
+
+func TestCurrentSnapshotExpires(t *testing.T) {
+	repo := &countingRepository{fakeRepository: fakeRepository{
+		weathers: map[string]*weather.Weather{
+			"Beijing": {Location: weather.Location{City: "Beijing", Country: "CN"}},
+		},
+	}}
+	now := time.Unix(1700000000, 0)
+	service := NewWeatherApplicationService(repo,
+		WithSnapshotTTL(5*time.Minute),
+		WithClock(func() time.Time { return now }))
+
+	if _, err := service.GetCurrentSnapshot(context.Background(), "Beijing"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now = now.Add(4 * time.Minute)
+	if _, err := service.GetCurrentSnapshot(context.Background(), " Beijing "); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo.calls != 1 {
+		t.Errorf("Expected fresh snapshot to be served from cache, got %d upstream calls", repo.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := service.GetCurrentSnapshot(context.Background(), "Beijing"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo.calls != 2 {
+		t.Errorf("Expected expired snapshot to be refetched, got %d upstream calls", repo.calls)
+	}
+}
+
+func TestForecastSnapshotRequiresEnoughSlots(t *testing.T) {
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"Beijing": {Hourly: []weather.HourlyWeather{{Temperature: 20}}},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	if _, err := service.GetHourlyWeatherByLocation(context.Background(), "Beijing", 3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := service.snapshots.getHourly("Beijing", 3, service.now(), service.snapshotTTL); !ok {
+		t.Errorf("Expected cached forecast to cover 3 hours")
+	}
+	if _, ok := service.snapshots.getHourly("Beijing", 6, service.now(), service.snapshotTTL); ok {
+		t.Errorf("Expected cached forecast with one slot not to cover 6 hours")
+	}
+}
+
+func TestForecastSnapshotTrimmedToRequestedHours(t *testing.T) {
+	hourly := make([]weather.HourlyWeather, 8)
+	repo := &fakeRepository{
+		hourly: map[string]*weather.HourlyWeatherResult{
+			"Beijing": {Hourly: hourly},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	if _, err := service.GetHourlyWeatherByLocation(context.Background(), "Beijing", 24); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	hw, err := service.GetForecastSnapshot(context.Background(), "Beijing", 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(hw.Hourly) != 2 {
+		t.Errorf("Expected snapshot trimmed to 2 slots, got %d", len(hw.Hourly))
+	}
+	if cached, _ := service.snapshots.getHourly("Beijing", 24, service.now(), service.snapshotTTL); cached == nil || len(cached.Hourly) != 8 {
+		t.Errorf("Expected cached forecast to keep all 8 slots, got %+v", cached)
+	}
+}
+
+func TestKnownLocations(t *testing.T) {
+	repo := &countingRepository{fakeRepository: fakeRepository{
+		weathers: map[string]*weather.Weather{
+			"北京":       {Location: weather.Location{City: "Beijing", Country: "CN"}},
+			"Shanghai": {Location: weather.Location{City: "Shanghai", Country: "CN"}},
+		},
+	}}
+	now := time.Unix(1700000000, 0)
+	service := NewWeatherApplicationService(repo, WithClock(func() time.Time { return now }))
+
+	service.GetWeatherByLocation(context.Background(), "北京")
+	now = now.Add(time.Minute)
+	service.GetWeatherByLocation(context.Background(), "Shanghai")
+	service.GetWeatherByLocation(context.Background(), "Atlantis")
+
+	locations := service.KnownLocations(context.Background())
+	expected := []string{"Shanghai", "北京", "深圳"}
+	if len(locations) != len(expected) {
+		t.Fatalf("Expected %d locations, got %+v", len(expected), locations)
+	}
+	for i, name := range expected {
+		if locations[i].Name != name {
+			t.Errorf("Expected %s at position %d, got %s", name, i, locations[i].Name)
+		}
+	}
+	if locations[0].Location == nil || locations[0].Location.City != "Shanghai" {
+		t.Errorf("Expected resolved location for recent query, got %+v", locations[0])
+	}
+	if locations[2].LastQueried != nil {
+		t.Errorf("Expected catalog location without query time, got %+v", locations[2])
+	}
+}
+
+// recordingCacheObserver 记录缓存查询结果的测试观察者
+type recordingCacheObserver struct {
+	lookups []string
+}
+
+func (o *recordingCacheObserver) ObserveCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	o.lookups = append(o.lookups, cache+":"+result)
+}
+
+func TestSnapshotCacheObserver(t *testing.T) {
+	repo := &fakeRepository{
+		weathers: map[string]*weather.Weather{
+			"Beijing": {Location: weather.Location{City: "Beijing", Country: "CN"}},
+		},
+	}
+	observer := &recordingCacheObserver{}
+	service := NewWeatherApplicationService(repo, WithCacheObserver(observer))
+
+	service.GetCurrentSnapshot(context.Background(), "Beijing")
+	service.GetCurrentSnapshot(context.Background(), "Beijing")
+	service.GetForecastSnapshot(context.Background(), "Beijing", 6)
+
+	expected := []string{"current:miss", "current:hit", "forecast:miss"}
+	if strings.Join(observer.lookups, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected lookups %v, got %v", expected, observer.lookups)
+	}
+}
+
+func TestSnapshotsIsolatedByTenant(t *testing.T) {
+	repo := &fakeRepository{
+		weathers: map[string]*weather.Weather{
+			"Beijing": {Location: weather.Location{City: "Beijing", Country: "CN"}},
+		},
+	}
+	observer := &recordingCacheObserver{}
+	service := NewWeatherApplicationService(repo, WithCacheObserver(observer))
+	alice := WithTenant(context.Background(), "alice")
+
+	service.GetWeatherByLocation(alice, "Beijing")
+	service.GetCurrentSnapshot(alice, "Beijing")
+	service.GetCurrentSnapshot(context.Background(), "Beijing")
+
+	expected := []string{"current:hit", "current:miss"}
+	if strings.Join(observer.lookups, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected lookups %v, got %v", expected, observer.lookups)
+	}
+	if got := service.KnownLocations(WithTenant(context.Background(), "bob")); len(got) != 0 {
+		t.Errorf("Expected no recent locations for another tenant, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxTenantSnapshots 最多同时缓存快照的租户数
+const maxTenantSnapshots = 100
+
+// tenantKey 上下文中租户标识的键
+type tenantKey struct{}
+
+// WithTenant 在上下文中设置租户标识
+// 使用自带上游API密钥的客户端属于独立的租户，天气快照缓存按租户隔离
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 获取上下文中的租户标识，使用服务器默认密钥时返回空字符串
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// DetachContext 返回保留请求身份但不随请求结束而取消的上下文
+// 订阅轮询和监测规则检查等后台任务在请求结束后执行，需保留租户、上游API密钥和客户端身份等上下文值，
+// 才能以发起请求的租户身份查询；返回的上下文不属于原请求的 trace，后台任务的 span 各自成为新的 trace
+func DetachContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.WithoutCancel(ctx), trace.SpanContext{})
+}
+
+// tenantSnapshots 租户的快照缓存
+type tenantSnapshots struct {
+	store    *snapshotStore
+	lastUsed time.Time
+}
+
+// snapshotsFor 获取请求所属租户的快照缓存，默认租户使用服务的快照缓存
+// 租户数超出上限时淘汰最久未使用的租户缓存
+func (s *WeatherApplicationService) snapshotsFor(ctx context.Context) *snapshotStore {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return s.snapshots
+	}
+
+	s.tenantsMu.Lock()
+	defer s.tenantsMu.Unlock()
+	if s.tenants == nil {
+		s.tenants = make(map[string]*tenantSnapshots)
+	}
+	t, ok := s.tenants[tenant]
+	if !ok {
+		for len(s.tenants) >= maxTenantSnapshots {
+			var oldest string
+			for name, other := range s.tenants {
+				if oldest == "" || other.lastUsed.Before(s.tenants[oldest].lastUsed) {
+					oldest = name
+				}
+			}
+			delete(s.tenants, oldest)
+		}
+		t = &tenantSnapshots{store: newSnapshotStore()}
+		s.tenants[tenant] = t
+	}
+	t.lastUsed = s.now()
+	return t.store
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer 应用服务的追踪器，未注册 TracerProvider 时为空操作
+var tracer = otel.Tracer("weather-mcp-server/internal/application/services")
+
+// startSpan 创建应用服务内部步骤的子 span
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan 记录错误并结束 span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// parseLocation 解析位置并记录 parse_location span
+func parseLocation(ctx context.Context, location string) (locationQuery, error) {
+	_, span := startSpan(ctx, "parse_location", attribute.String("weather.location", location))
+	q, err := parseLocationQuery(location)
+	span.SetAttributes(
+		attribute.Bool("weather.location.is_coords", q.kind == locationByCoords),
+		attribute.String("weather.location.kind", q.kind.String()),
+	)
+	endSpan(span, err)
+	return q, err
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/internal/domain/weather"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestServiceSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	repo := &fakeRepository{
+		weathers: map[string]*weather.Weather{
+			"Beijing": {Location: weather.Location{City: "Beijing", Country: "CN"}},
+		},
+	}
+	service := NewWeatherApplicationService(repo)
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "root")
+	service.GetCurrentSnapshot(ctx, "Beijing")
+	if _, err := service.GetWeatherByLocation(ctx, "north,116.4"); err == nil {
+		t.Error("Expected invalid coordinates error")
+	}
+	root.End()
+
+	var names []string
+	failed := 0
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+		if span.Name != "root" && span.Parent.SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of the root span", span.Name)
+		}
+		if span.Status.Code == codes.Error {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected 1 failed span for invalid coordinates, got %d", failed)
+	}
+	expected := "cache_lookup,parse_location,parse_location,root"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("Expected spans %s, got %s", expected, strings.Join(names, ","))
+	}
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// DefaultWatchCooldown 监测规则触发后的默认冷却时间
+const DefaultWatchCooldown = time.Hour
+
+// DefaultWatchInterval 监测规则的默认检查间隔
+const DefaultWatchInterval = 10 * time.Minute
+
+// maxWatchRulesPerOwner 每个用户最多的监测规则数
+const maxWatchRulesPerOwner = 20
+
+// WatchEngine 阈值监测规则引擎
+// 按间隔对所有规则取数比较，满足条件且不在冷却期的规则触发事件并发布给所有发布器。
+type WatchEngine struct {
+	weatherService *WeatherApplicationService
+	store          weather.WatchRuleRepository
+	publishers     []weather.WatchEventPublisher
+	cooldown       time.Duration
+	now            func() time.Time
+
+	mu    sync.Mutex
+	rules []weather.WatchRule
+	// scopes 规则ID -> 添加规则的请求身份（租户、上游API密钥、客户端），检查规则时恢复
+	// 上游API密钥不能写入规则文件，因此只保存在内存中
+	scopes map[string]context.Context
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchOption 监测规则引擎配置项
+type WatchOption func(*WatchEngine)
+
+// WithWatchCooldown 设置规则触发后的默认冷却时间
+func WithWatchCooldown(d time.Duration) WatchOption {
+	return func(e *WatchEngine) {
+		if d > 0 {
+			e.cooldown = d
+		}
+	}
+}
+
+// WithWatchPublishers 设置触发事件的发布器
+func WithWatchPublishers(publishers ...weather.WatchEventPublisher) WatchOption {
+	return func(e *WatchEngine) {
+		e.publishers = append(e.publishers, publishers...)
+	}
+}
+
+// WithWatchClock 设置引擎使用的时钟，主要用于测试
+func WithWatchClock(now func() time.Time) WatchOption {
+	return func(e *WatchEngine) {
+		e.now = now
+	}
+}
+
+// NewWatchEngine 创建监测规则引擎并加载已保存的规则
+// store 为 nil 时规则仅保存在内存中
+func NewWatchEngine(weatherService *WeatherApplicationService, store weather.WatchRuleRepository, opts ...WatchOption) (*WatchEngine, error) {
+	e := &WatchEngine{
+		weatherService: weatherService,
+		store:          store,
+		cooldown:       DefaultWatchCooldown,
+		now:            time.Now,
+		scopes:         make(map[string]context.Context),
+		stop:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if store != nil {
+		rules, err := store.LoadWatchRules()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load watch rules: %w", err)
+		}
+		e.rules = rules
+	}
+	return e, nil
+}
+
+// AddRule 添加监测规则，返回规则及是否新建
+// 规则按 ctx 中的租户身份检查；与已有规则内容相同时不会重复添加，返回已有规则并更新其租户身份
+func (e *WatchEngine) AddRule(ctx context.Context, rule weather.WatchRule) (weather.WatchRule, bool, error) {
+	if err := rule.Normalize(); err != nil {
+		return weather.WatchRule{}, false, err
+	}
+	rule.Tenant = TenantFromContext(ctx)
+	rule.CreatedAt = e.now()
+	rule.LastTriggered = nil
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	owned := 0
+	for i, r := range e.rules {
+		if r.ID == rule.ID {
+			if r.Tenant != rule.Tenant {
+				rules := append([]weather.WatchRule(nil), e.rules...)
+				rules[i].Tenant = rule.Tenant
+				if err := e.saveLocked(rules); err != nil {
+					return weather.WatchRule{}, false, err
+				}
+			}
+			e.scopes[r.ID] = DetachContext(ctx)
+			return e.rules[i], false, nil
+		}
+		if r.Owner == rule.Owner {
+			owned++
+		}
+	}
+	if owned >= maxWatchRulesPerOwner {
+		return weather.WatchRule{}, false, fmt.Errorf("at most %d watch rules are allowed", maxWatchRulesPerOwner)
+	}
+
+	rules := append(append([]weather.WatchRule(nil), e.rules...), rule)
+	if err := e.saveLocked(rules); err != nil {
+		return weather.WatchRule{}, false, err
+	}
+	e.scopes[rule.ID] = DetachContext(ctx)
+	return rule, true, nil
+}
+
+// RemoveRule 删除用户的监测规则，返回是否存在该规则
+func (e *WatchEngine) RemoveRule(owner, id string) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]weather.WatchRule, 0, len(e.rules))
+	for _, r := range e.rules {
+		if r.Owner != owner || r.ID != id {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == len(e.rules) {
+		return false, nil
+	}
+	if err := e.saveLocked(rules); err != nil {
+		return true, err
+	}
+	delete(e.scopes, id)
+	return true, nil
+}
+
+// Rules 获取用户的监测规则，按创建时间排序
+func (e *WatchEngine) Rules(owner string) []weather.WatchRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var rules []weather.WatchRule
+	for _, r := range e.rules {
+		if r.Owner == owner {
+			rules = append(rules, r)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules
+}
+
+// Evaluate 检查一次所有规则，返回本次触发的事件
+// 每条规则以添加规则时的租户身份取数，同一次检查中同一租户相同位置的数据只查询一次；
+// 取数失败的规则跳过，等待下次检查。
+func (e *WatchEngine) Evaluate(ctx context.Context) []weather.WatchEvent {
+	now := e.now()
+
+	e.mu.Lock()
+	rules := append([]weather.WatchRule(nil), e.rules...)
+	scopes := make(map[string]context.Context, len(e.scopes))
+	for id, scope := range e.scopes {
+		scopes[id] = scope
+	}
+	e.mu.Unlock()
+
+	cache := newWatchCache(e.weatherService)
+	var events []weather.WatchEvent
+	for _, rule := range rules {
+		if rule.CoolingDown(now, e.cooldown) {
+			continue
+		}
+		ruleCtx := ctx
+		if scope, ok := scopes[rule.ID]; ok {
+			ruleCtx = scope
+		} else if rule.Tenant != "" {
+			// 重启后租户的上游API密钥已丢失，不能改用服务器密钥代为查询，等待租户重新添加规则
+			slog.DebugContext(ctx, "watch rule skipped until its tenant reconnects", "rule", rule.ID, "tenant", rule.Tenant)
+			continue
+		}
+		value, err := cache.measure(ruleCtx, rule)
+		if err != nil {
+			slog.WarnContext(ctx, "watch rule evaluation failed", "rule", rule.ID, "watch", rule.Describe(), "error", err)
+			continue
+		}
+		if rule.Matches(value) {
+			events = append(events, weather.NewWatchEvent(rule, value, now))
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	e.markTriggered(events, now)
+	for _, event := range events {
+		for _, p := range e.publishers {
+			if err := p.PublishWatchEvent(event); err != nil {
+				slog.WarnContext(ctx, "failed to publish watch event", "rule", event.Rule.ID, "error", err)
+			}
+		}
+	}
+	return events
+}
+
+// markTriggered 记录规则的触发时间并保存，规则在检查期间被删除时忽略
+// 保存失败时仍更新内存中的触发时间，保证冷却期生效，避免每次检查都重复触发
+func (e *WatchEngine) markTriggered(events []weather.WatchEvent, at time.Time) {
+	triggered := make(map[string]bool, len(events))
+	for _, event := range events {
+		triggered[event.Rule.ID] = true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rules := append([]weather.WatchRule(nil), e.rules...)
+	for i := range rules {
+		if triggered[rules[i].ID] {
+			t := at
+			rules[i].LastTriggered = &t
+		}
+	}
+	if err := e.saveLocked(rules); err != nil {
+		slog.Error("failed to save watch rule trigger times", "error", err)
+		e.rules = rules
+	}
+}
+
+// saveLocked 保存规则并在成功后替换内存中的规则，调用方需持有锁
+func (e *WatchEngine) saveLocked(rules []weather.WatchRule) error {
+	if e.store != nil {
+		if err := e.store.SaveWatchRules(rules); err != nil {
+			return fmt.Errorf("failed to save watch rules: %w", err)
+		}
+	}
+	e.rules = rules
+	return nil
+}
+
+// Run 在后台按间隔检查规则，直到调用 Close
+func (e *WatchEngine) Run(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+				e.Evaluate(context.Background())
+			}
+		}
+	}()
+}
+
+// Close 停止后台检查
+func (e *WatchEngine) Close() {
+	e.closeOnce.Do(func() { close(e.stop) })
+}
+
+// FormatWatchRulesResponse 格式化监测规则列表
+func (e *WatchEngine) FormatWatchRulesResponse(rules []weather.WatchRule) string {
+	if len(rules) == 0 {
+		return "ℹ️ 暂无天气监测规则"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔔 天气监测规则（共%d条）\n", len(rules)))
+	for _, r := range rules {
+		sb.WriteString(fmt.Sprintf("- [%s] %s", r.ID, r.Describe()))
+		if r.LastTriggered != nil {
+			sb.WriteString(fmt.Sprintf("，上次触发: %s", r.LastTriggered.Format(timestampLayout)))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// watchCache 单次检查中按租户和位置缓存的天气数据
+type watchCache struct {
+	service *WeatherApplicationService
+	current map[string]*weather.Weather
+	air     map[string]*weather.AirQuality
+	hourly  map[string]*weather.HourlyWeatherResult
+}
+
+// newWatchCache 创建单次检查的数据缓存
+func newWatchCache(service *WeatherApplicationService) *watchCache {
+	return &watchCache{
+		service: service,
+		current: make(map[string]*weather.Weather),
+		air:     make(map[string]*weather.AirQuality),
+		hourly:  make(map[string]*weather.HourlyWeatherResult),
+	}
+}
+
+// measure 获取规则指标的当前值，位置别名按规则所有者（而不是 ctx 中的请求用户）的偏好解析
+func (c *watchCache) measure(ctx context.Context, rule weather.WatchRule) (float64, error) {
+	location, err := c.service.ResolveLocation(rule.Owner, rule.Location)
+	if err != nil {
+		return 0, err
+	}
+	key := rule.Tenant + "|" + location
+
+	switch rule.Metric {
+	case weather.WatchTemperature, weather.WatchWindSpeed:
+		w, ok := c.current[key]
+		if !ok {
+			if w, err = c.service.weatherByLocation(ctx, location); err != nil {
+				return 0, err
+			}
+			c.current[key] = w
+		}
+		if rule.Metric == weather.WatchTemperature {
+			return w.Current.Temperature, nil
+		}
+		return w.Current.WindSpeed, nil
+
+	case weather.WatchPM25, weather.WatchAQI:
+		aq, ok := c.air[key]
+		if !ok {
+			if aq, err = c.service.airQualityByLocation(ctx, location); err != nil {
+				return 0, err
+			}
+			c.air[key] = aq
+		}
+		if rule.Metric == weather.WatchPM25 {
+			return aq.PM25, nil
+		}
+		return float64(aq.AQI), nil
+
+	case weather.WatchPrecipProbability:
+		hourlyKey := fmt.Sprintf("%s|%d", key, rule.WithinHours)
+		hw, ok := c.hourly[hourlyKey]
+		if !ok {
+			if hw, err = c.service.hourlyWeatherByLocation(ctx, location, rule.WithinHours); err != nil {
+				return 0, err
+			}
+			c.hourly[hourlyKey] = hw
+		}
+		return weather.SummarizePrecipitation(hw.Hourly).PeakProbability * 100, nil
+
+	default:
+		return 0, fmt.Errorf("unsupported metric: %s", rule.Metric)
+	}
+}
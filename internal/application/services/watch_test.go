@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// airRepository 支持空气质量数据的测试仓储
+type airRepository struct {
+	fakeRepository
+	air *weather.AirQuality
+}
+
+func (r *airRepository) GetAirQuality(ctx context.Context, lat, lon float64) (*weather.AirQuality, error) {
+	return r.air, nil
+}
+
+// memoryWatchStore 测试用监测规则仓储
+type memoryWatchStore struct {
+	rules []weather.WatchRule
+	saves int
+	err   error
+}
+
+func (s *memoryWatchStore) LoadWatchRules() ([]weather.WatchRule, error) {
+	return append([]weather.WatchRule(nil), s.rules...), nil
+}
+
+func (s *memoryWatchStore) SaveWatchRules(rules []weather.WatchRule) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.rules = append([]weather.WatchRule(nil), rules...)
+	s.saves++
+	return nil
+}
+
+// recordingPublisher 记录发布事件的测试发布器
+type recordingPublisher struct {
+	events []weather.WatchEvent
+}
+
+func (p *recordingPublisher) PublishWatchEvent(event weather.WatchEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func newWatchTestEngine(t *testing.T, now *time.Time, store *memoryWatchStore, publisher *recordingPublisher) *WatchEngine {
+	t.Helper()
+	repo := &airRepository{
+		fakeRepository: fakeRepository{
+			weathers: map[string]*weather.Weather{
+				"Beijing": {Location: weather.Location{City: "Beijing", Lat: 39.9, Lon: 116.4}, Current: weather.CurrentWeather{Temperature: 31}},
+			},
+			hourly: map[string]*weather.HourlyWeatherResult{
+				"Beijing": {Hourly: []weather.HourlyWeather{{PrecipProbability: 0.3}, {PrecipProbability: 0.8}}},
+			},
+		},
+		air: &weather.AirQuality{AQI: 5, PM25: 180},
+	}
+	prefs := memoryPreferences{"alice": {Locations: map[string]string{"office": "Beijing"}}}
+	service := NewWeatherApplicationService(repo, WithPreferences(prefs))
+
+	engine, err := NewWatchEngine(service, store,
+		WithWatchClock(func() time.Time { return *now }),
+		WithWatchPublishers(publisher))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return engine
+}
+
+func TestWatchEngineDeduplicates(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := &memoryWatchStore{}
+	engine := newWatchTestEngine(t, &now, store, &recordingPublisher{})
+
+	rule := weather.WatchRule{Owner: "alice", Location: "Beijing", Metric: weather.WatchPM25, Operator: weather.WatchAbove, Threshold: 150}
+	first, created, err := engine.AddRule(context.Background(), rule)
+	if err != nil || !created {
+		t.Fatalf("Expected rule to be created, got %v (%v)", created, err)
+	}
+	rule.Location = " beijing "
+	second, created, err := engine.AddRule(context.Background(), rule)
+	if err != nil || created || second.ID != first.ID {
+		t.Errorf("Expected duplicate rule to return %s, got %s (created %v, %v)", first.ID, second.ID, created, err)
+	}
+
+	// 不同用户的相同规则互不影响
+	rule.Owner = "bob"
+	bobs, created, _ := engine.AddRule(context.Background(), rule)
+	if !created || bobs.ID == first.ID {
+		t.Errorf("Expected a separate rule for another owner, got %s (created %v)", bobs.ID, created)
+	}
+	if len(engine.Rules("alice")) != 1 || len(store.rules) != 2 {
+		t.Errorf("Expected 1 rule for alice and 2 stored, got %d and %d", len(engine.Rules("alice")), len(store.rules))
+	}
+
+	if removed, _ := engine.RemoveRule("bob", first.ID); removed {
+		t.Error("Expected removing another owner's rule to fail")
+	}
+	if removed, err := engine.RemoveRule("bob", bobs.ID); err != nil || !removed {
+		t.Errorf("Expected bob's rule to be removed, got %v (%v)", removed, err)
+	}
+}
+
+func TestWatchEngineEvaluate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := &memoryWatchStore{}
+	publisher := &recordingPublisher{}
+	engine := newWatchTestEngine(t, &now, store, publisher)
+
+	rules := []weather.WatchRule{
+		{Owner: "alice", Location: "Beijing", Metric: weather.WatchPM25, Operator: weather.WatchAbove, Threshold: 150},
+		{Owner: "alice", Location: "office", Metric: weather.WatchPrecipProbability, Operator: weather.WatchAbove, Threshold: 70},
+		{Owner: "alice", Location: "Beijing", Metric: weather.WatchTemperature, Operator: weather.WatchBelow, Threshold: 0},
+		{Owner: "alice", Location: "Beijing", Metric: weather.WatchTemperature, Operator: weather.WatchAtOrAbove, Threshold: 30, CooldownMinutes: 10},
+	}
+	for _, r := range rules {
+		if _, _, err := engine.AddRule(context.Background(), r); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	events := engine.Evaluate(context.Background())
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %+v", len(events), events)
+	}
+	if events[1].Value != 80 {
+		t.Errorf("Expected peak precipitation probability 80 via alias, got %.1f", events[1].Value)
+	}
+	if len(publisher.events) != 3 {
+		t.Errorf("Expected 3 published events, got %d", len(publisher.events))
+	}
+
+	// 冷却期内不重复触发，自定义冷却时间较短的规则先恢复
+	now = now.Add(30 * time.Minute)
+	if events := engine.Evaluate(context.Background()); len(events) != 1 || events[0].Rule.Metric != weather.WatchTemperature {
+		t.Errorf("Expected only the short cooldown rule to trigger, got %+v", events)
+	}
+
+	now = now.Add(31 * time.Minute)
+	if events := engine.Evaluate(context.Background()); len(events) != 3 {
+		t.Errorf("Expected all matching rules to trigger after cooldown, got %d", len(events))
+	}
+
+	// 触发时间持久化，重新加载后冷却仍然有效
+	reloaded := newWatchTestEngine(t, &now, store, &recordingPublisher{})
+	if events := reloaded.Evaluate(context.Background()); len(events) != 0 {
+		t.Errorf("Expected persisted cooldown after reload, got %d events", len(events))
+	}
+}
+
+// tenantRecordingRepository 记录查询所属租户的测试仓储
+type tenantRecordingRepository struct {
+	fakeRepository
+	tenants []string
+}
+
+func (r *tenantRecordingRepository) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	r.tenants = append(r.tenants, TenantFromContext(ctx))
+	return r.fakeRepository.GetWeatherByCity(ctx, city)
+}
+
+func TestWatchEngineEvaluatesWithRuleTenant(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	repo := &tenantRecordingRepository{fakeRepository: fakeRepository{weathers: map[string]*weather.Weather{
+		"Beijing": {Location: weather.Location{City: "Beijing"}, Current: weather.CurrentWeather{Temperature: 31}},
+	}}}
+	store := &memoryWatchStore{}
+	newEngine := func() *WatchEngine {
+		engine, err := NewWatchEngine(NewWeatherApplicationService(repo), store, WithWatchClock(func() time.Time { return now }))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return engine
+	}
+	engine := newEngine()
+
+	requestCtx, cancel := context.WithCancel(WithTenant(context.Background(), "tenant-a"))
+	rule, _, err := engine.AddRule(requestCtx, weather.WatchRule{Owner: "alice", Location: "Beijing", Metric: weather.WatchTemperature, Operator: weather.WatchAbove, Threshold: 30})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	engine.AddRule(context.Background(), weather.WatchRule{Owner: "bob", Location: "Beijing", Metric: weather.WatchTemperature, Operator: weather.WatchAbove, Threshold: 30})
+	cancel()
+	if rule.Tenant != "tenant-a" {
+		t.Errorf("Expected rule to record tenant-a, got %q", rule.Tenant)
+	}
+
+	// 请求结束后仍以各自规则的租户身份查询，同一位置不会在租户间共享数据
+	if events := engine.Evaluate(context.Background()); len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if got := strings.Join(repo.tenants, ","); got != "tenant-a," {
+		t.Errorf("Expected queries as tenant-a and the default tenant, got %q", got)
+	}
+
+	// 重启后租户的密钥不可用，不改用服务器密钥查询
+	repo.tenants = nil
+	now = now.Add(2 * time.Hour)
+	if events := newEngine().Evaluate(context.Background()); len(events) != 1 || events[0].Rule.Owner != "bob" {
+		t.Errorf("Expected only the default tenant rule to be evaluated after reload, got %+v", events)
+	}
+	if len(repo.tenants) != 1 || repo.tenants[0] != "" {
+		t.Errorf("Expected only a default tenant query, got %q", repo.tenants)
+	}
+}
+
+func TestWatchEngineCooldownSurvivesSaveFailure(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := &memoryWatchStore{}
+	engine := newWatchTestEngine(t, &now, store, &recordingPublisher{})
+	rule := weather.WatchRule{Owner: "alice", Location: "Beijing", Metric: weather.WatchPM25, Operator: weather.WatchAbove, Threshold: 150}
+	if _, _, err := engine.AddRule(context.Background(), rule); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	store.err = errors.New("disk full")
+	if events := engine.Evaluate(context.Background()); len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	now = now.Add(time.Minute)
+	if events := engine.Evaluate(context.Background()); len(events) != 0 {
+		t.Errorf("Expected cooldown to apply when saving fails, got %d events", len(events))
+	}
+	if rules := engine.Rules("alice"); len(rules) != 1 || rules[0].LastTriggered == nil {
+		t.Errorf("Expected trigger time in memory, got %+v", rules)
+	}
+}
@@ -1,96 +1,419 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"weather-mcp-server/internal/domain/weather"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// timestampLayout 更新时间格式，带时区
+	timestampLayout = "2006-01-02 15:04:05 MST"
+	// slotLayout 预报时段格式，带时区
+	slotLayout = "2006-01-02 15:04 MST"
 )
 
+// DefaultMaxConcurrency 批量查询默认的最大并发数
+const DefaultMaxConcurrency = 4
+
 // WeatherApplicationService 天气应用服务
 type WeatherApplicationService struct {
-	weatherRepo weather.WeatherRepository
+	weatherRepo    weather.WeatherRepository
+	maxConcurrency int
+	// limiter 批量类查询共享的并发名额，容量为 maxConcurrency
+	limiter       chan struct{}
+	snapshots     *snapshotStore
+	snapshotTTL   time.Duration
+	activityRules []weather.ActivityRule
+	preferences   weather.PreferencesRepository
+	preferencesMu sync.Mutex
+	identify      func(context.Context) string
+	cacheObserver CacheObserver
+	tenants       map[string]*tenantSnapshots
+	tenantsMu     sync.Mutex
+	now           func() time.Time
+}
+
+// ServiceOption 天气应用服务配置项
+type ServiceOption func(*WeatherApplicationService)
+
+// WithMaxConcurrency 设置批量查询（批量、对比和路线）对上游的最大并发请求数，所有请求共享该上限
+func WithMaxConcurrency(n int) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		if n > 0 {
+			s.maxConcurrency = n
+		}
+	}
+}
+
+// WithSnapshotTTL 设置天气快照的有效期
+func WithSnapshotTTL(ttl time.Duration) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		if ttl > 0 {
+			s.snapshotTTL = ttl
+		}
+	}
+}
+
+// WithClock 设置服务使用的时钟，主要用于测试
+func WithClock(now func() time.Time) ServiceOption {
+	return func(s *WeatherApplicationService) {
+		s.now = now
+	}
 }
 
 // NewWeatherApplicationService 创建新的天气应用服务
-func NewWeatherApplicationService(weatherRepo weather.WeatherRepository) *WeatherApplicationService {
-	return &WeatherApplicationService{
-		weatherRepo: weatherRepo,
-	}
-}
-
-// GetWeatherByLocation 根据位置获取天气
-func (s *WeatherApplicationService) GetWeatherByLocation(location string) (*weather.Weather, error) {
-	// 检查是否是坐标格式 (lat,lon)
-	if strings.Contains(location, ",") {
-		coords := strings.Split(location, ",")
-		if len(coords) == 2 {
-			lat, err := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
-			if err != nil {
-				return nil, fmt.Errorf("invalid latitude: %w", err)
-			}
-			lon, err := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
-			if err != nil {
-				return nil, fmt.Errorf("invalid longitude: %w", err)
-			}
-			return s.weatherRepo.GetCurrentWeather(lat, lon)
+func NewWeatherApplicationService(weatherRepo weather.WeatherRepository, opts ...ServiceOption) *WeatherApplicationService {
+	s := &WeatherApplicationService{
+		weatherRepo:    weatherRepo,
+		maxConcurrency: DefaultMaxConcurrency,
+		snapshots:      newSnapshotStore(),
+		snapshotTTL:    DefaultSnapshotTTL,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.limiter = make(chan struct{}, s.maxConcurrency)
+	return s
+}
+
+// GetWeatherByLocation 根据位置获取天气，位置可以是请求用户保存的别名
+func (s *WeatherApplicationService) GetWeatherByLocation(ctx context.Context, location string) (*weather.Weather, error) {
+	return s.weatherByLocation(ctx, s.resolveAlias(ctx, location))
+}
+
+// weatherByLocation 根据已解析别名的位置获取天气，并更新快照
+func (s *WeatherApplicationService) weatherByLocation(ctx context.Context, location string) (*weather.Weather, error) {
+	q, err := parseLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.currentWeather(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	s.snapshotsFor(ctx).putCurrent(snapshotKey(location), w, s.now())
+	return w, nil
+}
+
+// currentWeather 按解析后的位置查询实时天气
+func (s *WeatherApplicationService) currentWeather(ctx context.Context, q locationQuery) (*weather.Weather, error) {
+	switch q.kind {
+	case locationByCoords:
+		return s.weatherRepo.GetCurrentWeather(ctx, q.lat, q.lon)
+	case locationByCityID:
+		repo, err := s.placeLookup()
+		if err != nil {
+			return nil, err
+		}
+		return repo.GetWeatherByCityID(ctx, q.cityID)
+	case locationByPostalCode:
+		repo, err := s.placeLookup()
+		if err != nil {
+			return nil, err
+		}
+		return repo.GetWeatherByPostalCode(ctx, q.postalCode)
+	default:
+		return s.weatherRepo.GetWeatherByCity(ctx, q.name)
+	}
+}
+
+// GetHourlyWeatherByLocation 获取未来小时天气预报，位置可以是请求用户保存的别名
+func (s *WeatherApplicationService) GetHourlyWeatherByLocation(ctx context.Context, location string, hours int) (*weather.HourlyWeatherResult, error) {
+	return s.hourlyWeatherByLocation(ctx, s.resolveAlias(ctx, location), hours)
+}
+
+// hourlyWeatherByLocation 根据已解析别名的位置获取小时预报，并更新快照
+func (s *WeatherApplicationService) hourlyWeatherByLocation(ctx context.Context, location string, hours int) (*weather.HourlyWeatherResult, error) {
+	q, err := parseLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	var hw *weather.HourlyWeatherResult
+	switch q.kind {
+	case locationByCoords:
+		hw, err = s.weatherRepo.GetHourlyWeatherByCoords(ctx, q.lat, q.lon, hours)
+	case locationByCityID, locationByPostalCode:
+		var repo weather.PlaceLookupRepository
+		if repo, err = s.placeLookup(); err != nil {
+			break
+		}
+		if q.kind == locationByCityID {
+			hw, err = repo.GetHourlyWeatherByCityID(ctx, q.cityID, hours)
+		} else {
+			hw, err = repo.GetHourlyWeatherByPostalCode(ctx, q.postalCode, hours)
 		}
+	default:
+		hw, err = s.weatherRepo.GetHourlyWeatherByCity(ctx, q.name, hours)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.snapshotsFor(ctx).putHourly(snapshotKey(location), hw, s.now())
+	return hw, nil
+}
+
+// GetAirQualityByLocation 获取当前空气质量，位置可以是请求用户保存的别名
+// 非坐标的位置会先通过实时天气解析出坐标
+func (s *WeatherApplicationService) GetAirQualityByLocation(ctx context.Context, location string) (*weather.AirQuality, error) {
+	return s.airQualityByLocation(ctx, s.resolveAlias(ctx, location))
+}
+
+// airQualityByLocation 根据已解析别名的位置获取空气质量
+// 以坐标指定的位置不查询实时天气，时区未知，测量时间为UTC
+func (s *WeatherApplicationService) airQualityByLocation(ctx context.Context, location string) (*weather.AirQuality, error) {
+	q, err := parseLocation(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	loc := weather.Location{Lat: q.lat, Lon: q.lon}
+	if q.kind != locationByCoords {
+		geoCtx, span := startSpan(ctx, "geocode", attribute.String("weather.location", location))
+		w, err := s.currentWeather(geoCtx, q)
+		endSpan(span, err)
+		if err != nil {
+			return nil, err
+		}
+		loc = w.Location
+	}
+	return s.getAirQuality(ctx, loc)
+}
+
+// placeLookup 获取支持按城市ID和邮政编码查询的仓储
+func (s *WeatherApplicationService) placeLookup() (weather.PlaceLookupRepository, error) {
+	repo, ok := s.weatherRepo.(weather.PlaceLookupRepository)
+	if !ok {
+		return nil, fmt.Errorf("lookup by city ID or postal code is not supported by the current provider")
+	}
+	return repo, nil
+}
+
+// getAirQuality 通过支持空气质量的仓储获取位置的数据，测量时间转换到位置所在时区
+func (s *WeatherApplicationService) getAirQuality(ctx context.Context, location weather.Location) (*weather.AirQuality, error) {
+	repo, ok := s.weatherRepo.(weather.AirQualityRepository)
+	if !ok {
+		return nil, fmt.Errorf("air quality data is not supported by the current provider")
+	}
+	aq, err := repo.GetAirQuality(ctx, location.Lat, location.Lon)
+	if err != nil {
+		return nil, err
+	}
+	aq.MeasuredAt = location.LocalTime(aq.MeasuredAt)
+	return aq, nil
+}
+
+// 位置字符串的前缀，用于按数据源城市ID或邮政编码查询
+const (
+	cityIDPrefix     = "id:"
+	postalCodePrefix = "zip:"
+)
+
+// defaultPostalCountry 邮政编码未指定国家时使用的国家代码
+const defaultPostalCountry = "CN"
+
+// locationKind 位置字符串的格式
+type locationKind int
+
+const (
+	locationByName locationKind = iota
+	locationByCoords
+	locationByCityID
+	locationByPostalCode
+)
+
+// String 返回格式名称，用于追踪属性
+func (k locationKind) String() string {
+	switch k {
+	case locationByCoords:
+		return "coords"
+	case locationByCityID:
+		return "city_id"
+	case locationByPostalCode:
+		return "postal_code"
+	default:
+		return "name"
 	}
+}
 
-	// 否则按城市名处理
-	return s.weatherRepo.GetWeatherByCity(location)
-}
-
-// GetHourlyWeatherByLocation 获取未来小时天气预报
-func (s *WeatherApplicationService) GetHourlyWeatherByLocation(location string, hours int) (*weather.HourlyWeatherResult, error) {
-	if strings.Contains(location, ",") {
-		coords := strings.Split(location, ",")
-		if len(coords) == 2 {
-			lat, err := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
-			if err != nil {
-				return nil, fmt.Errorf("invalid latitude: %w", err)
-			}
-			lon, err := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
-			if err != nil {
-				return nil, fmt.Errorf("invalid longitude: %w", err)
-			}
-			return s.weatherRepo.GetHourlyWeatherByCoords(lat, lon, hours)
+// locationQuery 解析后的位置
+type locationQuery struct {
+	kind       locationKind
+	name       string
+	lat, lon   float64
+	cityID     int64
+	postalCode weather.PostalCode
+}
+
+// parseLocationQuery 解析位置字符串
+// 支持 id:城市ID、zip:邮政编码[,国家代码]、坐标 (lat,lon)，其余按城市名处理
+func parseLocationQuery(location string) (locationQuery, error) {
+	trimmed := strings.TrimSpace(location)
+	lower := strings.ToLower(trimmed)
+	switch {
+	case strings.HasPrefix(lower, cityIDPrefix):
+		id, err := strconv.ParseInt(strings.TrimSpace(trimmed[len(cityIDPrefix):]), 10, 64)
+		if err != nil || id <= 0 {
+			return locationQuery{}, fmt.Errorf("invalid city ID %q: must be a positive integer", trimmed[len(cityIDPrefix):])
+		}
+		return locationQuery{kind: locationByCityID, cityID: id}, nil
+	case strings.HasPrefix(lower, postalCodePrefix):
+		code, err := parsePostalCode(trimmed[len(postalCodePrefix):])
+		if err != nil {
+			return locationQuery{}, err
 		}
+		return locationQuery{kind: locationByPostalCode, postalCode: code}, nil
 	}
-	return s.weatherRepo.GetHourlyWeatherByCity(location, hours)
+
+	lat, lon, isCoords, err := parseCoordinates(location)
+	if err != nil {
+		return locationQuery{}, err
+	}
+	if isCoords {
+		return locationQuery{kind: locationByCoords, lat: lat, lon: lon}, nil
+	}
+	return locationQuery{kind: locationByName, name: location}, nil
 }
 
-// FormatWeatherResponse 格式化天气响应
+// parsePostalCode 解析 邮政编码[,国家代码] 形式的邮政编码
+func parsePostalCode(s string) (weather.PostalCode, error) {
+	code, rawCountry, _ := strings.Cut(s, ",")
+	code = strings.TrimSpace(code)
+	rawCountry = strings.TrimSpace(rawCountry)
+	country := strings.ToUpper(rawCountry)
+	if country == "" {
+		country = defaultPostalCountry
+	}
+	if code == "" || strings.ContainsAny(code, ",;&?#/") {
+		return weather.PostalCode{}, fmt.Errorf("invalid postal code %q", s)
+	}
+	if len(country) != 2 || strings.Trim(country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return weather.PostalCode{}, fmt.Errorf("invalid country code %q: must be a two-letter ISO 3166 code", rawCountry)
+	}
+	return weather.PostalCode{Code: code, Country: country}, nil
+}
+
+// parseCoordinates 解析坐标格式 (lat,lon) 的位置
+// 不是坐标格式时 isCoords 为 false
+func parseCoordinates(location string) (lat, lon float64, isCoords bool, err error) {
+	if !strings.Contains(location, ",") {
+		return 0, 0, false, nil
+	}
+	coords := strings.Split(location, ",")
+	if len(coords) != 2 {
+		return 0, 0, false, nil
+	}
+	lat, err = strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("invalid latitude: %w", err)
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("invalid longitude: %w", err)
+	}
+	return lat, lon, true, nil
+}
+
+// FormatWeatherResponse 格式化天气响应（公制单位）
 func (s *WeatherApplicationService) FormatWeatherResponse(w *weather.Weather) string {
+	return s.FormatWeatherResponseInUnits(w, weather.UnitsMetric)
+}
+
+// FormatWeatherResponseInUnits 按指定单位制格式化天气响应
+func (s *WeatherApplicationService) FormatWeatherResponseInUnits(w *weather.Weather, u weather.Units) string {
 	if w == nil {
 		return "无法获取天气信息"
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("📍 %s, %s\n", w.Location.City, w.Location.Country))
-	sb.WriteString(fmt.Sprintf("🌡️  温度: %.1f°C (体感: %.1f°C)\n", w.Current.Temperature, w.Current.FeelsLike))
+	sb.WriteString(locationHeader(w.Location))
+	sb.WriteString(fmt.Sprintf("🌡️  温度: %s (体感: %s)\n", u.Temperature(w.Current.Temperature), u.Temperature(w.Current.FeelsLike)))
 	sb.WriteString(fmt.Sprintf("💧 湿度: %d%%\n", w.Current.Humidity))
-	sb.WriteString(fmt.Sprintf("🌪️  风速: %.1f m/s (%s)\n", w.Current.WindSpeed, w.Current.WindDir))
+	sb.WriteString(fmt.Sprintf("🌪️  风速: %s (%s)\n", u.Speed(w.Current.WindSpeed), w.Current.WindDir))
 	sb.WriteString(fmt.Sprintf("🌡️  气压: %d hPa\n", w.Current.Pressure))
 	sb.WriteString(fmt.Sprintf("☁️  天气: %s\n", w.Current.Description))
-	sb.WriteString(fmt.Sprintf("🕐 更新时间: %s", w.LastUpdated.Format("2006-01-02 15:04:05")))
+	indices := weather.ComputeCurrentIndices(w.Current)
+	sb.WriteString(fmt.Sprintf("😊 舒适度: %s (露点: %s)\n", indices.Comfort.Label, u.Temperature(indices.DewPoint)))
+	sb.WriteString(fmt.Sprintf("👕 穿衣: %s，%s\n", indices.Clothing.Label, indices.Clothing.Advice))
+	if indices.UVRisk != nil {
+		sb.WriteString(fmt.Sprintf("☀️  紫外线: %s，%s\n", indices.UVRisk.Label, indices.UVRisk.Advice))
+	}
+	if indices.FrostRisk != weather.FrostRiskNone {
+		sb.WriteString(fmt.Sprintf("❄️  霜冻风险: %s\n", frostRiskLabel(indices.FrostRisk)))
+	}
+	sb.WriteString(fmt.Sprintf("🕐 更新时间: %s", w.Location.LocalTime(w.LastUpdated).Format(timestampLayout)))
 
 	return sb.String()
 }
 
-// FormatHourlyWeatherResponse 格式化小时级天气响应
+// FormatHourlyWeatherResponse 格式化小时级天气响应（公制单位）
 func (s *WeatherApplicationService) FormatHourlyWeatherResponse(hw *weather.HourlyWeatherResult) string {
+	return s.FormatHourlyWeatherResponseInUnits(hw, weather.UnitsMetric)
+}
+
+// FormatHourlyWeatherResponseInUnits 按指定单位制格式化小时级天气响应
+func (s *WeatherApplicationService) FormatHourlyWeatherResponseInUnits(hw *weather.HourlyWeatherResult, u weather.Units) string {
 	if hw == nil || len(hw.Hourly) == 0 {
 		return "无法获取小时级天气预报信息"
 	}
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("📍 %s, %s\n", hw.Location.City, hw.Location.Country))
+	sb.WriteString(locationHeader(hw.Location))
 	for i, h := range hw.Hourly {
-		sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, h.Date.Format("2006-01-02 15:04")))
-		sb.WriteString(fmt.Sprintf("  🌡️ %.1f°C (体感: %.1f°C), 💧%d%%, 🌪️ %.1fm/s(%s), ☁️ %s\n",
-			h.Temperature, h.FeelsLike, h.Humidity, h.WindSpeed, h.WindDir, h.Description))
+		sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, hw.Location.LocalTime(h.Date).Format(slotLayout)))
+		sb.WriteString(fmt.Sprintf("  🌡️ %s (体感: %s), 💧%d%%, 🌪️ %s (%s), ☁️ %s\n",
+			u.Temperature(h.Temperature), u.Temperature(h.FeelsLike), h.Humidity, u.Speed(h.WindSpeed), h.WindDir, h.Description))
+		sb.WriteString(fmt.Sprintf("  ☔ 降水概率: %.0f%%, 降水量: %.1fmm (%s)\n",
+			h.PrecipProbability*100, h.PrecipAmount, precipitationTypeLabel(h.PrecipType)))
 	}
-	sb.WriteString(fmt.Sprintf("🕐 更新时间: %s", hw.LastUpdated.Format("2006-01-02 15:04:05")))
+	summary := weather.SummarizePrecipitation(hw.Hourly)
+	sb.WriteString(fmt.Sprintf("☔ 累计降水: %.1fmm, 最高降水概率: %.0f%% (%s)\n",
+		summary.TotalAmount, summary.PeakProbability*100, hw.Location.LocalTime(summary.PeakAt).Format(slotLayout)))
+	sb.WriteString(fmt.Sprintf("🕐 更新时间: %s", hw.Location.LocalTime(hw.LastUpdated).Format(timestampLayout)))
 	return sb.String()
 }
+
+// locationHeader 格式化位置标题行
+// 数据源返回了城市ID时一并展示，便于后续查询用 id:城市ID 固定到同一位置
+func locationHeader(l weather.Location) string {
+	if l.ID != 0 {
+		return fmt.Sprintf("📍 %s, %s (%s%d)\n", l.City, l.Country, cityIDPrefix, l.ID)
+	}
+	return fmt.Sprintf("📍 %s, %s\n", l.City, l.Country)
+}
+
+// precipitationTypeLabel 获取降水类型的中文名称
+func precipitationTypeLabel(t weather.PrecipitationType) string {
+	switch t {
+	case weather.PrecipitationRain:
+		return "雨"
+	case weather.PrecipitationSnow:
+		return "雪"
+	case weather.PrecipitationMixed:
+		return "雨夹雪"
+	default:
+		return "无降水"
+	}
+}
+
+// frostRiskLabel 获取霜冻风险的中文名称
+func frostRiskLabel(r weather.FrostRisk) string {
+	switch r {
+	case weather.FrostRiskHigh:
+		return "高"
+	case weather.FrostRiskModerate:
+		return "中"
+	case weather.FrostRiskLow:
+		return "低"
+	default:
+		return "无"
+	}
+}
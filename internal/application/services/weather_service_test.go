@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestParseLocationQuery(t *testing.T) {
+	tests := []struct {
+		location string
+		expected locationQuery
+	}{
+		{"北京", locationQuery{kind: locationByName, name: "北京"}},
+		{"39.9042, 116.4074", locationQuery{kind: locationByCoords, lat: 39.9042, lon: 116.4074}},
+		{"id:1816670", locationQuery{kind: locationByCityID, cityID: 1816670}},
+		{" ID: 1816670 ", locationQuery{kind: locationByCityID, cityID: 1816670}},
+		{"zip:100000,CN", locationQuery{kind: locationByPostalCode, postalCode: weather.PostalCode{Code: "100000", Country: "CN"}}},
+		{"zip:94040,us", locationQuery{kind: locationByPostalCode, postalCode: weather.PostalCode{Code: "94040", Country: "US"}}},
+		{"zip:SW1A 1AA,GB", locationQuery{kind: locationByPostalCode, postalCode: weather.PostalCode{Code: "SW1A 1AA", Country: "GB"}}},
+		{"zip:100000", locationQuery{kind: locationByPostalCode, postalCode: weather.PostalCode{Code: "100000", Country: "CN"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			q, err := parseLocationQuery(tt.location)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if q != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, q)
+			}
+		})
+	}
+
+	invalid := []string{"id:", "id:0", "id:xian", "zip:", "zip:,CN", "zip:100000,China", "zip:100&appid=x", "north,116.4"}
+	for _, location := range invalid {
+		if _, err := parseLocationQuery(location); err == nil {
+			t.Errorf("Expected error for %q", location)
+		}
+	}
+}
+
+// placeLookupRepository 支持按城市ID和邮政编码查询的测试仓储
+type placeLookupRepository struct {
+	fakeRepository
+	byID   map[int64]*weather.Weather
+	byCode map[weather.PostalCode]*weather.Weather
+}
+
+func (r *placeLookupRepository) GetWeatherByCityID(ctx context.Context, id int64) (*weather.Weather, error) {
+	if w, ok := r.byID[id]; ok {
+		return w, nil
+	}
+	return nil, errors.New("API request failed with status: 404")
+}
+
+func (r *placeLookupRepository) GetHourlyWeatherByCityID(ctx context.Context, id int64, hours int) (*weather.HourlyWeatherResult, error) {
+	if w, ok := r.byID[id]; ok {
+		return &weather.HourlyWeatherResult{Location: w.Location}, nil
+	}
+	return nil, errors.New("API request failed with status: 404")
+}
+
+func (r *placeLookupRepository) GetWeatherByPostalCode(ctx context.Context, code weather.PostalCode) (*weather.Weather, error) {
+	if w, ok := r.byCode[code]; ok {
+		return w, nil
+	}
+	return nil, errors.New("API request failed with status: 404")
+}
+
+func (r *placeLookupRepository) GetHourlyWeatherByPostalCode(ctx context.Context, code weather.PostalCode, hours int) (*weather.HourlyWeatherResult, error) {
+	if w, ok := r.byCode[code]; ok {
+		return &weather.HourlyWeatherResult{Location: w.Location}, nil
+	}
+	return nil, errors.New("API request failed with status: 404")
+}
+
+func TestLookupByCityIDAndPostalCode(t *testing.T) {
+	beijing := &weather.Weather{Location: weather.Location{ID: 1816670, City: "Beijing", Country: "CN"}}
+	repo := &placeLookupRepository{
+		byID:   map[int64]*weather.Weather{1816670: beijing},
+		byCode: map[weather.PostalCode]*weather.Weather{{Code: "100000", Country: "CN"}: beijing},
+	}
+	service := NewWeatherApplicationService(repo)
+	ctx := context.Background()
+
+	for _, location := range []string{"id:1816670", "zip:100000,CN", "zip:100000"} {
+		w, err := service.GetWeatherByLocation(ctx, location)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", location, err)
+		}
+		if w.Location.ID != 1816670 {
+			t.Errorf("%s: expected city ID 1816670, got %d", location, w.Location.ID)
+		}
+		hw, err := service.GetHourlyWeatherByLocation(ctx, location, 3)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", location, err)
+		}
+		if hw.Location.City != "Beijing" {
+			t.Errorf("%s: expected Beijing, got %s", location, hw.Location.City)
+		}
+	}
+
+	if !strings.HasPrefix(service.FormatWeatherResponse(beijing), "📍 Beijing, CN (id:1816670)\n") {
+		t.Errorf("Expected city ID in response header, got %q", service.FormatWeatherResponse(beijing))
+	}
+
+	// 数据源不支持时返回明确的错误
+	unsupported := NewWeatherApplicationService(&fakeRepository{})
+	if _, err := unsupported.GetWeatherByLocation(ctx, "id:1816670"); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected unsupported error, got %v", err)
+	}
+}
+
+func TestAirQualityMeasuredAtInLocationZone(t *testing.T) {
+	measured := time.Date(2026, 10, 19, 4, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		location string
+		offset   int
+	}{
+		{"city", "Shanghai", 8 * 3600},
+		{"coordinates", "31.23,121.47", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &airRepository{
+				fakeRepository: fakeRepository{weathers: map[string]*weather.Weather{
+					"Shanghai": {Location: weather.Location{City: "Shanghai", Lat: 31.23, Lon: 121.47, UTCOffset: 8 * 3600}},
+				}},
+				air: &weather.AirQuality{AQI: 2, MeasuredAt: measured},
+			}
+			service := NewWeatherApplicationService(repo)
+
+			aq, err := service.GetAirQualityByLocation(context.Background(), tt.location)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !aq.MeasuredAt.Equal(measured) {
+				t.Errorf("Expected measured time %v, got %v", measured, aq.MeasuredAt)
+			}
+			if _, offset := aq.MeasuredAt.Zone(); offset != tt.offset {
+				t.Errorf("Expected offset %d, got %d", tt.offset, offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxBestSlots 每个活动推荐的最佳时段数
+const maxBestSlots = 3
+
+// ActivityRule 活动适宜度规则
+// 各阈值为可选的硬性限制，未设置的限制不参与判断。
+type ActivityRule struct {
+	Name                 string   `json:"name"`
+	Label                string   `json:"label"`
+	MaxWindSpeed         *float64 `json:"max_wind_speed,omitempty"`         // 最大风速 (m/s)
+	MaxPrecipProbability *float64 `json:"max_precip_probability,omitempty"` // 最大降水概率 (0-1)
+	MinTemperature       *float64 `json:"min_temperature,omitempty"`        // 最低气温 (°C)
+	MaxTemperature       *float64 `json:"max_temperature,omitempty"`        // 最高气温 (°C)
+	MinVisibility        *int     `json:"min_visibility,omitempty"`         // 最低能见度 (m)
+	MaxHumidity          *int     `json:"max_humidity,omitempty"`           // 最大相对湿度 (%)
+	DryHoursAfter        int      `json:"dry_hours_after,omitempty"`        // 之后需要保持无雨的小时数
+}
+
+// Validate 校验规则
+func (r ActivityRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("activity name is required")
+	}
+	if r.MinTemperature != nil && r.MaxTemperature != nil && *r.MinTemperature > *r.MaxTemperature {
+		return fmt.Errorf("activity %s: min_temperature must not exceed max_temperature", r.Name)
+	}
+	if r.MaxPrecipProbability != nil && (*r.MaxPrecipProbability < 0 || *r.MaxPrecipProbability > 1) {
+		return fmt.Errorf("activity %s: max_precip_probability must be between 0 and 1", r.Name)
+	}
+	if r.DryHoursAfter < 0 {
+		return fmt.Errorf("activity %s: dry_hours_after must not be negative", r.Name)
+	}
+	if r.DryHoursAfter > 0 && r.MaxPrecipProbability == nil {
+		return fmt.Errorf("activity %s: dry_hours_after requires max_precip_probability", r.Name)
+	}
+	return nil
+}
+
+// SlotAssessment 单个预报时段的活动适宜度评估
+type SlotAssessment struct {
+	Time     time.Time `json:"time"`
+	Score    int       `json:"score"` // 0-100，不适宜时为0
+	Suitable bool      `json:"suitable"`
+	Reasons  []string  `json:"reasons"`
+}
+
+// ActivityAdvice 活动适宜度建议
+type ActivityAdvice struct {
+	Activity  string           `json:"activity"`
+	Label     string           `json:"label"`
+	BestSlots []SlotAssessment `json:"best_slots"`
+	Slots     []SlotAssessment `json:"slots"`
+}
+
+// AssessActivity 按规则评估预报时段的活动适宜度
+// 满足所有限制的时段为适宜，得分从100开始按接近限制的程度扣分：
+// 风速最多扣30分（风速/上限），降水概率最多扣40分（概率/上限），
+// 同时设置温度上下限时最多扣30分（偏离区间中点的程度）。
+// 最佳时段为得分最高的至多3个适宜时段，得分相同时取较早的时段。
+// 只评估前slots个时段，之后的时段仅用于检查 dry_hours_after。
+func AssessActivity(rule ActivityRule, hourly []HourlyWeather, slots int) ActivityAdvice {
+	if slots > len(hourly) {
+		slots = len(hourly)
+	}
+	advice := ActivityAdvice{
+		Activity:  rule.Name,
+		Label:     rule.Label,
+		BestSlots: []SlotAssessment{},
+		Slots:     make([]SlotAssessment, 0, slots),
+	}
+	for i := 0; i < slots; i++ {
+		advice.Slots = append(advice.Slots, assessSlot(rule, hourly, i))
+	}
+
+	for _, s := range advice.Slots {
+		if s.Suitable {
+			advice.BestSlots = append(advice.BestSlots, s)
+		}
+	}
+	sort.SliceStable(advice.BestSlots, func(i, j int) bool {
+		if advice.BestSlots[i].Score != advice.BestSlots[j].Score {
+			return advice.BestSlots[i].Score > advice.BestSlots[j].Score
+		}
+		return advice.BestSlots[i].Time.Before(advice.BestSlots[j].Time)
+	})
+	if len(advice.BestSlots) > maxBestSlots {
+		advice.BestSlots = advice.BestSlots[:maxBestSlots]
+	}
+	return advice
+}
+
+// assessSlot 评估第i个时段
+func assessSlot(rule ActivityRule, hourly []HourlyWeather, i int) SlotAssessment {
+	h := hourly[i]
+	var violations, notes []string
+	penalty := 0.0
+
+	if rule.MaxWindSpeed != nil {
+		if h.WindSpeed > *rule.MaxWindSpeed {
+			violations = append(violations, fmt.Sprintf("风速 %.1fm/s 超过 %.1fm/s", h.WindSpeed, *rule.MaxWindSpeed))
+		} else {
+			penalty += 30 * ratio(h.WindSpeed, *rule.MaxWindSpeed)
+			notes = append(notes, fmt.Sprintf("风速 %.1fm/s", h.WindSpeed))
+		}
+	}
+	if rule.MaxPrecipProbability != nil {
+		if h.PrecipProbability > *rule.MaxPrecipProbability {
+			violations = append(violations, fmt.Sprintf("降水概率 %.0f%% 超过 %.0f%%", h.PrecipProbability*100, *rule.MaxPrecipProbability*100))
+		} else {
+			penalty += 40 * ratio(h.PrecipProbability, *rule.MaxPrecipProbability)
+			notes = append(notes, fmt.Sprintf("降水概率 %.0f%%", h.PrecipProbability*100))
+		}
+	}
+	tempOK := true
+	if rule.MinTemperature != nil && h.Temperature < *rule.MinTemperature {
+		violations = append(violations, fmt.Sprintf("气温 %.1f°C 低于 %.1f°C", h.Temperature, *rule.MinTemperature))
+		tempOK = false
+	}
+	if rule.MaxTemperature != nil && h.Temperature > *rule.MaxTemperature {
+		violations = append(violations, fmt.Sprintf("气温 %.1f°C 高于 %.1f°C", h.Temperature, *rule.MaxTemperature))
+		tempOK = false
+	}
+	if tempOK && (rule.MinTemperature != nil || rule.MaxTemperature != nil) {
+		if rule.MinTemperature != nil && rule.MaxTemperature != nil {
+			mid := (*rule.MinTemperature + *rule.MaxTemperature) / 2
+			half := (*rule.MaxTemperature - *rule.MinTemperature) / 2
+			penalty += 30 * ratio(math.Abs(h.Temperature-mid), half)
+		}
+		notes = append(notes, fmt.Sprintf("气温 %.1f°C", h.Temperature))
+	}
+	// 数据源未提供能见度时无法判断，不检查该条件
+	if rule.MinVisibility != nil && h.Visibility != nil {
+		if *h.Visibility < *rule.MinVisibility {
+			violations = append(violations, fmt.Sprintf("能见度 %dm 低于 %dm", *h.Visibility, *rule.MinVisibility))
+		} else {
+			notes = append(notes, fmt.Sprintf("能见度 %dm", *h.Visibility))
+		}
+	}
+	if rule.MaxHumidity != nil {
+		if h.Humidity > *rule.MaxHumidity {
+			violations = append(violations, fmt.Sprintf("湿度 %d%% 超过 %d%%", h.Humidity, *rule.MaxHumidity))
+		} else {
+			notes = append(notes, fmt.Sprintf("湿度 %d%%", h.Humidity))
+		}
+	}
+	if rule.DryHoursAfter > 0 {
+		if reason, ok := dryAfter(hourly, i, rule.DryHoursAfter, *rule.MaxPrecipProbability); !ok {
+			violations = append(violations, reason)
+		} else {
+			notes = append(notes, fmt.Sprintf("之后%d小时无明显降水", rule.DryHoursAfter))
+		}
+	}
+
+	if len(violations) > 0 {
+		return SlotAssessment{Time: h.Date, Score: 0, Suitable: false, Reasons: violations}
+	}
+	return SlotAssessment{
+		Time:     h.Date,
+		Score:    int(math.Round(math.Max(0, 100-penalty))),
+		Suitable: true,
+		Reasons:  notes,
+	}
+}
+
+// dryAfter 检查第i个时段之后hours小时内的降水概率是否都不超过上限
+func dryAfter(hourly []HourlyWeather, i, hours int, maxProbability float64) (string, bool) {
+	end := hourly[i].Date.Add(time.Duration(hours) * time.Hour)
+	covered := false
+	for _, h := range hourly[i+1:] {
+		if h.Date.After(end) {
+			covered = true
+			break
+		}
+		if h.PrecipProbability > maxProbability {
+			return fmt.Sprintf("之后%d小时内降水概率达 %.0f%%", hours, h.PrecipProbability*100), false
+		}
+		if !h.Date.Before(end) {
+			covered = true
+		}
+	}
+	if !covered {
+		return fmt.Sprintf("预报范围不足以确认之后%d小时无降水", hours), false
+	}
+	return "", true
+}
+
+// ratio 计算 value/limit，limit为0时返回0
+func ratio(value, limit float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return value / limit
+}
@@ -0,0 +1,149 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func float(v float64) *float64 { return &v }
+
+func integer(v int) *int { return &v }
+
+func TestAssessActivity(t *testing.T) {
+	base := time.Unix(1760918400, 0)
+	slot := func(i int, temp, wind, pop float64, visibility int) HourlyWeather {
+		return HourlyWeather{
+			Date:              base.Add(time.Duration(3*i) * time.Hour),
+			Temperature:       temp,
+			WindSpeed:         wind,
+			PrecipProbability: pop,
+			Visibility:        integer(visibility),
+			Humidity:          60,
+		}
+	}
+	running := ActivityRule{
+		Name:                 "running",
+		Label:                "跑步",
+		MaxWindSpeed:         float(8),
+		MaxPrecipProbability: float(0.3),
+		MinTemperature:       float(5),
+		MaxTemperature:       float(25),
+		MinVisibility:        integer(1000),
+	}
+
+	tests := []struct {
+		name         string
+		slot         HourlyWeather
+		suitable     bool
+		score        int
+		firstReason  string
+		reasonsCount int
+	}{
+		{"ideal", slot(0, 15, 0, 0, 10000), true, 100, "风速 0.0m/s", 4},
+		{"near limits", slot(0, 25, 8, 0.3, 10000), true, 0, "风速 8.0m/s", 4},
+		{"moderate", slot(0, 20, 4, 0.15, 10000), true, 50, "风速 4.0m/s", 4},
+		{"too windy", slot(0, 15, 9, 0, 10000), false, 0, "风速 9.0m/s 超过 8.0m/s", 1},
+		{"too cold and rainy", slot(0, 2, 1, 0.8, 10000), false, 0, "降水概率 80% 超过 30%", 2},
+		{"fog", slot(0, 15, 1, 0, 500), false, 0, "能见度 500m 低于 1000m", 1},
+		{"visibility missing", func() HourlyWeather {
+			h := slot(0, 15, 0, 0, 0)
+			h.Visibility = nil
+			return h
+		}(), true, 100, "风速 0.0m/s", 3},
+	}
+
+	for _, test := range tests {
+		advice := AssessActivity(running, []HourlyWeather{test.slot}, 1)
+		s := advice.Slots[0]
+		if s.Suitable != test.suitable || s.Score != test.score {
+			t.Errorf("%s: expected suitable=%v score=%d, got suitable=%v score=%d (%v)",
+				test.name, test.suitable, test.score, s.Suitable, s.Score, s.Reasons)
+		}
+		if len(s.Reasons) != test.reasonsCount || s.Reasons[0] != test.firstReason {
+			t.Errorf("%s: expected %d reasons starting with %q, got %v", test.name, test.reasonsCount, test.firstReason, s.Reasons)
+		}
+	}
+}
+
+func TestAssessActivityBestSlots(t *testing.T) {
+	base := time.Unix(1760918400, 0)
+	rule := ActivityRule{Name: "cycling", MaxWindSpeed: float(6)}
+	var hourly []HourlyWeather
+	for i, wind := range []float64{5, 1, 7, 1, 3, 2} {
+		hourly = append(hourly, HourlyWeather{Date: base.Add(time.Duration(3*i) * time.Hour), WindSpeed: wind})
+	}
+
+	advice := AssessActivity(rule, hourly, len(hourly))
+
+	expected := []time.Time{hourly[1].Date, hourly[3].Date, hourly[5].Date}
+	if len(advice.BestSlots) != len(expected) {
+		t.Fatalf("Expected %d best slots, got %d", len(expected), len(advice.BestSlots))
+	}
+	for i, ts := range expected {
+		if !advice.BestSlots[i].Time.Equal(ts) {
+			t.Errorf("Expected best slot %d at %v, got %v", i, ts, advice.BestSlots[i].Time)
+		}
+	}
+}
+
+func TestAssessActivityDryHoursAfter(t *testing.T) {
+	base := time.Unix(1760918400, 0)
+	rule := ActivityRule{Name: "car_washing", MaxPrecipProbability: float(0.2), DryHoursAfter: 6}
+	var hourly []HourlyWeather
+	for i, pop := range []float64{0, 0, 0.1, 0.9, 0, 0} {
+		hourly = append(hourly, HourlyWeather{Date: base.Add(time.Duration(3*i) * time.Hour), PrecipProbability: pop})
+	}
+
+	advice := AssessActivity(rule, hourly, len(hourly))
+
+	expected := []bool{true, false, false, false, false, false}
+	for i, suitable := range expected {
+		if advice.Slots[i].Suitable != suitable {
+			t.Errorf("Slot %d: expected suitable=%v, got %v (%v)", i, suitable, advice.Slots[i].Suitable, advice.Slots[i].Reasons)
+		}
+	}
+	if advice.Slots[5].Reasons[0] != "预报范围不足以确认之后6小时无降水" {
+		t.Errorf("Expected uncovered window reason, got %v", advice.Slots[5].Reasons)
+	}
+}
+
+func TestAssessActivityScoresOnlyWindow(t *testing.T) {
+	base := time.Unix(1760918400, 0)
+	rule := ActivityRule{Name: "car_washing", MaxPrecipProbability: float(0.2), DryHoursAfter: 6}
+	var hourly []HourlyWeather
+	for i := 0; i < 6; i++ {
+		hourly = append(hourly, HourlyWeather{Date: base.Add(time.Duration(3*i) * time.Hour)})
+	}
+
+	advice := AssessActivity(rule, hourly, 2)
+
+	if len(advice.Slots) != 2 {
+		t.Fatalf("Expected 2 scored slots, got %d", len(advice.Slots))
+	}
+	for i, slot := range advice.Slots {
+		if !slot.Suitable {
+			t.Errorf("Slot %d: expected suitable using the forecast past the window, got %v", i, slot.Reasons)
+		}
+	}
+}
+
+func TestActivityRuleValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      ActivityRule
+		expectErr bool
+	}{
+		{"valid", ActivityRule{Name: "running", MinTemperature: float(5), MaxTemperature: float(25)}, false},
+		{"missing name", ActivityRule{}, true},
+		{"inverted temperature", ActivityRule{Name: "x", MinTemperature: float(25), MaxTemperature: float(5)}, true},
+		{"probability out of range", ActivityRule{Name: "x", MaxPrecipProbability: float(30)}, true},
+		{"dry hours without probability", ActivityRule{Name: "x", DryHoursAfter: 6}, true},
+	}
+
+	for _, test := range tests {
+		err := test.rule.Validate()
+		if (err != nil) != test.expectErr {
+			t.Errorf("%s: expected error=%v, got %v", test.name, test.expectErr, err)
+		}
+	}
+}
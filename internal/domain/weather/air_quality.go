@@ -0,0 +1,24 @@
+package weather
+
+import (
+	"context"
+	"time"
+)
+
+// AirQuality 空气质量值对象
+type AirQuality struct {
+	AQI        int       `json:"aqi"`   // OpenWeatherMap空气质量等级 1(优)-5(极差)
+	PM25       float64   `json:"pm2_5"` // μg/m³
+	PM10       float64   `json:"pm10"`  // μg/m³
+	O3         float64   `json:"o3"`    // μg/m³
+	NO2        float64   `json:"no2"`   // μg/m³
+	SO2        float64   `json:"so2"`   // μg/m³
+	CO         float64   `json:"co"`    // μg/m³
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// AirQualityRepository 空气质量仓储接口
+// 作为 WeatherRepository 的可选能力，由支持空气质量数据的数据源实现
+type AirQualityRepository interface {
+	GetAirQuality(ctx context.Context, lat, lon float64) (*AirQuality, error)
+}
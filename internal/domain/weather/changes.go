@@ -0,0 +1,68 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultTemperatureChangeThreshold 判定温度显著变化的默认阈值（°C）
+const DefaultTemperatureChangeThreshold = 2.0
+
+// DetectChanges 比较两次天气观测，返回显著变化的说明
+// 显著变化包括：温度变化达到阈值、天气状况类别变化、预警新增或解除。
+// 没有显著变化时返回空切片。
+func DetectChanges(prev, curr *Weather, tempThreshold float64) []string {
+	if prev == nil || curr == nil {
+		return nil
+	}
+
+	var changes []string
+	delta := curr.Current.Temperature - prev.Current.Temperature
+	if math.Abs(delta) >= tempThreshold {
+		changes = append(changes, fmt.Sprintf("温度 %.1f°C → %.1f°C", prev.Current.Temperature, curr.Current.Temperature))
+	}
+	if conditionCode(prev.Current) != conditionCode(curr.Current) {
+		changes = append(changes, fmt.Sprintf("天气 %s → %s", prev.Current.Description, curr.Current.Description))
+	}
+
+	prevAlerts, currAlerts := alertKeys(prev.Alerts), alertKeys(curr.Alerts)
+	for _, key := range sortedKeys(currAlerts) {
+		if !prevAlerts[key] {
+			changes = append(changes, fmt.Sprintf("新增预警: %s", strings.SplitN(key, "|", 2)[0]))
+		}
+	}
+	for _, key := range sortedKeys(prevAlerts) {
+		if !currAlerts[key] {
+			changes = append(changes, fmt.Sprintf("预警解除: %s", strings.SplitN(key, "|", 2)[0]))
+		}
+	}
+	return changes
+}
+
+// conditionCode 获取天气状况类别
+// 使用图标代码的前两位（如 "10d" 和 "10n" 均为降雨），忽略昼夜差异；无图标时使用描述
+func conditionCode(c CurrentWeather) string {
+	if len(c.Icon) >= 2 {
+		return c.Icon[:2]
+	}
+	return c.Description
+}
+
+func alertKeys(alerts []Alert) map[string]bool {
+	keys := make(map[string]bool, len(alerts))
+	for _, a := range alerts {
+		keys[fmt.Sprintf("%s|%d", a.Event, a.Start.Unix())] = true
+	}
+	return keys
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
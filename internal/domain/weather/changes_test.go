@@ -0,0 +1,65 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectChanges(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	base := &Weather{Current: CurrentWeather{Temperature: 28, Description: "多云", Icon: "03d"}}
+	typhoon := Alert{Event: "台风橙色预警", Start: start}
+
+	tests := []struct {
+		name     string
+		curr     *Weather
+		expected []string
+	}{
+		{
+			name:     "small temperature change",
+			curr:     &Weather{Current: CurrentWeather{Temperature: 29.5, Description: "多云", Icon: "03n"}},
+			expected: nil,
+		},
+		{
+			name:     "temperature drop",
+			curr:     &Weather{Current: CurrentWeather{Temperature: 25.5, Description: "多云", Icon: "03d"}},
+			expected: []string{"温度 28.0°C → 25.5°C"},
+		},
+		{
+			name:     "condition change",
+			curr:     &Weather{Current: CurrentWeather{Temperature: 27, Description: "大雨", Icon: "10d"}},
+			expected: []string{"天气 多云 → 大雨"},
+		},
+		{
+			name: "new alert",
+			curr: &Weather{
+				Current: CurrentWeather{Temperature: 28, Description: "多云", Icon: "03d"},
+				Alerts:  []Alert{typhoon},
+			},
+			expected: []string{"新增预警: 台风橙色预警"},
+		},
+	}
+
+	for _, test := range tests {
+		changes := DetectChanges(base, test.curr, DefaultTemperatureChangeThreshold)
+		if len(changes) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, changes)
+			continue
+		}
+		for i := range changes {
+			if changes[i] != test.expected[i] {
+				t.Errorf("%s: expected %q, got %q", test.name, test.expected[i], changes[i])
+			}
+		}
+	}
+
+	withAlert := &Weather{Current: base.Current, Alerts: []Alert{typhoon}}
+	changes := DetectChanges(withAlert, base, DefaultTemperatureChangeThreshold)
+	if len(changes) != 1 || changes[0] != "预警解除: 台风橙色预警" {
+		t.Errorf("Expected lifted alert, got %v", changes)
+	}
+
+	if changes := DetectChanges(nil, base, DefaultTemperatureChangeThreshold); changes != nil {
+		t.Errorf("Expected no changes without previous observation, got %v", changes)
+	}
+}
@@ -0,0 +1,29 @@
+package weather
+
+import (
+	"context"
+	"time"
+)
+
+// ProviderProbe 数据源探测结果
+type ProviderProbe struct {
+	Provider   string        `json:"provider"`
+	Reachable  bool          `json:"reachable"`   // 是否能连接到数据源
+	KeyValid   bool          `json:"key_valid"`   // API密钥是否被接受
+	StatusCode int           `json:"status_code"` // 探测请求的HTTP状态码，网络错误时为0
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+	Features   []string      `json:"features,omitempty"` // 密钥可用的付费功能，如 onecall
+	CheckedAt  time.Time     `json:"checked_at"`
+}
+
+// Healthy 数据源可达且密钥有效
+func (p ProviderProbe) Healthy() bool {
+	return p.Reachable && p.KeyValid
+}
+
+// ProviderProber 数据源探测接口
+// 作为 WeatherRepository 的可选能力，由支持廉价健康探测的数据源实现
+type ProviderProber interface {
+	Probe(ctx context.Context) ProviderProbe
+}
@@ -0,0 +1,247 @@
+package weather
+
+import (
+	"math"
+)
+
+// ComfortIndices 由观测值计算的舒适度与安全指数
+type ComfortIndices struct {
+	HeatIndex float64        `json:"heat_index"` // 酷热指数 (°C)
+	WindChill float64        `json:"wind_chill"` // 风寒温度 (°C)
+	DewPoint  float64        `json:"dew_point"`  // 露点 (°C)
+	Humidex   float64        `json:"humidex"`    // 加拿大湿热指数
+	Comfort   ComfortLevel   `json:"comfort"`    // 人体舒适度
+	Clothing  ClothingAdvice `json:"clothing"`   // 穿衣指数
+	UVRisk    *UVRisk        `json:"uv_risk,omitempty"`
+	FrostRisk FrostRisk      `json:"frost_risk"`
+}
+
+// ComfortLevel 人体舒适度等级
+type ComfortLevel struct {
+	Index float64 `json:"index"` // 舒适度指数 SSD
+	Level int     `json:"level"` // -4(很冷) 到 4(很热)，0为舒适
+	Label string  `json:"label"`
+}
+
+// ClothingAdvice 穿衣指数
+type ClothingAdvice struct {
+	Level  int    `json:"level"` // 1(炎热) 到 8(寒冷)
+	Label  string `json:"label"`
+	Advice string `json:"advice"`
+}
+
+// UVRisk 紫外线风险等级
+type UVRisk struct {
+	Index  float64 `json:"index"`
+	Label  string  `json:"label"`
+	Advice string  `json:"advice"`
+}
+
+// FrostRisk 霜冻风险等级
+type FrostRisk string
+
+const (
+	FrostRiskNone     FrostRisk = "none"
+	FrostRiskLow      FrostRisk = "low"
+	FrostRiskModerate FrostRisk = "moderate"
+	FrostRiskHigh     FrostRisk = "high"
+)
+
+// ComputeCurrentIndices 根据实时天气计算各项指数
+func ComputeCurrentIndices(c CurrentWeather) ComfortIndices {
+	return computeIndices(c.Temperature, c.FeelsLike, c.Humidity, c.WindSpeed, c.UVIndex)
+}
+
+// ComputeHourlyIndices 根据预报时段天气计算各项指数
+func ComputeHourlyIndices(h HourlyWeather) ComfortIndices {
+	return computeIndices(h.Temperature, h.FeelsLike, h.Humidity, h.WindSpeed, nil)
+}
+
+// computeIndices 计算各项指数，穿衣指数以体感温度为依据
+func computeIndices(tempC, feelsLike float64, humidity int, windSpeed float64, uvIndex *float64) ComfortIndices {
+	dewPoint := DewPoint(tempC, humidity)
+	indices := ComfortIndices{
+		HeatIndex: HeatIndex(tempC, humidity),
+		WindChill: WindChill(tempC, windSpeed),
+		DewPoint:  dewPoint,
+		Humidex:   Humidex(tempC, dewPoint),
+		Comfort:   Comfort(tempC, humidity, windSpeed),
+		Clothing:  Clothing(feelsLike),
+		FrostRisk: Frost(tempC, dewPoint, windSpeed),
+	}
+	if uvIndex != nil {
+		risk := UVRiskCategory(*uvIndex)
+		indices.UVRisk = &risk
+	}
+	return indices
+}
+
+// HeatIndex 计算酷热指数（°C）
+// 采用美国国家气象局的 Rothfusz 回归公式：
+//
+//	HI = -42.379 + 2.04901523T + 10.14333127RH - 0.22475541T·RH - 0.00683783T²
+//	     - 0.05481717RH² + 0.00122874T²·RH + 0.00085282T·RH² - 0.00000199T²·RH²
+//
+// 其中 T 为华氏温度、RH 为相对湿度(%)。按 NWS 规则先计算 Steadman 简化公式
+// HI = 0.5(T + 61 + 1.2(T-68) + 0.094RH)，简化结果与 T 的平均值低于80°F时直接采用简化结果，
+// 否则采用回归公式，并对低湿和高湿情况做修正。
+func HeatIndex(tempC float64, humidity int) float64 {
+	t := celsiusToFahrenheit(tempC)
+	rh := float64(humidity)
+
+	hi := 0.5 * (t + 61.0 + (t-68.0)*1.2 + rh*0.094)
+	if (hi+t)/2 < 80 {
+		return fahrenheitToCelsius(hi)
+	}
+
+	hi = -42.379 + 2.04901523*t + 10.14333127*rh -
+		0.22475541*t*rh - 0.00683783*t*t - 0.05481717*rh*rh +
+		0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+
+	switch {
+	case rh < 13 && t >= 80 && t <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t >= 80 && t <= 87:
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+	return fahrenheitToCelsius(hi)
+}
+
+// WindChill 计算风寒温度（°C）
+// 采用加拿大环境部与美国国家气象局共同使用的公式：
+//
+//	WC = 13.12 + 0.6215T - 11.37V^0.16 + 0.3965T·V^0.16
+//
+// 其中 T 为气温(°C)、V 为10米高度风速(km/h)。公式仅适用于 T ≤ 10°C 且 V > 4.8km/h，
+// 超出范围时风寒效应可以忽略，直接返回气温。
+func WindChill(tempC, windSpeed float64) float64 {
+	v := windSpeed * 3.6
+	if tempC > 10 || v <= 4.8 {
+		return tempC
+	}
+	vp := math.Pow(v, 0.16)
+	return 13.12 + 0.6215*tempC - 11.37*vp + 0.3965*tempC*vp
+}
+
+// DewPoint 计算露点温度（°C）
+// 采用 Magnus 公式（Sonntag 1990 系数 b=17.62, c=243.12°C）：
+//
+//	γ = ln(RH/100) + bT/(c+T)
+//	Td = cγ / (b-γ)
+func DewPoint(tempC float64, humidity int) float64 {
+	const b, c = 17.62, 243.12
+	rh := math.Max(float64(humidity), 1)
+	gamma := math.Log(rh/100) + b*tempC/(c+tempC)
+	return c * gamma / (b - gamma)
+}
+
+// Humidex 计算加拿大湿热指数
+// 采用加拿大环境部公式：
+//
+//	H = T + 0.5555(6.11e^{5417.7530(1/273.16 - 1/(273.15+Td))} - 10)
+//
+// 其中 T 为气温(°C)、Td 为露点(°C)。
+func Humidex(tempC, dewPointC float64) float64 {
+	e := 6.11 * math.Exp(5417.7530*(1/273.16-1/(273.15+dewPointC)))
+	return tempC + 0.5555*(e-10)
+}
+
+// Comfort 计算人体舒适度
+// 采用中国气象部门常用的人体舒适度指数公式：
+//
+//	SSD = (1.818T + 18.18)(0.88 + 0.002RH) + (T - 32)/(45 - T) - 3.2V + 18.2
+//
+// 其中 T 为气温(°C)、RH 为相对湿度(%)、V 为风速(m/s)。
+// 等级划分：≥86 很热，80-85 炎热，76-79 偏热，71-75 温暖，59-70 舒适，
+// 51-58 凉爽，39-50 偏凉，26-38 冷，≤25 很冷。
+func Comfort(tempC float64, humidity int, windSpeed float64) ComfortLevel {
+	t := math.Min(tempC, 44) // 避免分母趋近于零
+	ssd := (1.818*t+18.18)*(0.88+0.002*float64(humidity)) + (t-32)/(45-t) - 3.2*windSpeed + 18.2
+
+	levels := []struct {
+		min   float64
+		level int
+		label string
+	}{
+		{86, 4, "很热，极不适应"},
+		{80, 3, "炎热，很不舒适"},
+		{76, 2, "偏热，不舒适"},
+		{71, 1, "温暖，较舒适"},
+		{59, 0, "舒适"},
+		{51, -1, "凉爽，较舒适"},
+		{39, -2, "偏凉，不舒适"},
+		{26, -3, "冷，很不舒适"},
+	}
+	for _, l := range levels {
+		if ssd >= l.min {
+			return ComfortLevel{Index: ssd, Level: l.level, Label: l.label}
+		}
+	}
+	return ComfortLevel{Index: ssd, Level: -4, Label: "很冷，极不适应"}
+}
+
+// Clothing 根据体感温度计算穿衣指数
+// 参照中国气象局穿衣指数分级，按体感温度划分为8级：
+// ≥28°C 炎热，24-28 热，21-24 舒适，18-21 较舒适，15-18 温凉，11-15 凉，6-11 冷，<6 寒冷。
+func Clothing(feelsLike float64) ClothingAdvice {
+	levels := []struct {
+		min    float64
+		advice ClothingAdvice
+	}{
+		{28, ClothingAdvice{1, "炎热", "建议穿短袖、短裙、短裤等清凉夏装"}},
+		{24, ClothingAdvice{2, "热", "建议穿短袖、薄长裤等夏季服装"}},
+		{21, ClothingAdvice{3, "舒适", "建议穿长袖衬衫、单裤等服装"}},
+		{18, ClothingAdvice{4, "较舒适", "建议穿薄外套、薄毛衣等服装"}},
+		{15, ClothingAdvice{5, "温凉", "建议穿夹克、风衣或毛衣"}},
+		{11, ClothingAdvice{6, "凉", "建议穿风衣、大衣、厚毛衣等服装"}},
+		{6, ClothingAdvice{7, "冷", "建议穿棉衣、冬大衣、皮夹克等冬装"}},
+	}
+	for _, l := range levels {
+		if feelsLike >= l.min {
+			return l.advice
+		}
+	}
+	return ClothingAdvice{8, "寒冷", "建议穿羽绒服、厚棉衣，并戴帽子手套"}
+}
+
+// UVRiskCategory 按世界卫生组织标准划分紫外线风险等级
+// 0-2 低，3-5 中等，6-7 高，8-10 很高，≥11 极高。
+func UVRiskCategory(uvIndex float64) UVRisk {
+	switch {
+	case uvIndex >= 11:
+		return UVRisk{uvIndex, "极高", "尽量避免外出，必须外出时做好全面防护"}
+	case uvIndex >= 8:
+		return UVRisk{uvIndex, "很高", "避免正午外出，涂抹SPF30+防晒霜并戴帽子墨镜"}
+	case uvIndex >= 6:
+		return UVRisk{uvIndex, "高", "正午前后减少外出，外出时涂抹防晒霜"}
+	case uvIndex >= 3:
+		return UVRisk{uvIndex, "中等", "外出时建议戴帽子、涂抹防晒霜"}
+	default:
+		return UVRisk{uvIndex, "低", "无需特别防护"}
+	}
+}
+
+// Frost 计算霜冻风险
+// 地表在晴朗静风的夜间可比2米气温低2-4°C，因此：
+// 气温 ≤ 0°C 为高风险；气温 ≤ 2°C 且露点 ≤ 0°C 为中等风险；
+// 气温 ≤ 4°C 且风速 < 2m/s（辐射降温明显）为低风险；其余无风险。
+func Frost(tempC, dewPointC, windSpeed float64) FrostRisk {
+	switch {
+	case tempC <= 0:
+		return FrostRiskHigh
+	case tempC <= 2 && dewPointC <= 0:
+		return FrostRiskModerate
+	case tempC <= 4 && windSpeed < 2:
+		return FrostRiskLow
+	default:
+		return FrostRiskNone
+	}
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+func fahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
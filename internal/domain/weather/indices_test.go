@@ -0,0 +1,242 @@
+package weather
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHeatIndex(t *testing.T) {
+	tests := []struct {
+		tempC    float64
+		humidity int
+		expected float64
+	}{
+		{20, 50, 19.4},  // 低温时采用简化公式，接近气温
+		{30, 70, 35.0},  // NWS 表: 86°F/70% ≈ 95°F
+		{35, 60, 45.1},  // NWS 表: 95°F/60% ≈ 113°F
+		{32, 90, 49.0},  // NWS 表: 90°F/90% ≈ 122°F
+		{40, 10, 36.7},  // 低湿修正，NWS 计算器 ≈ 98°F
+		{27, 40, 26.9},  // 临界附近
+		{-5, 80, -7.4},  // 寒冷天气不应出现异常值
+		{25, 100, 26.2}, // 饱和湿度
+	}
+
+	for _, test := range tests {
+		result := HeatIndex(test.tempC, test.humidity)
+		if math.Abs(result-test.expected) > 0.1 {
+			t.Errorf("For %.1f°C/%d%%, expected heat index %.1f, got %.2f", test.tempC, test.humidity, test.expected, result)
+		}
+	}
+}
+
+func TestHeatIndexThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		tempC    float64
+		humidity int
+		expected float64
+	}{
+		// 78.8°F/50%: 简化结果 78.73°F，与气温的平均值 78.77°F 低于80°F，采用简化结果
+		{"average below 80°F", 26, 50, 25.96},
+		// 81.5°F/10%: 简化结果 79.82°F 低于80°F，但与气温的平均值 80.66°F 达到80°F，采用回归公式（含低湿修正）
+		{"simplified below but average above 80°F", 27.5, 10, 26.04},
+		// 80.6°F/50%: 平均值 80.66°F，采用回归公式
+		{"average above 80°F", 27, 50, 27.42},
+	}
+
+	for _, test := range tests {
+		result := HeatIndex(test.tempC, test.humidity)
+		if math.Abs(result-test.expected) > 0.01 {
+			t.Errorf("%s: for %.1f°C/%d%%, expected heat index %.2f, got %.2f", test.name, test.tempC, test.humidity, test.expected, result)
+		}
+	}
+}
+
+func TestWindChill(t *testing.T) {
+	tests := []struct {
+		tempC     float64
+		windSpeed float64
+		expected  float64
+	}{
+		{-10, 5, -17.4},    // 18km/h
+		{0, 10, -7.1},      // 36km/h
+		{-20, 8.33, -32.6}, // 加拿大环境部表: -20°C/30km/h ≈ -32.6
+		{5, 1, 5},          // 风速过低，不适用
+		{15, 10, 15},       // 气温过高，不适用
+	}
+
+	for _, test := range tests {
+		result := WindChill(test.tempC, test.windSpeed)
+		if math.Abs(result-test.expected) > 0.1 {
+			t.Errorf("For %.1f°C/%.1fm/s, expected wind chill %.1f, got %.2f", test.tempC, test.windSpeed, test.expected, result)
+		}
+	}
+}
+
+func TestDewPoint(t *testing.T) {
+	tests := []struct {
+		tempC    float64
+		humidity int
+		expected float64
+	}{
+		{20, 50, 9.3},
+		{30, 70, 23.9},
+		{0, 100, 0},
+		{-10, 60, -16.3},
+		{35, 40, 19.4},
+	}
+
+	for _, test := range tests {
+		result := DewPoint(test.tempC, test.humidity)
+		if math.Abs(result-test.expected) > 0.1 {
+			t.Errorf("For %.1f°C/%d%%, expected dew point %.1f, got %.2f", test.tempC, test.humidity, test.expected, result)
+		}
+	}
+}
+
+func TestHumidex(t *testing.T) {
+	tests := []struct {
+		tempC     float64
+		dewPointC float64
+		expected  float64
+	}{
+		{30, 23.93, 41.2}, // 加拿大环境部表: 30°C/露点24°C ≈ 41
+		{35, 19.38, 42.1},
+		{20, 9.26, 20.9},
+	}
+
+	for _, test := range tests {
+		result := Humidex(test.tempC, test.dewPointC)
+		if math.Abs(result-test.expected) > 0.1 {
+			t.Errorf("For %.1f°C/dew point %.1f°C, expected humidex %.1f, got %.2f", test.tempC, test.dewPointC, test.expected, result)
+		}
+	}
+}
+
+func TestComfort(t *testing.T) {
+	tests := []struct {
+		tempC     float64
+		humidity  int
+		windSpeed float64
+		expected  int
+	}{
+		{35, 80, 1, 4},   // SSD ≈ 100.4
+		{30, 60, 1, 4},   // SSD ≈ 87.6
+		{27, 70, 1, 3},   // SSD ≈ 83.3
+		{22, 50, 1, 1},   // SSD ≈ 71.6
+		{18, 40, 2, 0},   // SSD ≈ 60.2
+		{15, 60, 1, 0},   // SSD ≈ 59.9
+		{10, 50, 2, -2},  // SSD ≈ 46.8
+		{5, 60, 3, -3},   // SSD ≈ 35.2
+		{-10, 50, 5, -4}, // SSD ≈ 1.4
+		{50, 50, 0, 4},   // 极端高温不应除零
+	}
+
+	for _, test := range tests {
+		result := Comfort(test.tempC, test.humidity, test.windSpeed)
+		if result.Level != test.expected {
+			t.Errorf("For %.1f°C/%d%%/%.1fm/s, expected comfort level %d, got %d (SSD %.1f)",
+				test.tempC, test.humidity, test.windSpeed, test.expected, result.Level, result.Index)
+		}
+		if result.Label == "" {
+			t.Errorf("For %.1f°C, expected comfort label", test.tempC)
+		}
+	}
+}
+
+func TestClothing(t *testing.T) {
+	tests := []struct {
+		feelsLike float64
+		expected  int
+	}{
+		{35, 1},
+		{28, 1},
+		{25, 2},
+		{22, 3},
+		{19, 4},
+		{16, 5},
+		{12, 6},
+		{8, 7},
+		{5.9, 8},
+		{-15, 8},
+	}
+
+	for _, test := range tests {
+		result := Clothing(test.feelsLike)
+		if result.Level != test.expected {
+			t.Errorf("For feels like %.1f°C, expected clothing level %d, got %d", test.feelsLike, test.expected, result.Level)
+		}
+		if result.Advice == "" {
+			t.Errorf("For feels like %.1f°C, expected clothing advice", test.feelsLike)
+		}
+	}
+}
+
+func TestUVRiskCategory(t *testing.T) {
+	tests := []struct {
+		uvIndex  float64
+		expected string
+	}{
+		{0, "低"},
+		{2.9, "低"},
+		{3, "中等"},
+		{6, "高"},
+		{8, "很高"},
+		{10.9, "很高"},
+		{11, "极高"},
+	}
+
+	for _, test := range tests {
+		result := UVRiskCategory(test.uvIndex)
+		if result.Label != test.expected {
+			t.Errorf("For UV index %.1f, expected %s, got %s", test.uvIndex, test.expected, result.Label)
+		}
+	}
+}
+
+func TestFrost(t *testing.T) {
+	tests := []struct {
+		tempC     float64
+		dewPointC float64
+		windSpeed float64
+		expected  FrostRisk
+	}{
+		{-2, -5, 3, FrostRiskHigh},
+		{0, -1, 0, FrostRiskHigh},
+		{1.5, -0.5, 4, FrostRiskModerate},
+		{1.5, 1, 4, FrostRiskNone},
+		{3, 1, 1, FrostRiskLow},
+		{3, 1, 3, FrostRiskNone},
+		{10, 5, 0, FrostRiskNone},
+	}
+
+	for _, test := range tests {
+		result := Frost(test.tempC, test.dewPointC, test.windSpeed)
+		if result != test.expected {
+			t.Errorf("For %.1f°C/dew point %.1f°C/%.1fm/s, expected %s, got %s",
+				test.tempC, test.dewPointC, test.windSpeed, test.expected, result)
+		}
+	}
+}
+
+func TestComputeCurrentIndices(t *testing.T) {
+	uv := 7.0
+	current := CurrentWeather{Temperature: 8, FeelsLike: 5, Humidity: 70, WindSpeed: 5, UVIndex: &uv}
+
+	indices := ComputeCurrentIndices(current)
+
+	if indices.Clothing.Level != 8 {
+		t.Errorf("Expected clothing level based on feels like temperature, got %d", indices.Clothing.Level)
+	}
+	if indices.WindChill >= current.Temperature {
+		t.Errorf("Expected wind chill below air temperature, got %.1f", indices.WindChill)
+	}
+	if indices.UVRisk == nil || indices.UVRisk.Label != "高" {
+		t.Errorf("Expected high UV risk, got %+v", indices.UVRisk)
+	}
+
+	hourly := ComputeHourlyIndices(HourlyWeather{Temperature: 8, FeelsLike: 5, Humidity: 70, WindSpeed: 5})
+	if hourly.UVRisk != nil {
+		t.Errorf("Expected no UV risk without UV index, got %+v", hourly.UVRisk)
+	}
+}
@@ -0,0 +1,106 @@
+package weather
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Units 单位制
+type Units string
+
+const (
+	// UnitsMetric 公制：°C、m/s
+	UnitsMetric Units = "metric"
+	// UnitsImperial 英制：°F、mph
+	UnitsImperial Units = "imperial"
+)
+
+// 偏好设置的限制
+const (
+	// MaxSavedLocations 每个用户最多保存的位置别名数
+	MaxSavedLocations = 20
+	// MaxAliasLength 位置别名的最大长度（字符）
+	MaxAliasLength = 50
+)
+
+// ParseUnits 解析单位制名称
+func ParseUnits(s string) (Units, error) {
+	switch u := Units(strings.ToLower(strings.TrimSpace(s))); u {
+	case UnitsMetric, UnitsImperial:
+		return u, nil
+	default:
+		return "", fmt.Errorf("unsupported units: %s (supported: metric, imperial)", s)
+	}
+}
+
+// Temperature 按单位制格式化摄氏温度
+func (u Units) Temperature(c float64) string {
+	if u == UnitsImperial {
+		return fmt.Sprintf("%.1f°F", celsiusToFahrenheit(c))
+	}
+	return fmt.Sprintf("%.1f°C", c)
+}
+
+// Speed 按单位制格式化风速（输入为 m/s）
+func (u Units) Speed(ms float64) string {
+	if u == UnitsImperial {
+		return fmt.Sprintf("%.1f mph", ms*2.236936)
+	}
+	return fmt.Sprintf("%.1f m/s", ms)
+}
+
+// Preferences 用户偏好设置
+type Preferences struct {
+	Locations    map[string]string `json:"locations,omitempty"` // 位置别名 -> 城市名或坐标
+	Units        Units             `json:"units,omitempty"`
+	Language     string            `json:"language,omitempty"`
+	DefaultHours int               `json:"default_hours,omitempty"` // get_weather 未指定 hours 时使用
+}
+
+// PreferencesRepository 用户偏好设置仓储接口
+type PreferencesRepository interface {
+	// GetPreferences 获取用户偏好设置，不存在时返回 nil
+	GetPreferences(user string) (*Preferences, error)
+	// SavePreferences 保存用户偏好设置
+	SavePreferences(user string, prefs *Preferences) error
+}
+
+// NormalizeAlias 规范化位置别名：去除首尾空白并转为小写
+func NormalizeAlias(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ValidateAlias 校验位置别名
+func ValidateAlias(name string) error {
+	alias := NormalizeAlias(name)
+	if alias == "" {
+		return fmt.Errorf("alias name is required")
+	}
+	if utf8.RuneCountInString(alias) > MaxAliasLength {
+		return fmt.Errorf("alias name must be at most %d characters", MaxAliasLength)
+	}
+	if strings.Contains(alias, ",") {
+		return fmt.Errorf("alias name must not contain commas")
+	}
+	return nil
+}
+
+// ResolveAlias 将位置别名解析为实际位置，不是别名时原样返回
+func (p *Preferences) ResolveAlias(location string) (string, bool) {
+	if p == nil {
+		return location, false
+	}
+	if target, ok := p.Locations[NormalizeAlias(location)]; ok {
+		return target, true
+	}
+	return location, false
+}
+
+// EffectiveUnits 获取生效的单位制，未设置时为公制
+func (p *Preferences) EffectiveUnits() Units {
+	if p == nil || p.Units == "" {
+		return UnitsMetric
+	}
+	return p.Units
+}
@@ -0,0 +1,171 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RankCriterion 位置排名标准
+type RankCriterion string
+
+const (
+	CriterionWarmest         RankCriterion = "warmest"
+	CriterionLeastRainy      RankCriterion = "least_rainy"
+	CriterionLowestWind      RankCriterion = "lowest_wind"
+	CriterionBestAQI         RankCriterion = "best_aqi"
+	CriterionMostComfortable RankCriterion = "most_comfortable"
+)
+
+// ComfortableHeatIndex 人体感觉最舒适的酷热指数（°C）
+const ComfortableHeatIndex = 22.0
+
+// RankCriteria 所有支持的排名标准
+var RankCriteria = []RankCriterion{
+	CriterionWarmest,
+	CriterionLeastRainy,
+	CriterionLowestWind,
+	CriterionBestAQI,
+	CriterionMostComfortable,
+}
+
+// ParseRankCriterion 解析排名标准
+func ParseRankCriterion(s string) (RankCriterion, error) {
+	for _, c := range RankCriteria {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported criterion: %s", s)
+}
+
+// LocationConditions 参与排名的位置气象条件
+type LocationConditions struct {
+	Name       string
+	Location   Location
+	Hourly     []HourlyWeather
+	AirQuality *AirQuality
+}
+
+// RankedLocation 排名结果
+type RankedLocation struct {
+	Rank     int      `json:"rank"`
+	Name     string   `json:"name"`
+	Location Location `json:"location"`
+	Value    float64  `json:"value"`    // 排名依据的主要数值
+	Detail   string   `json:"detail"`   // 排名依据说明
+	Unranked bool     `json:"unranked"` // 数据不足无法参与排名
+}
+
+// rankEntry 排名计算的中间结果，keys按顺序比较，越小越靠前
+type rankEntry struct {
+	result RankedLocation
+	keys   []float64
+}
+
+// RankLocations 按指定标准对位置排名
+// 排名是确定性的：数值相同时按次要指标比较，仍相同时按名称排序。
+// 缺少所需数据的位置排在最后并标记为 Unranked。
+func RankLocations(conditions []LocationConditions, criterion RankCriterion) ([]RankedLocation, error) {
+	if _, err := ParseRankCriterion(string(criterion)); err != nil {
+		return nil, err
+	}
+
+	ranked := make([]rankEntry, 0, len(conditions))
+	unranked := make([]RankedLocation, 0)
+	for _, c := range conditions {
+		entry, ok := evaluateCriterion(c, criterion)
+		if !ok {
+			unranked = append(unranked, RankedLocation{
+				Name:     c.Name,
+				Location: c.Location,
+				Detail:   "数据不足",
+				Unranked: true,
+			})
+			continue
+		}
+		ranked = append(ranked, entry)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		for k := range a.keys {
+			if a.keys[k] != b.keys[k] {
+				return a.keys[k] < b.keys[k]
+			}
+		}
+		return a.result.Name < b.result.Name
+	})
+	sort.SliceStable(unranked, func(i, j int) bool {
+		return unranked[i].Name < unranked[j].Name
+	})
+
+	results := make([]RankedLocation, 0, len(conditions))
+	for i, e := range ranked {
+		e.result.Rank = i + 1
+		results = append(results, e.result)
+	}
+	return append(results, unranked...), nil
+}
+
+// evaluateCriterion 计算单个位置在指定标准下的排序键
+func evaluateCriterion(c LocationConditions, criterion RankCriterion) (rankEntry, bool) {
+	result := RankedLocation{Name: c.Name, Location: c.Location}
+
+	if criterion == CriterionBestAQI {
+		if c.AirQuality == nil {
+			return rankEntry{}, false
+		}
+		result.Value = float64(c.AirQuality.AQI)
+		result.Detail = fmt.Sprintf("AQI等级 %d, PM2.5 %.1fμg/m³", c.AirQuality.AQI, c.AirQuality.PM25)
+		return rankEntry{result: result, keys: []float64{result.Value, c.AirQuality.PM25}}, true
+	}
+
+	if len(c.Hourly) == 0 {
+		return rankEntry{}, false
+	}
+
+	switch criterion {
+	case CriterionWarmest:
+		maxTemp, meanTemp := math.Inf(-1), 0.0
+		for _, h := range c.Hourly {
+			maxTemp = math.Max(maxTemp, h.Temperature)
+			meanTemp += h.Temperature
+		}
+		meanTemp /= float64(len(c.Hourly))
+		result.Value = maxTemp
+		result.Detail = fmt.Sprintf("最高温 %.1f°C, 平均 %.1f°C", maxTemp, meanTemp)
+		return rankEntry{result: result, keys: []float64{-maxTemp, -meanTemp}}, true
+
+	case CriterionLeastRainy:
+		summary := SummarizePrecipitation(c.Hourly)
+		result.Value = summary.PeakProbability
+		result.Detail = fmt.Sprintf("最高降水概率 %.0f%%, 累计降水 %.1fmm", summary.PeakProbability*100, summary.TotalAmount)
+		return rankEntry{result: result, keys: []float64{summary.PeakProbability, summary.TotalAmount}}, true
+
+	case CriterionLowestWind:
+		maxWind, meanWind := 0.0, 0.0
+		for _, h := range c.Hourly {
+			maxWind = math.Max(maxWind, h.WindSpeed)
+			meanWind += h.WindSpeed
+		}
+		meanWind /= float64(len(c.Hourly))
+		result.Value = maxWind
+		result.Detail = fmt.Sprintf("最大风速 %.1fm/s, 平均 %.1fm/s", maxWind, meanWind)
+		return rankEntry{result: result, keys: []float64{maxWind, meanWind}}, true
+
+	case CriterionMostComfortable:
+		meanHI, worstDeviation := 0.0, 0.0
+		for _, h := range c.Hourly {
+			hi := HeatIndex(h.Temperature, h.Humidity)
+			meanHI += hi
+			worstDeviation = math.Max(worstDeviation, math.Abs(hi-ComfortableHeatIndex))
+		}
+		meanHI /= float64(len(c.Hourly))
+		result.Value = meanHI
+		result.Detail = fmt.Sprintf("平均酷热指数 %.1f°C, 偏离舒适值最多 %.1f°C", meanHI, worstDeviation)
+		return rankEntry{result: result, keys: []float64{math.Abs(meanHI - ComfortableHeatIndex), worstDeviation}}, true
+	}
+
+	return rankEntry{}, false
+}
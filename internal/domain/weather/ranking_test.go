@@ -0,0 +1,91 @@
+package weather
+
+import (
+	"testing"
+)
+
+func TestRankLocations(t *testing.T) {
+	shanghai := LocationConditions{
+		Name: "上海",
+		Hourly: []HourlyWeather{
+			{Temperature: 26, Humidity: 80, WindSpeed: 6, PrecipProbability: 0.7, PrecipAmount: 3},
+			{Temperature: 29, Humidity: 75, WindSpeed: 4, PrecipProbability: 0.5, PrecipAmount: 1},
+		},
+		AirQuality: &AirQuality{AQI: 2, PM25: 30},
+	}
+	chengdu := LocationConditions{
+		Name: "成都",
+		Hourly: []HourlyWeather{
+			{Temperature: 21, Humidity: 60, WindSpeed: 1.5, PrecipProbability: 0.2},
+			{Temperature: 23, Humidity: 55, WindSpeed: 2, PrecipProbability: 0.1},
+		},
+		AirQuality: &AirQuality{AQI: 3, PM25: 60},
+	}
+	xian := LocationConditions{
+		Name: "西安",
+		Hourly: []HourlyWeather{
+			{Temperature: 31, Humidity: 30, WindSpeed: 3, PrecipProbability: 0.2, PrecipAmount: 0.5},
+			{Temperature: 33, Humidity: 25, WindSpeed: 5, PrecipProbability: 0},
+		},
+		AirQuality: &AirQuality{AQI: 2, PM25: 25},
+	}
+	missing := LocationConditions{Name: "Atlantis"}
+	all := []LocationConditions{shanghai, chengdu, missing, xian}
+
+	tests := []struct {
+		criterion RankCriterion
+		expected  []string
+	}{
+		{CriterionWarmest, []string{"西安", "上海", "成都", "Atlantis"}},
+		{CriterionLeastRainy, []string{"成都", "西安", "上海", "Atlantis"}},
+		{CriterionLowestWind, []string{"成都", "西安", "上海", "Atlantis"}},
+		{CriterionBestAQI, []string{"西安", "上海", "成都", "Atlantis"}},
+		{CriterionMostComfortable, []string{"成都", "上海", "西安", "Atlantis"}},
+	}
+
+	for _, test := range tests {
+		rankings, err := RankLocations(all, test.criterion)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.criterion, err)
+		}
+		if len(rankings) != len(test.expected) {
+			t.Fatalf("%s: expected %d rankings, got %d", test.criterion, len(test.expected), len(rankings))
+		}
+		for i, name := range test.expected {
+			if rankings[i].Name != name {
+				t.Errorf("%s: expected %s at position %d, got %s", test.criterion, name, i+1, rankings[i].Name)
+			}
+		}
+		last := rankings[len(rankings)-1]
+		if !last.Unranked || last.Rank != 0 {
+			t.Errorf("%s: expected location without data to be unranked, got %+v", test.criterion, last)
+		}
+	}
+}
+
+func TestRankLocationsTieBreaksByName(t *testing.T) {
+	same := []HourlyWeather{{Temperature: 20, WindSpeed: 2}}
+	conditions := []LocationConditions{
+		{Name: "b", Hourly: same},
+		{Name: "c", Hourly: same},
+		{Name: "a", Hourly: same},
+	}
+
+	for i := 0; i < 3; i++ {
+		rankings, err := RankLocations(conditions, CriterionLowestWind)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for j, name := range []string{"a", "b", "c"} {
+			if rankings[j].Name != name || rankings[j].Rank != j+1 {
+				t.Errorf("Expected %s ranked %d, got %+v", name, j+1, rankings[j])
+			}
+		}
+	}
+}
+
+func TestRankLocationsUnsupportedCriterion(t *testing.T) {
+	if _, err := RankLocations(nil, RankCriterion("sunniest")); err == nil {
+		t.Errorf("Expected error for unsupported criterion")
+	}
+}
@@ -0,0 +1,126 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// EarthRadiusKm 地球平均半径（IUGG，km）
+const EarthRadiusKm = 6371.0088
+
+// 路线危险天气阈值
+const (
+	// HazardWindSpeed 大风阈值（m/s），对应蒲福风级7级
+	HazardWindSpeed = 13.9
+	// HazardPrecipAmount 强降水阈值（mm/3h）
+	HazardPrecipAmount = 10.0
+	// HazardVisibility 低能见度阈值（m）
+	HazardVisibility = 1000
+	// HazardHeat 高温阈值（°C）
+	HazardHeat = 35.0
+)
+
+// slotHalfWidth 3小时预报时段覆盖的半宽
+const slotHalfWidth = 90 * time.Minute
+
+// slotStep 预报时段间隔，首个时段最晚在当前时间之后一个间隔发布
+const slotStep = 3 * time.Hour
+
+// GreatCircleDistance 使用 haversine 公式计算两点间的大圆距离（km）
+//
+//	a = sin²(Δφ/2) + cos φ1 · cos φ2 · sin²(Δλ/2)
+//	d = 2R · atan2(√a, √(1−a))
+func GreatCircleDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// RoutePoint 路线途经点
+type RoutePoint struct {
+	Lat float64
+	Lon float64
+	ETA *time.Time // 指定的到达时间，为空时按平均速度推算
+}
+
+// ComputeETAs 计算各途经点的到达时间和与上一点的距离（km）
+// 第一个点未指定到达时间时使用出发时间；其余点未指定时由上一点的到达时间加上
+// 大圆距离除以平均速度推算，此时 speedKmh 必须为正数。指定的时间不能早于上一点。
+func ComputeETAs(points []RoutePoint, departure time.Time, speedKmh float64) ([]time.Time, []float64, error) {
+	etas := make([]time.Time, len(points))
+	distances := make([]float64, len(points))
+	for i, p := range points {
+		if i > 0 {
+			prev := points[i-1]
+			distances[i] = GreatCircleDistance(prev.Lat, prev.Lon, p.Lat, p.Lon)
+		}
+
+		switch {
+		case p.ETA != nil:
+			etas[i] = *p.ETA
+		case i == 0:
+			etas[i] = departure
+		default:
+			if speedKmh <= 0 {
+				return nil, nil, fmt.Errorf("average speed is required to estimate arrival at waypoint %d", i+1)
+			}
+			travel := time.Duration(distances[i] / speedKmh * float64(time.Hour))
+			etas[i] = etas[i-1].Add(travel)
+		}
+
+		if i > 0 && etas[i].Before(etas[i-1]) {
+			return nil, nil, fmt.Errorf("arrival at waypoint %d is before waypoint %d", i+1, i)
+		}
+	}
+	return etas, distances, nil
+}
+
+// SlotAt 选择覆盖指定时间的3小时预报时段
+// 时段以预报时间为中心覆盖前后各1.5小时；首个时段向前覆盖一个完整间隔，
+// 使按当前时间出发的途经点也能取到预报。超出预报范围时返回 false
+func SlotAt(hourly []HourlyWeather, at time.Time) (HourlyWeather, bool) {
+	if len(hourly) > 0 && at.Before(hourly[0].Date) && hourly[0].Date.Sub(at) <= slotStep {
+		return hourly[0], true
+	}
+	best, found := HourlyWeather{}, false
+	var bestDiff time.Duration
+	for _, h := range hourly {
+		diff := at.Sub(h.Date)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= slotHalfWidth && (!found || diff < bestDiff) {
+			best, bestDiff, found = h, diff, true
+		}
+	}
+	return best, found
+}
+
+// Hazards 识别预报时段中影响出行的危险天气
+func Hazards(h HourlyWeather) []string {
+	var hazards []string
+	if h.WindSpeed >= HazardWindSpeed {
+		hazards = append(hazards, fmt.Sprintf("大风 %.1fm/s", h.WindSpeed))
+	}
+	if h.PrecipAmount >= HazardPrecipAmount {
+		hazards = append(hazards, fmt.Sprintf("强降水 %.1fmm", h.PrecipAmount))
+	}
+	if h.PrecipType == PrecipitationSnow || h.PrecipType == PrecipitationMixed {
+		hazards = append(hazards, "降雪")
+	}
+	if h.Temperature <= 0 && h.PrecipAmount > 0 {
+		hazards = append(hazards, "路面结冰")
+	}
+	if h.Visibility != nil && *h.Visibility < HazardVisibility {
+		hazards = append(hazards, fmt.Sprintf("低能见度 %dm", *h.Visibility))
+	}
+	if h.Temperature >= HazardHeat {
+		hazards = append(hazards, fmt.Sprintf("高温 %.1f°C", h.Temperature))
+	}
+	return hazards
+}
@@ -0,0 +1,144 @@
+package weather
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGreatCircleDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		expected               float64
+	}{
+		{"same point", 39.9042, 116.4074, 39.9042, 116.4074, 0},
+		{"beijing to shanghai", 39.9042, 116.4074, 31.2304, 121.4737, 1067.3},
+		{"quarter meridian", 0, 0, 90, 0, 10007.6},
+		{"across antimeridian", 0, 179.5, 0, -179.5, 111.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GreatCircleDistance(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.expected) > 0.5 {
+				t.Errorf("Expected %.1f km, got %.1f km", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestComputeETAs(t *testing.T) {
+	departure := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+	fixed := departure.Add(5 * time.Hour)
+
+	// 沿赤道每1度约111.2km
+	points := []RoutePoint{
+		{Lat: 0, Lon: 0},
+		{Lat: 0, Lon: 1},
+		{Lat: 0, Lon: 2, ETA: &fixed},
+		{Lat: 0, Lon: 3},
+	}
+
+	etas, distances, err := ComputeETAs(points, departure, 111.19)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []time.Time{departure, departure.Add(time.Hour), fixed, fixed.Add(time.Hour)}
+	for i := range expected {
+		if diff := etas[i].Sub(expected[i]); diff < -time.Minute || diff > time.Minute {
+			t.Errorf("Expected ETA %d to be %v, got %v", i, expected[i], etas[i])
+		}
+	}
+	if distances[0] != 0 {
+		t.Errorf("Expected first distance to be 0, got %f", distances[0])
+	}
+	if math.Abs(distances[1]-111.19) > 0.1 {
+		t.Errorf("Expected second distance to be about 111.19, got %f", distances[1])
+	}
+}
+
+func TestComputeETAsErrors(t *testing.T) {
+	departure := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+	early := departure.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		points []RoutePoint
+		speed  float64
+	}{
+		{"missing speed", []RoutePoint{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}}, 0},
+		{"arrival before previous", []RoutePoint{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1, ETA: &early}}, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ComputeETAs(tt.points, departure, tt.speed); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSlotAt(t *testing.T) {
+	base := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	hourly := []HourlyWeather{
+		{Date: base, Temperature: 10},
+		{Date: base.Add(3 * time.Hour), Temperature: 13},
+		{Date: base.Add(6 * time.Hour), Temperature: 16},
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		found    bool
+		expected float64
+	}{
+		{"exact slot", base.Add(3 * time.Hour), true, 13},
+		{"nearest earlier slot", base.Add(4 * time.Hour), true, 13},
+		{"nearest later slot", base.Add(5 * time.Hour), true, 16},
+		{"edge of first slot", base.Add(-90 * time.Minute), true, 10},
+		{"departure before first slot", base.Add(-150 * time.Minute), true, 10},
+		{"before coverage", base.Add(-4 * time.Hour), false, 0},
+		{"after coverage", base.Add(8 * time.Hour), false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, found := SlotAt(hourly, tt.at)
+			if found != tt.found {
+				t.Fatalf("Expected found %v, got %v", tt.found, found)
+			}
+			if found && slot.Temperature != tt.expected {
+				t.Errorf("Expected slot temperature %.0f, got %.0f", tt.expected, slot.Temperature)
+			}
+		})
+	}
+}
+
+func TestHazards(t *testing.T) {
+	tests := []struct {
+		name     string
+		hourly   HourlyWeather
+		expected int
+	}{
+		{"clear", HourlyWeather{Temperature: 20, WindSpeed: 3, Visibility: integer(10000)}, 0},
+		{"gale", HourlyWeather{Temperature: 20, WindSpeed: 15, Visibility: integer(10000)}, 1},
+		{"fog", HourlyWeather{Temperature: 10, Visibility: integer(200)}, 1},
+		{"freezing snow", HourlyWeather{Temperature: -2, PrecipAmount: 3, PrecipType: PrecipitationSnow, Visibility: integer(5000)}, 2},
+		{"heavy rain", HourlyWeather{Temperature: 25, PrecipAmount: 12, PrecipType: PrecipitationRain, Visibility: integer(5000)}, 1},
+		{"heat", HourlyWeather{Temperature: 38, Visibility: integer(10000)}, 1},
+		{"visibility missing", HourlyWeather{Temperature: 20, WindSpeed: 3}, 0},
+		{"unknown visibility", HourlyWeather{Temperature: 20}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hazards(tt.hourly)
+			if len(got) != tt.expected {
+				t.Errorf("Expected %d hazards, got %v", tt.expected, got)
+			}
+		})
+	}
+}
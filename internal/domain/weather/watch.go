@@ -0,0 +1,225 @@
+package weather
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WatchMetric 监测规则的指标
+type WatchMetric string
+
+const (
+	// WatchTemperature 实时温度（°C）
+	WatchTemperature WatchMetric = "temperature"
+	// WatchWindSpeed 实时风速（m/s）
+	WatchWindSpeed WatchMetric = "wind_speed"
+	// WatchPM25 实时PM2.5浓度（μg/m³）
+	WatchPM25 WatchMetric = "pm25"
+	// WatchAQI 实时空气质量等级（1-5）
+	WatchAQI WatchMetric = "aqi"
+	// WatchPrecipProbability 未来若干小时内的最高降水概率（%）
+	WatchPrecipProbability WatchMetric = "precip_probability"
+)
+
+// WatchMetrics 所有支持的监测指标
+var WatchMetrics = []WatchMetric{WatchTemperature, WatchWindSpeed, WatchPM25, WatchAQI, WatchPrecipProbability}
+
+// WatchOperator 监测规则的比较运算符
+type WatchOperator string
+
+const (
+	WatchAbove     WatchOperator = ">"
+	WatchAtOrAbove WatchOperator = ">="
+	WatchBelow     WatchOperator = "<"
+	WatchAtOrBelow WatchOperator = "<="
+)
+
+const (
+	// watchIDLength 规则ID的长度（十六进制字符）
+	watchIDLength = 12
+	// defaultWatchHours 预报类指标默认的监测时长
+	defaultWatchHours = 6
+)
+
+// WatchOperators 所有支持的比较运算符
+var WatchOperators = []WatchOperator{WatchAbove, WatchAtOrAbove, WatchBelow, WatchAtOrBelow}
+
+// WatchRule 阈值监测规则
+type WatchRule struct {
+	ID              string        `json:"id"`
+	Owner           string        `json:"owner"`
+	Tenant          string        `json:"tenant,omitempty"` // 添加规则时的租户，为空表示使用服务器密钥
+	Location        string        `json:"location"`
+	Metric          WatchMetric   `json:"metric"`
+	Operator        WatchOperator `json:"operator"`
+	Threshold       float64       `json:"threshold"`
+	WithinHours     int           `json:"within_hours,omitempty"`     // 仅用于预报类指标
+	CooldownMinutes int           `json:"cooldown_minutes,omitempty"` // 为0时使用默认冷却时间
+	CreatedAt       time.Time     `json:"created_at"`
+	LastTriggered   *time.Time    `json:"last_triggered,omitempty"`
+}
+
+// WatchEvent 监测规则触发事件
+type WatchEvent struct {
+	Rule        WatchRule `json:"rule"`
+	Value       float64   `json:"value"`
+	TriggeredAt time.Time `json:"triggered_at"`
+	Message     string    `json:"message"`
+}
+
+// WatchRuleRepository 监测规则仓储接口
+type WatchRuleRepository interface {
+	// LoadWatchRules 加载所有监测规则
+	LoadWatchRules() ([]WatchRule, error)
+	// SaveWatchRules 保存所有监测规则
+	SaveWatchRules(rules []WatchRule) error
+}
+
+// WatchEventPublisher 监测事件发布接口，如MCP日志通知或Webhook
+type WatchEventPublisher interface {
+	PublishWatchEvent(event WatchEvent) error
+}
+
+// Normalize 规范化规则并校验，为预报类指标补全默认时长，并根据规则内容生成ID
+// 内容相同（所有者、位置、指标、运算符、阈值、时长）的规则生成相同的ID，用于去重
+func (r *WatchRule) Normalize() error {
+	r.Location = strings.TrimSpace(r.Location)
+	if r.Location == "" {
+		return fmt.Errorf("location is required")
+	}
+	if !containsMetric(r.Metric) {
+		return fmt.Errorf("unsupported metric: %s", r.Metric)
+	}
+	if !containsOperator(r.Operator) {
+		return fmt.Errorf("unsupported operator: %s", r.Operator)
+	}
+	if r.CooldownMinutes < 0 {
+		return fmt.Errorf("cooldown must not be negative")
+	}
+
+	if r.Metric == WatchPrecipProbability {
+		if r.WithinHours == 0 {
+			r.WithinHours = defaultWatchHours
+		}
+		if r.WithinHours < 1 || r.WithinHours > 120 {
+			return fmt.Errorf("within_hours must be between 1 and 120")
+		}
+		if r.Threshold < 0 || r.Threshold > 100 {
+			return fmt.Errorf("precipitation probability threshold must be between 0 and 100")
+		}
+	} else {
+		r.WithinHours = 0
+	}
+
+	key := strings.Join([]string{
+		r.Owner, strings.ToLower(r.Location), string(r.Metric), string(r.Operator),
+		fmt.Sprintf("%g", r.Threshold), fmt.Sprintf("%d", r.WithinHours),
+	}, "|")
+	sum := sha1.Sum([]byte(key))
+	r.ID = hex.EncodeToString(sum[:])[:watchIDLength]
+	return nil
+}
+
+// Matches 判断指标值是否满足规则条件
+func (r WatchRule) Matches(value float64) bool {
+	switch r.Operator {
+	case WatchAbove:
+		return value > r.Threshold
+	case WatchAtOrAbove:
+		return value >= r.Threshold
+	case WatchBelow:
+		return value < r.Threshold
+	case WatchAtOrBelow:
+		return value <= r.Threshold
+	default:
+		return false
+	}
+}
+
+// CoolingDown 判断规则是否仍处于上次触发后的冷却期
+func (r WatchRule) CoolingDown(now time.Time, defaultCooldown time.Duration) bool {
+	if r.LastTriggered == nil {
+		return false
+	}
+	cooldown := defaultCooldown
+	if r.CooldownMinutes > 0 {
+		cooldown = time.Duration(r.CooldownMinutes) * time.Minute
+	}
+	return now.Sub(*r.LastTriggered) < cooldown
+}
+
+// Describe 获取规则的可读描述，如 "北京 PM2.5 > 150"
+func (r WatchRule) Describe() string {
+	desc := fmt.Sprintf("%s %s %s %g%s", r.Location, watchMetricLabel(r.Metric), r.Operator, r.Threshold, watchMetricUnit(r.Metric))
+	if r.WithinHours > 0 {
+		desc += fmt.Sprintf("（未来%d小时）", r.WithinHours)
+	}
+	return desc
+}
+
+// watchMetricLabel 获取指标的中文名称
+func watchMetricLabel(m WatchMetric) string {
+	switch m {
+	case WatchTemperature:
+		return "温度"
+	case WatchWindSpeed:
+		return "风速"
+	case WatchPM25:
+		return "PM2.5"
+	case WatchAQI:
+		return "AQI等级"
+	case WatchPrecipProbability:
+		return "降水概率"
+	default:
+		return string(m)
+	}
+}
+
+// watchMetricUnit 获取指标的单位
+func watchMetricUnit(m WatchMetric) string {
+	switch m {
+	case WatchTemperature:
+		return "°C"
+	case WatchWindSpeed:
+		return "m/s"
+	case WatchPM25:
+		return "μg/m³"
+	case WatchPrecipProbability:
+		return "%"
+	default:
+		return ""
+	}
+}
+
+// containsMetric 判断是否为支持的监测指标
+func containsMetric(m WatchMetric) bool {
+	for _, metric := range WatchMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// containsOperator 判断是否为支持的比较运算符
+func containsOperator(op WatchOperator) bool {
+	for _, o := range WatchOperators {
+		if op == o {
+			return true
+		}
+	}
+	return false
+}
+
+// NewWatchEvent 创建规则触发事件
+func NewWatchEvent(rule WatchRule, value float64, at time.Time) WatchEvent {
+	return WatchEvent{
+		Rule:        rule,
+		Value:       value,
+		TriggeredAt: at,
+		Message:     fmt.Sprintf("⚠️ 天气监测触发: %s，当前值 %.1f%s", rule.Describe(), value, watchMetricUnit(rule.Metric)),
+	}
+}
@@ -0,0 +1,61 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchRuleNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    WatchRule
+		wantErr bool
+		hours   int
+	}{
+		{"valid pm25", WatchRule{Location: "北京", Metric: WatchPM25, Operator: WatchAbove, Threshold: 150}, false, 0},
+		{"default forecast hours", WatchRule{Location: "office", Metric: WatchPrecipProbability, Operator: WatchAbove, Threshold: 70}, false, 6},
+		{"hours ignored for current metric", WatchRule{Location: "北京", Metric: WatchTemperature, Operator: WatchBelow, WithinHours: 6}, false, 0},
+		{"missing location", WatchRule{Metric: WatchPM25, Operator: WatchAbove}, true, 0},
+		{"unknown metric", WatchRule{Location: "北京", Metric: "pollen", Operator: WatchAbove}, true, 0},
+		{"unknown operator", WatchRule{Location: "北京", Metric: WatchPM25, Operator: "=="}, true, 0},
+		{"probability out of range", WatchRule{Location: "北京", Metric: WatchPrecipProbability, Operator: WatchAbove, Threshold: 140}, true, 0},
+		{"hours out of range", WatchRule{Location: "北京", Metric: WatchPrecipProbability, Operator: WatchAbove, WithinHours: 121}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Normalize()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (tt.rule.ID == "" || tt.rule.WithinHours != tt.hours) {
+				t.Errorf("Expected ID and %d hours, got %q and %d", tt.hours, tt.rule.ID, tt.rule.WithinHours)
+			}
+		})
+	}
+}
+
+func TestWatchRuleMatchesAndCooldown(t *testing.T) {
+	rule := WatchRule{Operator: WatchAtOrAbove, Threshold: 70}
+	if !rule.Matches(70) || rule.Matches(69.9) {
+		t.Error("Expected >= to include the threshold only")
+	}
+	rule.Operator = WatchBelow
+	if !rule.Matches(69.9) || rule.Matches(70) {
+		t.Error("Expected < to exclude the threshold")
+	}
+
+	now := time.Unix(1700000000, 0)
+	if rule.CoolingDown(now, time.Hour) {
+		t.Error("Expected untriggered rule not to be cooling down")
+	}
+	last := now.Add(-30 * time.Minute)
+	rule.LastTriggered = &last
+	if !rule.CoolingDown(now, time.Hour) {
+		t.Error("Expected rule to be cooling down within default cooldown")
+	}
+	rule.CooldownMinutes = 15
+	if rule.CoolingDown(now, time.Hour) {
+		t.Error("Expected custom cooldown to take precedence")
+	}
+}
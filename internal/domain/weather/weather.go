@@ -1,81 +1,183 @@
 package weather
 
 import (
+	"context"
+	"fmt"
 	"time"
 )
 
 // Weather 天气实体
 type Weather struct {
-	Location    Location
-	Current     CurrentWeather
-	Forecast    []ForecastWeather
-	LastUpdated time.Time
+	Location    Location          `json:"location"`
+	Current     CurrentWeather    `json:"current"`
+	Forecast    []ForecastWeather `json:"forecast,omitempty"`
+	Alerts      []Alert           `json:"alerts,omitempty"`
+	LastUpdated time.Time         `json:"last_updated"`
+}
+
+// Alert 气象预警值对象
+type Alert struct {
+	Event       string    `json:"event"`
+	Sender      string    `json:"sender,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Description string    `json:"description,omitempty"`
 }
 
 // Location 位置值对象
 type Location struct {
-	City    string
-	Country string
-	Lat     float64
-	Lon     float64
+	ID        int64   `json:"id,omitempty"` // 数据源的城市ID，可用于后续查询固定到同一位置，未知时为0
+	City      string  `json:"city"`
+	Country   string  `json:"country"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Timezone  string  `json:"timezone,omitempty"` // IANA时区名，未知时为空
+	UTCOffset int     `json:"utc_offset"`         // 相对UTC的偏移秒数
+}
+
+// Zone 获取位置所在时区
+// 优先使用IANA时区名，否则根据UTC偏移构造固定时区
+func (l Location) Zone() *time.Location {
+	if l.Timezone != "" {
+		if loc, err := time.LoadLocation(l.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(FormatUTCOffset(l.UTCOffset), l.UTCOffset)
+}
+
+// LocalTime 将时间转换为位置所在时区的本地时间
+func (l Location) LocalTime(t time.Time) time.Time {
+	return t.In(l.Zone())
+}
+
+// FormatUTCOffset 将UTC偏移秒数格式化为 UTC+08:00 形式
+func FormatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, offset/3600, offset%3600/60)
 }
 
 // CurrentWeather 当前天气值对象
 type CurrentWeather struct {
-	Temperature float64
-	FeelsLike   float64
-	Humidity    int
-	Pressure    int
-	WindSpeed   float64
-	WindDir     string
-	Description string
-	Icon        string
+	Temperature float64  `json:"temperature"`
+	FeelsLike   float64  `json:"feels_like"`
+	Humidity    int      `json:"humidity"`
+	Pressure    int      `json:"pressure"`
+	WindSpeed   float64  `json:"wind_speed"`
+	WindDir     string   `json:"wind_dir"`
+	Description string   `json:"description"`
+	Icon        string   `json:"icon"`
+	UVIndex     *float64 `json:"uv_index,omitempty"` // 紫外线指数，数据源不提供时为空
 }
 
 // ForecastWeather 预报天气值对象
 type ForecastWeather struct {
-	Date        time.Time
+	Date        time.Time `json:"date"`
 	Temperature struct {
-		Min float64
-		Max float64
-	}
-	Humidity    int
-	Description string
-	Icon        string
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	} `json:"temperature"`
+	Humidity    int    `json:"humidity"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
 }
 
 // HourlyWeather 小时级天气预报值对象
 type HourlyWeather struct {
-	Date        time.Time
-	Temperature float64
-	FeelsLike   float64
-	Humidity    int
-	Pressure    int
-	WindSpeed   float64
-	WindDir     string
-	Description string
-	Icon        string
+	Date              time.Time         `json:"date"`
+	Temperature       float64           `json:"temperature"`
+	FeelsLike         float64           `json:"feels_like"`
+	Humidity          int               `json:"humidity"`
+	Pressure          int               `json:"pressure"`
+	WindSpeed         float64           `json:"wind_speed"`
+	WindDir           string            `json:"wind_dir"`
+	Description       string            `json:"description"`
+	Icon              string            `json:"icon"`
+	PrecipProbability float64           `json:"precip_probability"`   // 降水概率 (0-1)
+	PrecipAmount      float64           `json:"precip_amount"`        // 时段内降水量 (mm)
+	PrecipType        PrecipitationType `json:"precip_type"`          // 降水类型
+	Visibility        *int              `json:"visibility,omitempty"` // 能见度 (m)，数据源未提供时为空
+}
+
+// PrecipitationType 降水类型
+type PrecipitationType string
+
+const (
+	PrecipitationNone  PrecipitationType = "none"
+	PrecipitationRain  PrecipitationType = "rain"
+	PrecipitationSnow  PrecipitationType = "snow"
+	PrecipitationMixed PrecipitationType = "mixed"
+)
+
+// PrecipitationSummary 降水汇总值对象
+type PrecipitationSummary struct {
+	TotalAmount     float64   `json:"total_amount"`     // 累计降水量 (mm)
+	PeakProbability float64   `json:"peak_probability"` // 最高降水概率 (0-1)
+	PeakAt          time.Time `json:"peak_at"`          // 最高降水概率出现的时间
+}
+
+// SummarizePrecipitation 汇总时间窗口内的累计降水量和最高降水概率
+func SummarizePrecipitation(hourly []HourlyWeather) PrecipitationSummary {
+	var summary PrecipitationSummary
+	for i, h := range hourly {
+		summary.TotalAmount += h.PrecipAmount
+		if i == 0 || h.PrecipProbability > summary.PeakProbability {
+			summary.PeakProbability = h.PrecipProbability
+			summary.PeakAt = h.Date
+		}
+	}
+	return summary
 }
 
 // HourlyWeatherResult 小时级天气预报结果
 type HourlyWeatherResult struct {
-	Location    Location
-	Hourly      []HourlyWeather
-	LastUpdated time.Time
+	Location    Location        `json:"location"`
+	Hourly      []HourlyWeather `json:"hourly"`
+	LastUpdated time.Time       `json:"last_updated"`
 }
 
 // WeatherRepository 天气仓储接口
 type WeatherRepository interface {
-	GetCurrentWeather(lat, lon float64) (*Weather, error)
-	GetWeatherByCity(city string) (*Weather, error)
-	GetHourlyWeatherByCoords(lat, lon float64, hours int) (*HourlyWeatherResult, error)
-	GetHourlyWeatherByCity(city string, hours int) (*HourlyWeatherResult, error)
+	GetCurrentWeather(ctx context.Context, lat, lon float64) (*Weather, error)
+	GetWeatherByCity(ctx context.Context, city string) (*Weather, error)
+	GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*HourlyWeatherResult, error)
+	GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*HourlyWeatherResult, error)
+}
+
+// PostalCode 邮政编码及其所属国家
+type PostalCode struct {
+	Code    string `json:"code"`
+	Country string `json:"country"` // ISO 3166 两位国家代码
+}
+
+// String 格式化为 code,country 形式
+func (p PostalCode) String() string {
+	return p.Code + "," + p.Country
+}
+
+// PlaceLookupRepository 按数据源城市ID和邮政编码查询的仓储接口
+// 作为 WeatherRepository 的可选能力，城市ID不会像城市名那样匹配到同名的其他位置
+type PlaceLookupRepository interface {
+	GetWeatherByCityID(ctx context.Context, id int64) (*Weather, error)
+	GetHourlyWeatherByCityID(ctx context.Context, id int64, hours int) (*HourlyWeatherResult, error)
+	GetWeatherByPostalCode(ctx context.Context, code PostalCode) (*Weather, error)
+	GetHourlyWeatherByPostalCode(ctx context.Context, code PostalCode, hours int) (*HourlyWeatherResult, error)
+}
+
+// LocationCatalog 位置目录接口
+// 作为 WeatherRepository 的可选能力，由内置已知位置的数据源实现
+type LocationCatalog interface {
+	KnownLocations() []string
 }
 
 // WeatherService 天气服务接口
 type WeatherService interface {
-	GetCurrentWeather(lat, lon float64) (*Weather, error)
-	GetWeatherByCity(city string) (*Weather, error)
-	GetHourlyWeatherByCoords(lat, lon float64, hours int) (*HourlyWeatherResult, error)
-	GetHourlyWeatherByCity(city string, hours int) (*HourlyWeatherResult, error)
+	GetCurrentWeather(ctx context.Context, lat, lon float64) (*Weather, error)
+	GetWeatherByCity(ctx context.Context, city string) (*Weather, error)
+	GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*HourlyWeatherResult, error)
+	GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*HourlyWeatherResult, error)
 }
@@ -0,0 +1,118 @@
+package weather
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarizePrecipitation(t *testing.T) {
+	base := time.Unix(1642248600, 0)
+
+	tests := []struct {
+		name         string
+		hourly       []HourlyWeather
+		expectedSum  float64
+		expectedPeak float64
+		expectedAt   time.Time
+	}{
+		{
+			name:   "empty window",
+			hourly: nil,
+		},
+		{
+			name: "dry window",
+			hourly: []HourlyWeather{
+				{Date: base, PrecipProbability: 0},
+				{Date: base.Add(3 * time.Hour), PrecipProbability: 0},
+			},
+			expectedAt: base,
+		},
+		{
+			name: "rain in the afternoon",
+			hourly: []HourlyWeather{
+				{Date: base, PrecipProbability: 0.2},
+				{Date: base.Add(3 * time.Hour), PrecipProbability: 0.8, PrecipAmount: 2.5},
+				{Date: base.Add(6 * time.Hour), PrecipProbability: 0.6, PrecipAmount: 1.25},
+			},
+			expectedSum:  3.75,
+			expectedPeak: 0.8,
+			expectedAt:   base.Add(3 * time.Hour),
+		},
+	}
+
+	for _, test := range tests {
+		summary := SummarizePrecipitation(test.hourly)
+		if summary.TotalAmount != test.expectedSum {
+			t.Errorf("%s: expected total %f, got %f", test.name, test.expectedSum, summary.TotalAmount)
+		}
+		if summary.PeakProbability != test.expectedPeak {
+			t.Errorf("%s: expected peak %f, got %f", test.name, test.expectedPeak, summary.PeakProbability)
+		}
+		if !summary.PeakAt.Equal(test.expectedAt) {
+			t.Errorf("%s: expected peak time %v, got %v", test.name, test.expectedAt, summary.PeakAt)
+		}
+	}
+}
+
+func TestFormatUTCOffset(t *testing.T) {
+	tests := []struct {
+		offset   int
+		expected string
+	}{
+		{0, "UTC+00:00"},
+		{28800, "UTC+08:00"},
+		{-18000, "UTC-05:00"},
+		{19800, "UTC+05:30"},
+		{-12600, "UTC-03:30"},
+	}
+
+	for _, test := range tests {
+		result := FormatUTCOffset(test.offset)
+		if result != test.expected {
+			t.Errorf("For offset %d, expected %s, got %s", test.offset, test.expected, result)
+		}
+	}
+}
+
+func TestLocationLocalTime(t *testing.T) {
+	ts := time.Unix(1642257000, 0) // 2022-01-15 14:30:00 UTC
+
+	tests := []struct {
+		name     string
+		location Location
+		expected string
+	}{
+		{"utc offset", Location{UTCOffset: -18000}, "2022-01-15 09:30 UTC-05:00"},
+		{"iana zone", Location{Timezone: "Asia/Shanghai", UTCOffset: 28800}, "2022-01-15 22:30 CST"},
+		{"unknown iana zone falls back to offset", Location{Timezone: "Nowhere/Land", UTCOffset: 3600}, "2022-01-15 15:30 UTC+01:00"},
+	}
+
+	for _, test := range tests {
+		result := test.location.LocalTime(ts).Format("2006-01-02 15:04 MST")
+		if result != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestHourlyWeatherResultJSONUsesRFC3339Offsets(t *testing.T) {
+	location := Location{City: "New York", Country: "US", UTCOffset: -18000}
+	result := HourlyWeatherResult{
+		Location:    location,
+		Hourly:      []HourlyWeather{{Date: location.LocalTime(time.Unix(1642257000, 0))}},
+		LastUpdated: location.LocalTime(time.Unix(1642257000, 0)),
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to marshal result: %v", err)
+	}
+	if !strings.Contains(string(data), `"date":"2022-01-15T09:30:00-05:00"`) {
+		t.Errorf("Expected RFC 3339 date with offset, got %s", data)
+	}
+	if !strings.Contains(string(data), `"last_updated":"2022-01-15T09:30:00-05:00"`) {
+		t.Errorf("Expected RFC 3339 last_updated with offset, got %s", data)
+	}
+}
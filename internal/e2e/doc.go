@@ -0,0 +1,5 @@
+// Package e2e 端到端MCP协议测试
+// 测试通过进程内传输连接完整组装的MCP服务器，使用模拟天气数据源离线运行，
+// 覆盖从工具参数解析、应用服务到响应格式化的完整链路，并用golden文件断言工具定义和响应内容。
+// 修改了工具定义或响应格式时，使用 go test ./internal/e2e -update 更新golden文件。
+package e2e
@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mcpproto "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolsList(t *testing.T) {
+	h := newHarness(t, "typhoon-xiamen")
+	result, err := h.client.ListTools(context.Background(), mcpproto.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := json.MarshalIndent(result.Tools, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "tools_list", string(data)+"\n")
+}
+
+func TestGetWeather(t *testing.T) {
+	tests := []struct {
+		name      string
+		scenario  string
+		offset    time.Duration
+		arguments map[string]any
+	}{
+		// 有效参数
+		{"current_city", "typhoon-xiamen", 0, map[string]any{"location": "厦门"}},
+		{"current_alias", "typhoon-xiamen", 0, map[string]any{"location": "Xiamen"}},
+		{"current_coordinates", "typhoon-xiamen", 0, map[string]any{"location": "24.4798,118.0894"}},
+		{"current_landfall", "typhoon-xiamen", 30 * time.Hour, map[string]any{"location": "厦门"}},
+		{"current_heatwave", "heatwave", 7 * time.Hour, map[string]any{"location": "重庆"}},
+		{"forecast_6h", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": 6}},
+		{"forecast_12h", "heatwave", 0, map[string]any{"location": "上海", "hours": 12}},
+		{"sensor_dropout_stale", "sensor-dropout", 5 * time.Hour, map[string]any{"location": "深圳"}},
+		{"current_city_id", "typhoon-xiamen", 0, map[string]any{"location": "id:1790645"}},
+		{"forecast_city_id", "typhoon-xiamen", 0, map[string]any{"location": "id:1797353", "hours": 6}},
+		{"current_postal_code", "heatwave", 0, map[string]any{"location": "zip:400000,CN"}},
+
+		// 边界参数
+		{"hours_zero", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": 0}},
+		{"hours_one", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": 1}},
+		{"coordinates_with_space", "typhoon-xiamen", 0, map[string]any{"location": "24.4798, 118.0894"}},
+		{"padded_city", "typhoon-xiamen", 0, map[string]any{"location": "  厦门  "}},
+		{"postal_code_default_country", "heatwave", 0, map[string]any{"location": "ZIP:200000"}},
+		{"extra_argument", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "units": "imperial"}},
+
+		// 无效参数
+		{"missing_location", "typhoon-xiamen", 0, map[string]any{}},
+		{"empty_location", "typhoon-xiamen", 0, map[string]any{"location": ""}},
+		{"hours_too_large", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": 13}},
+		{"hours_negative", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": -1}},
+		{"hours_not_integer", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": 2.5}},
+		{"hours_string", "typhoon-xiamen", 0, map[string]any{"location": "厦门", "hours": "6"}},
+		{"location_not_string", "typhoon-xiamen", 0, map[string]any{"location": 42}},
+		{"unknown_city", "typhoon-xiamen", 0, map[string]any{"location": "北京"}},
+		{"coordinates_out_of_scenario", "typhoon-xiamen", 0, map[string]any{"location": "39.9042,116.4074"}},
+		{"sensor_offline", "sensor-dropout", 0, map[string]any{"location": "广州"}},
+		{"unknown_city_id", "typhoon-xiamen", 0, map[string]any{"location": "id:1816670"}},
+		{"invalid_city_id", "typhoon-xiamen", 0, map[string]any{"location": "id:xiamen"}},
+		{"invalid_postal_country", "heatwave", 0, map[string]any{"location": "zip:400000,China"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t, tt.scenario)
+			h.provider.Advance(tt.offset)
+			assertGolden(t, "get_weather/"+tt.name, h.callTool("get_weather", tt.arguments))
+		})
+	}
+}
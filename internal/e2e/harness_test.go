@@ -0,0 +1,122 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/infrastructure/activity"
+	"weather-mcp-server/internal/infrastructure/mcp"
+	"weather-mcp-server/internal/infrastructure/mock"
+
+	"github.com/mark3labs/mcp-go/client"
+	mcpproto "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// update 重新生成golden文件
+var update = flag.Bool("update", false, "update golden files")
+
+// harness 进程内运行的MCP服务器和已初始化的客户端
+type harness struct {
+	t        *testing.T
+	provider *mock.Provider
+	client   *client.Client
+}
+
+// newHarness 使用模拟场景组装MCP服务器，并通过进程内传输连接客户端
+func newHarness(t *testing.T, scenario string) *harness {
+	t.Helper()
+	provider := mock.Fixture(t, scenario)
+	activityRules, err := activity.LoadRules("")
+	if err != nil {
+		t.Fatalf("Expected no error loading activity rules, got %v", err)
+	}
+	weatherService := services.NewWeatherApplicationService(provider,
+		services.WithClock(provider.Now),
+		services.WithActivityRules(activityRules),
+	)
+
+	mcpServer := server.NewMCPServer("weather-mcp-server", "1.0.0",
+		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(mcp.RequestIDMiddleware()),
+	)
+	mcpServer.AddTools(mcp.NewWeatherTools(weatherService).GetTools()...)
+
+	c, err := client.NewInProcessClient(mcpServer)
+	if err != nil {
+		t.Fatalf("Expected no error creating client, got %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Expected no error starting client, got %v", err)
+	}
+	initRequest := mcpproto.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcpproto.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcpproto.Implementation{Name: "e2e-test", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Expected no error initializing, got %v", err)
+	}
+	return &harness{t: t, provider: provider, client: c}
+}
+
+// callTool 调用工具并将结果渲染为稳定的文本，协议错误同样渲染为文本以便与golden文件比较
+func (h *harness) callTool(name string, arguments map[string]any) string {
+	h.t.Helper()
+	request := mcpproto.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+
+	result, err := h.client.CallTool(context.Background(), request)
+	if err != nil {
+		return fmt.Sprintf("error: %v\n", err)
+	}
+	return renderResult(result)
+}
+
+// renderResult 将工具结果渲染为便于阅读和比较的文本
+func renderResult(result *mcpproto.CallToolResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "isError: %v\n", result.IsError)
+	for i, content := range result.Content {
+		switch c := content.(type) {
+		case mcpproto.TextContent:
+			fmt.Fprintf(&b, "--- content[%d] text ---\n%s\n", i, strings.TrimRight(c.Text, "\n"))
+		default:
+			data, _ := json.Marshal(c)
+			fmt.Fprintf(&b, "--- content[%d] ---\n%s\n", i, data)
+		}
+	}
+	return b.String()
+}
+
+// assertGolden 比较输出与golden文件，使用 -update 时重写golden文件
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected golden file %s (run with -update to create it): %v", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("Output does not match %s (run with -update to accept):\n--- expected ---\n%s\n--- got ---\n%s", path, want, got)
+	}
+}
@@ -0,0 +1,73 @@
+package activity
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+//go:embed default_activities.json
+var defaultActivities []byte
+
+// LoadRules 加载活动规则
+// 先加载内置规则，再用 userFile（可为空）中的规则按名称覆盖或追加，
+// 团队无需修改代码即可调整阈值或新增活动。
+func LoadRules(userFile string) ([]weather.ActivityRule, error) {
+	rules, err := parseRules(defaultActivities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default activities: %w", err)
+	}
+	if userFile == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(userFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activities file: %w", err)
+	}
+	custom, err := parseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse activities file %s: %w", userFile, err)
+	}
+	return mergeRules(rules, custom), nil
+}
+
+// parseRules 解析并校验JSON格式的活动规则
+func parseRules(data []byte) ([]weather.ActivityRule, error) {
+	var rules []weather.ActivityRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate activity: %s", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return rules, nil
+}
+
+// mergeRules 按名称用 custom 覆盖 base 中的规则，新活动追加在末尾
+func mergeRules(base, custom []weather.ActivityRule) []weather.ActivityRule {
+	index := make(map[string]int, len(base))
+	for i, r := range base {
+		index[r.Name] = i
+	}
+	merged := append([]weather.ActivityRule(nil), base...)
+	for _, r := range custom {
+		if i, ok := index[r.Name]; ok {
+			merged[i] = r
+			continue
+		}
+		index[r.Name] = len(merged)
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,76 @@
+package activity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDefaultRules(t *testing.T) {
+	rules, err := LoadRules("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"running", "cycling", "hiking", "drone_flying", "laundry_drying", "car_washing", "fishing"}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d default rules, got %d", len(expected), len(rules))
+	}
+	for i, name := range expected {
+		if rules[i].Name != name {
+			t.Errorf("Expected rule %d to be %s, got %s", i, name, rules[i].Name)
+		}
+	}
+}
+
+func TestLoadRulesWithUserFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activities.json")
+	data := `[
+		{"name": "running", "label": "夜跑", "max_wind_speed": 5},
+		{"name": "kite_flying", "label": "放风筝", "max_wind_speed": 9, "max_precip_probability": 0.1}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write activities file: %v", err)
+	}
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(rules) != 8 {
+		t.Fatalf("Expected 8 rules, got %d", len(rules))
+	}
+	if rules[0].Label != "夜跑" || *rules[0].MaxWindSpeed != 5 || rules[0].MinVisibility != nil {
+		t.Errorf("Expected running to be replaced by user rule, got %+v", rules[0])
+	}
+	if rules[7].Name != "kite_flying" {
+		t.Errorf("Expected new activity appended, got %s", rules[7].Name)
+	}
+}
+
+func TestLoadRulesInvalidUserFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"malformed", `[{"name": "running",`},
+		{"invalid rule", `[{"name": "x", "min_temperature": 30, "max_temperature": 10}]`},
+		{"duplicate", `[{"name": "x"}, {"name": "x"}]`},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name+".json")
+		if err := os.WriteFile(path, []byte(test.data), 0o644); err != nil {
+			t.Fatalf("Failed to write activities file: %v", err)
+		}
+		if _, err := LoadRules(path); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+
+	if _, err := LoadRules(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("Expected error for missing file")
+	}
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/infrastructure/storage"
+)
+
+// realm WWW-Authenticate 响应头中的 realm
+const realm = "weather-mcp-server"
+
+// Client 允许访问的客户端
+type Client struct {
+	ID          string `json:"id"`
+	TokenSHA256 string `json:"token_sha256"`          // 令牌的SHA-256十六进制摘要，不保存明文
+	DailyQuota  int    `json:"daily_quota,omitempty"` // 每天（UTC）允许的工具调用次数，0表示使用默认配额
+}
+
+// tokenFile 令牌文件格式
+type tokenFile struct {
+	Clients []Client `json:"clients"`
+}
+
+// HashToken 计算令牌的SHA-256十六进制摘要
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseStaticTokens 解析 "id:token,id:token" 格式的静态令牌配置
+func ParseStaticTokens(s string) ([]Client, error) {
+	var clients []Client
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, token, ok := strings.Cut(entry, ":")
+		id, token = strings.TrimSpace(id), strings.TrimSpace(token)
+		if !ok || id == "" || token == "" {
+			return nil, fmt.Errorf("invalid token entry %q, expected id:token", entry)
+		}
+		clients = append(clients, Client{ID: id, TokenSHA256: HashToken(token)})
+	}
+	return clients, nil
+}
+
+// LoadTokenFile 加载保存令牌摘要的JSON文件
+func LoadTokenFile(path string) ([]Client, error) {
+	var f tokenFile
+	found, err := storage.ReadJSONFile(path, &f)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("token file %s does not exist", path)
+	}
+	for _, c := range f.Clients {
+		if c.ID == "" {
+			return nil, fmt.Errorf("token file %s: client id is required", path)
+		}
+		if b, err := hex.DecodeString(c.TokenSHA256); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("token file %s: client %s has an invalid token_sha256", path, c.ID)
+		}
+	}
+	return f.Clients, nil
+}
+
+// clientIDKey 上下文中已认证客户端ID的键
+type clientIDKey struct{}
+
+// WithClientID 在上下文中设置已认证的客户端ID
+func WithClientID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, id)
+}
+
+// ClientID 获取上下文中已认证的客户端ID，未认证（如 stdio 传输）时返回空字符串
+func ClientID(ctx context.Context) string {
+	id, _ := ctx.Value(clientIDKey{}).(string)
+	return id
+}
+
+// Authenticator 校验HTTP请求的 Bearer 令牌，并按客户端统计每日调用配额
+type Authenticator struct {
+	clients      []Client
+	hashes       [][]byte
+	defaultQuota int
+	audit        *slog.Logger
+	now          func() time.Time
+
+	mu    sync.Mutex
+	day   string
+	usage map[string]int
+}
+
+// Option 认证配置项
+type Option func(*Authenticator)
+
+// WithDefaultQuota 设置未单独配置配额的客户端的每日调用次数，0表示不限制
+func WithDefaultQuota(n int) Option {
+	return func(a *Authenticator) {
+		if n >= 0 {
+			a.defaultQuota = n
+		}
+	}
+}
+
+// WithAuditLogger 设置审计日志，默认使用全局日志
+func WithAuditLogger(logger *slog.Logger) Option {
+	return func(a *Authenticator) {
+		a.audit = logger
+	}
+}
+
+// WithClock 设置认证使用的时钟，主要用于测试
+func WithClock(now func() time.Time) Option {
+	return func(a *Authenticator) {
+		a.now = now
+	}
+}
+
+// New 创建认证器
+func New(clients []Client, opts ...Option) (*Authenticator, error) {
+	a := &Authenticator{now: time.Now, usage: make(map[string]int)}
+	seen := make(map[string]bool, len(clients))
+	for _, c := range clients {
+		if seen[c.ID] {
+			return nil, fmt.Errorf("duplicate client id: %s", c.ID)
+		}
+		seen[c.ID] = true
+		hash, err := hex.DecodeString(c.TokenSHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("client %s has an invalid token hash", c.ID)
+		}
+		a.clients = append(a.clients, c)
+		a.hashes = append(a.hashes, hash)
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
+}
+
+// Authenticate 校验令牌，返回对应的客户端
+// 比较令牌摘要时使用常数时间比较，并遍历所有客户端，避免通过耗时推测令牌
+func (a *Authenticator) Authenticate(token string) (Client, bool) {
+	sum := sha256.Sum256([]byte(token))
+	match := -1
+	for i, hash := range a.hashes {
+		if subtle.ConstantTimeCompare(sum[:], hash) == 1 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return Client{}, false
+	}
+	return a.clients[match], true
+}
+
+// Middleware 要求请求携带有效的 Bearer 令牌，认证通过后在请求上下文中设置客户端ID
+// 认证失败时按 RFC 6750 返回401和 WWW-Authenticate 响应头
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, realm))
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		client, ok := a.Authenticate(token)
+		if !ok {
+			a.auditLogger().WarnContext(r.Context(), "authentication failed",
+				"remote_addr", r.RemoteAddr, "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="invalid_token"`, realm))
+			http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithClientID(r.Context(), client.ID)))
+	})
+}
+
+// HTTPContextFunc 将请求上下文中的客户端ID传递给MCP请求上下文
+// 用于 server.WithHTTPContextFunc 和 server.WithSSEContextFunc
+func (a *Authenticator) HTTPContextFunc(ctx context.Context, r *http.Request) context.Context {
+	if id := ClientID(r.Context()); id != "" && ClientID(ctx) == "" {
+		return WithClientID(ctx, id)
+	}
+	return ctx
+}
+
+// bearerToken 从 Authorization 请求头中提取 Bearer 令牌
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// quota 获取客户端的每日配额，0表示不限制
+func (a *Authenticator) quota(id string) int {
+	for _, c := range a.clients {
+		if c.ID == id && c.DailyQuota > 0 {
+			return c.DailyQuota
+		}
+	}
+	return a.defaultQuota
+}
+
+// consume 消耗一次客户端当天的调用配额，配额用完时返回 false
+func (a *Authenticator) consume(id string) (used, limit int, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	day := a.now().UTC().Format("2006-01-02")
+	if day != a.day {
+		a.day = day
+		a.usage = make(map[string]int)
+	}
+	limit = a.quota(id)
+	if limit > 0 && a.usage[id] >= limit {
+		return a.usage[id], limit, false
+	}
+	a.usage[id]++
+	return a.usage[id], limit, true
+}
+
+// auditLogger 获取审计日志
+func (a *Authenticator) auditLogger() *slog.Logger {
+	if a.audit != nil {
+		return a.audit
+	}
+	return slog.Default()
+}
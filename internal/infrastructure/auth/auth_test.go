@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// syncBuffer 并发安全的日志缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newTestServer 启动带认证的 Streamable HTTP MCP 服务器，提供返回调用者身份的 whoami 工具
+func newTestServer(t *testing.T, authn *Authenticator) *httptest.Server {
+	t.Helper()
+	mcpServer := server.NewMCPServer("test", "1.0.0",
+		server.WithToolHandlerMiddleware(authn.ToolMiddleware()),
+	)
+	mcpServer.AddTool(mcp.NewTool("whoami", mcp.WithString("location")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(ClientID(ctx)), nil
+		})
+	handler := authn.Middleware(server.NewStreamableHTTPServer(mcpServer, server.WithHTTPContextFunc(authn.HTTPContextFunc)))
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// connect 使用指定令牌连接并初始化MCP会话
+func connect(t *testing.T, url, token string) (*client.Client, error) {
+	t.Helper()
+	var opts []transport.StreamableHTTPCOption
+	if token != "" {
+		opts = append(opts, transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + token}))
+	}
+	c, err := client.NewStreamableHttpClient(url, opts...)
+	if err != nil {
+		t.Fatalf("Expected client, got %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Start(ctx); err != nil {
+		return nil, err
+	}
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, request); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// callWhoami 调用 whoami 工具并返回结果
+func callWhoami(t *testing.T, c *client.Client) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "whoami"
+	request.Params.Arguments = map[string]any{"location": "北京"}
+	result, err := c.CallTool(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected tool result, got %v", err)
+	}
+	return result
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	if len(result.Content) == 0 {
+		return ""
+	}
+	text, _ := result.Content[0].(mcp.TextContent)
+	return text.Text
+}
+
+func TestMiddlewareRejectsMissingAndInvalidTokens(t *testing.T) {
+	clients, _ := ParseStaticTokens("alice:s3cret")
+	authn, err := New(clients)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ts := newTestServer(t, authn)
+
+	tests := []struct {
+		name      string
+		header    string
+		challenge string
+	}{
+		{"missing", "", `Bearer realm="weather-mcp-server"`},
+		{"wrong scheme", "Basic YWxpY2U6czNjcmV0", `Bearer realm="weather-mcp-server"`},
+		{"invalid", "Bearer wrong", `Bearer realm="weather-mcp-server", error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{}`))
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatalf("Expected response, got %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("Expected 401, got %d", resp.StatusCode)
+			}
+			if got := resp.Header.Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("Expected challenge %s, got %s", tt.challenge, got)
+			}
+		})
+	}
+
+	if _, err := connect(t, ts.URL, "wrong"); err == nil {
+		t.Error("Expected MCP initialization to fail with an invalid token")
+	}
+}
+
+func TestAuthenticatedToolCallsQuotaAndAudit(t *testing.T) {
+	clients, _ := ParseStaticTokens("alice:s3cret, bob:hunter2")
+	clients[0].DailyQuota = 2
+	var audit syncBuffer
+	authn, err := New(clients, WithAuditLogger(slog.New(slog.NewJSONHandler(&audit, nil))))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ts := newTestServer(t, authn)
+
+	alice, err := connect(t, ts.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("Expected alice to connect, got %v", err)
+	}
+	if got := resultText(callWhoami(t, alice)); got != "alice" {
+		t.Errorf("Expected tool to see client alice, got %q", got)
+	}
+	callWhoami(t, alice)
+	if result := callWhoami(t, alice); !result.IsError || !strings.Contains(resultText(result), "配额") {
+		t.Errorf("Expected quota error on third call, got %+v", result)
+	}
+
+	// bob 不受 alice 配额的影响，且未配置配额时不限制
+	bob, err := connect(t, ts.URL, "hunter2")
+	if err != nil {
+		t.Fatalf("Expected bob to connect, got %v", err)
+	}
+	if got := resultText(callWhoami(t, bob)); got != "bob" {
+		t.Errorf("Expected tool to see client bob, got %q", got)
+	}
+
+	log := audit.String()
+	if strings.Count(log, `"client":"alice"`) != 3 || !strings.Contains(log, `"status":"quota_exceeded"`) {
+		t.Errorf("Expected audit entries for all alice calls, got %s", log)
+	}
+	if !strings.Contains(log, `"tool":"whoami"`) || !strings.Contains(log, `"location":"北京"`) {
+		t.Errorf("Expected audit log to record tool and arguments, got %s", log)
+	}
+}
+
+func TestResourceAndPromptHandlersShareQuota(t *testing.T) {
+	clients, _ := ParseStaticTokens("alice:s3cret")
+	clients[0].DailyQuota = 2
+	var audit syncBuffer
+	authn, _ := New(clients, WithAuditLogger(slog.New(slog.NewJSONHandler(&audit, nil))))
+
+	calls := 0
+	readResource := authn.ResourceHandler(func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		calls++
+		return nil, nil
+	})
+	getPrompt := authn.PromptHandler(func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		calls++
+		return &mcp.GetPromptResult{}, nil
+	})
+	resourceRequest := mcp.ReadResourceRequest{}
+	resourceRequest.Params.URI = "weather://current/北京"
+	promptRequest := mcp.GetPromptRequest{}
+	promptRequest.Params.Name = "weather_report"
+
+	ctx := WithClientID(context.Background(), "alice")
+	if _, err := readResource(ctx, resourceRequest); err != nil {
+		t.Fatalf("Expected resource read to be allowed, got %v", err)
+	}
+	if _, err := getPrompt(ctx, promptRequest); err != nil {
+		t.Fatalf("Expected prompt get to be allowed, got %v", err)
+	}
+	if _, err := readResource(ctx, resourceRequest); err == nil || !strings.Contains(err.Error(), "配额") {
+		t.Errorf("Expected quota error on third call, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected rejected call not to reach the handler, got %d calls", calls)
+	}
+
+	// 未认证的请求不计配额
+	if _, err := getPrompt(context.Background(), promptRequest); err != nil {
+		t.Errorf("Expected unauthenticated prompt get to be allowed, got %v", err)
+	}
+
+	log := audit.String()
+	if !strings.Contains(log, `"resource":"weather://current/北京"`) || !strings.Contains(log, `"prompt":"weather_report"`) || !strings.Contains(log, `"status":"quota_exceeded"`) {
+		t.Errorf("Expected audit entries for resources and prompts, got %s", log)
+	}
+}
+
+func TestQuotaResetsDaily(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC)
+	clients, _ := ParseStaticTokens("alice:s3cret")
+	authn, _ := New(clients, WithDefaultQuota(1), WithClock(func() time.Time { return now }))
+
+	if _, _, ok := authn.consume("alice"); !ok {
+		t.Fatal("Expected first call to be allowed")
+	}
+	if _, _, ok := authn.consume("alice"); ok {
+		t.Error("Expected second call to exceed the quota")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, _, ok := authn.consume("alice"); !ok {
+		t.Error("Expected quota to reset on the next UTC day")
+	}
+}
+
+func TestParseStaticTokens(t *testing.T) {
+	clients, err := ParseStaticTokens(" alice:abc , bob:def ")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(clients) != 2 || clients[1].ID != "bob" || clients[1].TokenSHA256 != HashToken("def") {
+		t.Errorf("Unexpected clients: %+v", clients)
+	}
+	for _, invalid := range []string{"alice", "alice:", ":abc"} {
+		if _, err := ParseStaticTokens(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestLoadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "tokens.json")
+	os.WriteFile(valid, []byte(`{"clients":[{"id":"ci","token_sha256":"`+HashToken("t0ken")+`","daily_quota":10}]}`), 0o600)
+
+	clients, err := LoadTokenFile(valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	authn, err := New(clients)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if c, ok := authn.Authenticate("t0ken"); !ok || c.ID != "ci" || c.DailyQuota != 10 {
+		t.Errorf("Expected token to authenticate client ci, got %+v (%v)", c, ok)
+	}
+	if _, ok := authn.Authenticate("other"); ok {
+		t.Error("Expected unknown token to be rejected")
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"clients":[{"id":"ci","token_sha256":"plain-text"}]}`), 0o600)
+	if _, err := LoadTokenFile(invalid); err == nil {
+		t.Error("Expected error for non-hashed token")
+	}
+	if _, err := LoadTokenFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected error for missing token file")
+	}
+}
+
+func TestNewRejectsDuplicateClients(t *testing.T) {
+	clients, _ := ParseStaticTokens("alice:a,alice:b")
+	if _, err := New(clients); err == nil {
+		t.Error("Expected error for duplicate client id")
+	}
+}
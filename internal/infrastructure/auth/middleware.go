@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolMiddleware 检查已认证客户端的每日调用配额，并记录谁调用了什么工具
+// 未认证的请求（如 stdio 传输）直接放行
+func (a *Authenticator) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id := ClientID(ctx)
+			if id == "" {
+				return next(ctx, request)
+			}
+			c, err := a.begin(ctx, id, "tool call", "tool", request.Params.Name, "arguments", request.Params.Arguments)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result, err := next(ctx, request)
+			c.finish(ctx, err != nil || (result != nil && result.IsError))
+			return result, err
+		}
+	}
+}
+
+// ResourceHandler 为资源读取应用与工具调用相同的配额和审计
+// 资源和提示词同样会访问上游数据源，mcp-go 没有对应的中间件选项，需在注册时包装处理函数
+func (a *Authenticator) ResourceHandler(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		id := ClientID(ctx)
+		if id == "" {
+			return next(ctx, request)
+		}
+		c, err := a.begin(ctx, id, "resource read", "resource", request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := next(ctx, request)
+		c.finish(ctx, err != nil)
+		return contents, err
+	}
+}
+
+// ResourceTemplateHandler 为资源模板读取应用配额和审计
+func (a *Authenticator) ResourceTemplateHandler(next server.ResourceTemplateHandlerFunc) server.ResourceTemplateHandlerFunc {
+	return server.ResourceTemplateHandlerFunc(a.ResourceHandler(server.ResourceHandlerFunc(next)))
+}
+
+// PromptHandler 为提示词获取应用配额和审计
+func (a *Authenticator) PromptHandler(next server.PromptHandlerFunc) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		id := ClientID(ctx)
+		if id == "" {
+			return next(ctx, request)
+		}
+		c, err := a.begin(ctx, id, "prompt get", "prompt", request.Params.Name, "arguments", request.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		result, err := next(ctx, request)
+		c.finish(ctx, err != nil)
+		return result, err
+	}
+}
+
+// call 一次已通过配额检查的调用
+type call struct {
+	logger *slog.Logger
+	action string
+	start  time.Time
+	used   int
+	limit  int
+}
+
+// begin 扣减客户端配额并准备审计日志，超出配额时记录拒绝并返回错误
+func (a *Authenticator) begin(ctx context.Context, id, action string, attrs ...any) (*call, error) {
+	logger := a.auditLogger().With(append([]any{"client", id}, attrs...)...)
+	used, limit, ok := a.consume(id)
+	if !ok {
+		logger.WarnContext(ctx, action+" rejected", "status", "quota_exceeded", "quota", limit)
+		return nil, fmt.Errorf("❌ 客户端 %s 今天的调用配额（%d 次）已用完，将在UTC零点重置", id, limit)
+	}
+	return &call{logger: logger, action: action, start: time.Now(), used: used, limit: limit}, nil
+}
+
+// finish 记录调用结果
+func (c *call) finish(ctx context.Context, failed bool) {
+	status := "ok"
+	if failed {
+		status = "error"
+	}
+	c.logger.InfoContext(ctx, c.action, "status", status, "duration", time.Since(c.start), "quota_used", c.used, "quota", c.limit)
+}
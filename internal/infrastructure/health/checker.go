@@ -0,0 +1,297 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// 服务状态
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"    // 可用，但最近上游错误较多
+	StatusUnavailable = "unavailable" // 数据源不可达或API密钥无效
+)
+
+const (
+	// DefaultProbeTTL 探测结果的默认缓存时间，避免频繁探测消耗调用配额
+	DefaultProbeTTL = time.Minute
+	// degradedErrorRate 判定为降级的上游错误率
+	degradedErrorRate = 0.5
+	// degradedMinRequests 判定降级所需的最少请求数，避免样本过少误判
+	degradedMinRequests = 4
+	// maxProbeScopes 最多缓存探测结果的范围数，与租户客户端的上限（weather.DefaultMaxTenants）一致
+	maxProbeScopes = 100
+)
+
+// Report 服务状态报告
+type Report struct {
+	Status        string                 `json:"status"`
+	Version       string                 `json:"version"`
+	Uptime        string                 `json:"uptime"`
+	StartedAt     time.Time              `json:"started_at"`
+	Provider      *weather.ProviderProbe `json:"provider,omitempty"`
+	ToolsInFlight int                    `json:"tools_in_flight"`
+	Upstream      UpstreamStats          `json:"upstream"`
+	Config        map[string]string      `json:"config"`
+	Problems      []string               `json:"problems,omitempty"`
+}
+
+// Ready 服务是否可以处理请求
+func (r Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+// Checker 汇总数据源探测、请求统计和配置信息，生成服务状态报告
+type Checker struct {
+	prober   weather.ProviderProber
+	monitor  *Monitor
+	version  string
+	config   map[string]string
+	probeTTL time.Duration
+	now      func() time.Time
+	started  time.Time
+
+	scope func(context.Context) string
+
+	mu     sync.Mutex
+	probes map[string]*probeEntry
+}
+
+// probeEntry 单个范围的探测，done 关闭后 result 可用
+type probeEntry struct {
+	startedAt time.Time
+	result    *weather.ProviderProbe
+	done      chan struct{}
+}
+
+// CheckerOption 状态检查配置项
+type CheckerOption func(*Checker)
+
+// WithVersion 设置报告中的服务版本
+func WithVersion(version string) CheckerOption {
+	return func(c *Checker) {
+		c.version = version
+	}
+}
+
+// WithConfigSummary 设置报告中的配置摘要，调用方负责对密钥脱敏（见 MaskSecret）
+func WithConfigSummary(config map[string]string) CheckerOption {
+	return func(c *Checker) {
+		c.config = config
+	}
+}
+
+// WithProbeTTL 设置探测结果的缓存时间
+func WithProbeTTL(ttl time.Duration) CheckerOption {
+	return func(c *Checker) {
+		if ttl > 0 {
+			c.probeTTL = ttl
+		}
+	}
+}
+
+// WithProbeScope 按请求范围（如租户）分别缓存探测结果
+// 不同租户使用不同的上游密钥时，探测结果不能共享
+func WithProbeScope(scope func(context.Context) string) CheckerOption {
+	return func(c *Checker) {
+		c.scope = scope
+	}
+}
+
+// WithCheckerClock 设置状态检查使用的时钟，主要用于测试
+func WithCheckerClock(now func() time.Time) CheckerOption {
+	return func(c *Checker) {
+		c.now = now
+	}
+}
+
+// NewChecker 创建状态检查
+// prober 为空时不探测数据源，monitor 为空时不统计请求
+func NewChecker(prober weather.ProviderProber, monitor *Monitor, opts ...CheckerOption) *Checker {
+	c := &Checker{
+		prober:   prober,
+		monitor:  monitor,
+		probeTTL: DefaultProbeTTL,
+		now:      time.Now,
+		probes:   make(map[string]*probeEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.started = c.now()
+	return c
+}
+
+// probe 获取数据源探测结果，缓存未过期时直接返回
+// 探测期间不持有锁，同一范围的并发请求等待同一次探测的结果，不同范围的探测互不阻塞
+func (c *Checker) probe(ctx context.Context) *weather.ProviderProbe {
+	if c.prober == nil {
+		return nil
+	}
+	scope := ""
+	if c.scope != nil {
+		scope = c.scope(ctx)
+	}
+
+	c.mu.Lock()
+	e, ok := c.probes[scope]
+	if ok {
+		select {
+		case <-e.done:
+			if c.now().Sub(e.result.CheckedAt) < c.probeTTL {
+				c.mu.Unlock()
+				return e.result
+			}
+			ok = false
+		default:
+		}
+	}
+	if !ok {
+		e = &probeEntry{startedAt: c.now(), done: make(chan struct{})}
+		c.probes[scope] = e
+		c.evictLocked()
+		c.mu.Unlock()
+
+		// 探测结果由等待的请求共享，不随发起探测的请求取消
+		p := c.prober.Probe(context.WithoutCancel(ctx))
+		p.CheckedAt = c.now()
+		e.result = &p
+		close(e.done)
+		return e.result
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-e.done:
+		return e.result
+	case <-ctx.Done():
+		return &weather.ProviderProbe{Error: ctx.Err().Error(), CheckedAt: c.now()}
+	}
+}
+
+// evictLocked 超出上限时淘汰最早开始的探测，调用方需持有锁
+func (c *Checker) evictLocked() {
+	for len(c.probes) > maxProbeScopes {
+		var oldest string
+		for scope, e := range c.probes {
+			if oldest == "" || e.startedAt.Before(c.probes[oldest].startedAt) {
+				oldest = scope
+			}
+		}
+		delete(c.probes, oldest)
+	}
+}
+
+// Status 生成服务状态报告
+func (c *Checker) Status(ctx context.Context) Report {
+	now := c.now()
+	r := Report{
+		Status:    StatusOK,
+		Version:   c.version,
+		Uptime:    now.Sub(c.started).Truncate(time.Second).String(),
+		StartedAt: c.started,
+		Provider:  c.probe(ctx),
+		Config:    c.config,
+	}
+	if c.monitor != nil {
+		r.ToolsInFlight = c.monitor.ToolsInFlight()
+		r.Upstream = c.monitor.Upstream()
+	}
+
+	if p := r.Provider; p != nil {
+		switch {
+		case !p.Reachable:
+			r.Status = StatusUnavailable
+			r.Problems = append(r.Problems, fmt.Sprintf("无法连接到 %s: %s，请检查网络、代理或防火墙设置", p.Provider, p.Error))
+		case !p.KeyValid:
+			r.Status = StatusUnavailable
+			r.Problems = append(r.Problems, fmt.Sprintf("%s 拒绝了API密钥（HTTP %d），请检查 OPENWEATHER_API_KEY（或请求头 X-OpenWeather-API-Key 中自带的密钥）是否正确；新申请的密钥可能需要数小时才能生效", p.Provider, p.StatusCode))
+		case p.StatusCode == http.StatusTooManyRequests:
+			r.Status = StatusDegraded
+			r.Problems = append(r.Problems, fmt.Sprintf("%s 返回限流（HTTP 429），当前套餐的调用配额可能已用完", p.Provider))
+		case p.Error != "":
+			r.Status = StatusDegraded
+			r.Problems = append(r.Problems, fmt.Sprintf("%s 探测异常: %s", p.Provider, p.Error))
+		}
+	}
+	if u := r.Upstream; u.Requests >= degradedMinRequests && u.ErrorRate >= degradedErrorRate {
+		if r.Status == StatusOK {
+			r.Status = StatusDegraded
+		}
+		r.Problems = append(r.Problems, fmt.Sprintf("最近 %d 次上游请求中有 %d 次失败，最近的错误: %s", u.Requests, u.Errors, u.LastError))
+	}
+	return r
+}
+
+// HealthzHandler 存活检查，进程能响应即返回200
+func (c *Checker) HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status":  StatusOK,
+			"version": c.version,
+			"uptime":  c.now().Sub(c.started).Truncate(time.Second).String(),
+		})
+	})
+}
+
+// ReadyzHandler 就绪检查，返回完整的状态报告；数据源不可达或密钥无效时返回503
+func (c *Checker) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Status(r.Context())
+		code := http.StatusOK
+		if !report.Ready() {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+// ReadinessHandler 仅返回就绪状态的就绪检查，供无需认证的端点使用
+// 完整报告包含配置摘要、上游统计和错误信息，不应对未认证的调用方公开；
+// 探测结果按 probeTTL 缓存，频繁调用也不会增加上游请求
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Status(r.Context())
+		code := http.StatusOK
+		if !report.Ready() {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, map[string]string{"status": report.Status})
+	})
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// MaskSecret 对密钥脱敏，仅保留末4位用于核对是否配置了预期的密钥
+func MaskSecret(secret string) string {
+	switch {
+	case secret == "":
+		return "(unset)"
+	case len(secret) <= 8:
+		return "****"
+	default:
+		return "****" + secret[len(secret)-4:]
+	}
+}
+
+// ConfigKeys 获取配置摘要中的配置项名称（按字母排序）
+func (r Report) ConfigKeys() []string {
+	keys := make([]string, 0, len(r.Config))
+	for k := range r.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,289 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/roundtrip"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// stubProber 返回固定探测结果并统计调用次数的测试探测器
+type stubProber struct {
+	probe weather.ProviderProbe
+	calls int
+}
+
+func (p *stubProber) Probe(ctx context.Context) weather.ProviderProbe {
+	p.calls++
+	return p.probe
+}
+
+// statusTransport 按顺序返回指定状态码的测试传输层，状态码为0时返回网络错误
+func statusTransport(codes ...int) http.RoundTripper {
+	i := 0
+	return roundtrip.Func(func(req *http.Request) (*http.Response, error) {
+		code := codes[i%len(codes)]
+		i++
+		if code == 0 {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: http.NoBody}, nil
+	})
+}
+
+func TestMonitorUpstreamErrorRate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMonitor(WithErrorWindow(time.Minute), WithMonitorClock(func() time.Time { return now }))
+	transport := m.InstrumentTransport(statusTransport(200, 404, 401, 0))
+
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/weather", nil)
+		transport.RoundTrip(req)
+	}
+	stats := m.Upstream()
+	if stats.Requests != 4 || stats.Errors != 2 || stats.ErrorRate != 0.5 {
+		t.Errorf("Expected 2 errors in 4 requests, got %+v", stats)
+	}
+	if !strings.Contains(stats.LastError, "connection refused") {
+		t.Errorf("Expected last error to be recorded, got %q", stats.LastError)
+	}
+	if stats.InFlight != 0 {
+		t.Errorf("Expected no in-flight requests, got %d", stats.InFlight)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if stats := m.Upstream(); stats.Requests != 0 || stats.LastError != "" {
+		t.Errorf("Expected outcomes outside the window to be dropped, got %+v", stats)
+	}
+}
+
+func TestMonitorToolsInFlight(t *testing.T) {
+	m := NewMonitor()
+	var during int
+	handler := m.ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		during = m.ToolsInFlight()
+		return nil, nil
+	})
+	handler(context.Background(), mcp.CallToolRequest{})
+	if during != 1 || m.ToolsInFlight() != 0 {
+		t.Errorf("Expected 1 tool in flight during the call and 0 after, got %d and %d", during, m.ToolsInFlight())
+	}
+}
+
+func TestCheckerStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		probe    weather.ProviderProbe
+		codes    []int
+		expected string
+		problem  string
+	}{
+		{"healthy", weather.ProviderProbe{Reachable: true, KeyValid: true, StatusCode: 200}, nil, StatusOK, ""},
+		{"invalid key", weather.ProviderProbe{Reachable: true, StatusCode: 401}, nil, StatusUnavailable, "OPENWEATHER_API_KEY"},
+		{"unreachable", weather.ProviderProbe{Error: "dial tcp: timeout"}, nil, StatusUnavailable, "无法连接"},
+		{"rate limited", weather.ProviderProbe{Reachable: true, KeyValid: true, StatusCode: 429, Error: "rate limited"}, nil, StatusDegraded, "429"},
+		{"upstream errors", weather.ProviderProbe{Reachable: true, KeyValid: true, StatusCode: 200}, []int{500, 500, 500, 200}, StatusDegraded, "3 次失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitor()
+			if tt.codes != nil {
+				transport := m.InstrumentTransport(statusTransport(tt.codes...))
+				for range tt.codes {
+					transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://example.com/weather", nil))
+				}
+			}
+			checker := NewChecker(&stubProber{probe: tt.probe}, m)
+			report := checker.Status(context.Background())
+			if report.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s (%v)", tt.expected, report.Status, report.Problems)
+			}
+			if tt.problem != "" && !strings.Contains(strings.Join(report.Problems, "\n"), tt.problem) {
+				t.Errorf("Expected problem mentioning %q, got %v", tt.problem, report.Problems)
+			}
+			if tt.problem == "" && len(report.Problems) != 0 {
+				t.Errorf("Expected no problems, got %v", report.Problems)
+			}
+		})
+	}
+}
+
+func TestCheckerCachesProbe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	prober := &stubProber{probe: weather.ProviderProbe{Reachable: true, KeyValid: true}}
+	checker := NewChecker(prober, nil, WithProbeTTL(time.Minute), WithCheckerClock(func() time.Time { return now }))
+
+	checker.Status(context.Background())
+	now = now.Add(30 * time.Second)
+	report := checker.Status(context.Background())
+	if prober.calls != 1 {
+		t.Errorf("Expected cached probe within TTL, got %d probes", prober.calls)
+	}
+	if report.Uptime != "30s" {
+		t.Errorf("Expected uptime 30s, got %s", report.Uptime)
+	}
+
+	now = now.Add(time.Minute)
+	checker.Status(context.Background())
+	if prober.calls != 2 {
+		t.Errorf("Expected probe after TTL, got %d probes", prober.calls)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	prober := &stubProber{probe: weather.ProviderProbe{Reachable: true, StatusCode: 401}}
+	checker := NewChecker(prober, NewMonitor(),
+		WithVersion("1.0.0"),
+		WithConfigSummary(map[string]string{"OPENWEATHER_API_KEY": MaskSecret("abcdef0123456789")}),
+	)
+
+	rec := httptest.NewRecorder()
+	checker.HealthzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected healthz 200, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	checker.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readyz 503 with invalid key, got %d", rec.Code)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON report, got %v", err)
+	}
+	if report.Version != "1.0.0" || report.Config["OPENWEATHER_API_KEY"] != "****6789" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	rec = httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness 503 with invalid key, got %d", rec.Code)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"status":"unavailable"}` {
+		t.Errorf("Expected status only, got %s", body)
+	}
+	if prober.calls != 1 {
+		t.Errorf("Expected cached probe to be reused, got %d probes", prober.calls)
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "(unset)"},
+		{"short", "****"},
+		{"abcdef0123456789", "****6789"},
+	}
+	for _, tt := range tests {
+		if got := MaskSecret(tt.input); got != tt.expected {
+			t.Errorf("MaskSecret(%q): expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestCheckerProbeScope(t *testing.T) {
+	type scopeKey struct{}
+	prober := &stubProber{probe: weather.ProviderProbe{Reachable: true, KeyValid: true}}
+	checker := NewChecker(prober, nil, WithProbeScope(func(ctx context.Context) string {
+		scope, _ := ctx.Value(scopeKey{}).(string)
+		return scope
+	}))
+
+	checker.Status(context.Background())
+	checker.Status(context.WithValue(context.Background(), scopeKey{}, "tenant-a"))
+	checker.Status(context.WithValue(context.Background(), scopeKey{}, "tenant-a"))
+	if prober.calls != 2 {
+		t.Errorf("Expected one probe per scope, got %d", prober.calls)
+	}
+}
+
+// blockingProber 按范围探测的测试探测器，"slow" 范围的探测阻塞到 release 关闭
+type blockingProber struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	release chan struct{}
+}
+
+func (p *blockingProber) Probe(ctx context.Context) weather.ProviderProbe {
+	scope, _ := ctx.Value(scopeKey{}).(string)
+	p.mu.Lock()
+	p.calls[scope]++
+	p.mu.Unlock()
+	if scope == "slow" {
+		<-p.release
+	}
+	return weather.ProviderProbe{Reachable: true, KeyValid: true}
+}
+
+// scopeKey 测试用的探测范围上下文键
+type scopeKey struct{}
+
+func TestCheckerProbesDoNotBlockEachOther(t *testing.T) {
+	prober := &blockingProber{calls: make(map[string]int), release: make(chan struct{})}
+	checker := NewChecker(prober, nil, WithProbeScope(func(ctx context.Context) string {
+		scope, _ := ctx.Value(scopeKey{}).(string)
+		return scope
+	}))
+	slow := context.WithValue(context.Background(), scopeKey{}, "slow")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Status(slow)
+		}()
+	}
+
+	// 慢探测进行中，其他范围的探测不受影响
+	done := make(chan struct{})
+	go func() {
+		checker.Status(context.WithValue(context.Background(), scopeKey{}, "fast"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected probe of another scope not to wait for the slow probe")
+	}
+
+	close(prober.release)
+	wg.Wait()
+	if prober.calls["slow"] != 1 {
+		t.Errorf("Expected concurrent requests to share one probe, got %d probes", prober.calls["slow"])
+	}
+}
+
+func TestCheckerBoundsProbeScopes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	checker := NewChecker(&blockingProber{calls: make(map[string]int)}, nil,
+		WithCheckerClock(func() time.Time { now = now.Add(time.Millisecond); return now }),
+		WithProbeScope(func(ctx context.Context) string {
+			scope, _ := ctx.Value(scopeKey{}).(string)
+			return scope
+		}))
+	for i := 0; i < maxProbeScopes+50; i++ {
+		checker.Status(context.WithValue(context.Background(), scopeKey{}, fmt.Sprintf("tenant-%d", i)))
+	}
+	if len(checker.probes) != maxProbeScopes {
+		t.Errorf("Expected %d cached probe scopes, got %d", maxProbeScopes, len(checker.probes))
+	}
+	if _, ok := checker.probes["tenant-0"]; ok {
+		t.Error("Expected the oldest probe scope to be evicted")
+	}
+}
@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/infrastructure/logging"
+	"weather-mcp-server/internal/infrastructure/roundtrip"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultErrorWindow 统计上游错误率的默认时间窗口
+const DefaultErrorWindow = 5 * time.Minute
+
+// UpstreamStats 上游请求统计
+type UpstreamStats struct {
+	InFlight  int     `json:"in_flight"`
+	Requests  int     `json:"recent_requests"` // 时间窗口内完成的请求数
+	Errors    int     `json:"recent_errors"`   // 时间窗口内的网络错误和5xx/401/429响应数
+	ErrorRate float64 `json:"error_rate"`
+	LastError string  `json:"last_error,omitempty"`
+}
+
+// outcome 一次上游请求的结果
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// Monitor 统计进行中的工具调用和上游请求，以及最近的上游错误率
+type Monitor struct {
+	mu               sync.Mutex
+	window           time.Duration
+	now              func() time.Time
+	toolsInFlight    int
+	upstreamInFlight int
+	outcomes         []outcome
+	lastError        string
+}
+
+// MonitorOption 监控配置项
+type MonitorOption func(*Monitor)
+
+// WithErrorWindow 设置统计上游错误率的时间窗口
+func WithErrorWindow(window time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		if window > 0 {
+			m.window = window
+		}
+	}
+}
+
+// WithMonitorClock 设置监控使用的时钟，主要用于测试
+func WithMonitorClock(now func() time.Time) MonitorOption {
+	return func(m *Monitor) {
+		m.now = now
+	}
+}
+
+// NewMonitor 创建请求监控
+func NewMonitor(opts ...MonitorOption) *Monitor {
+	m := &Monitor{window: DefaultErrorWindow, now: time.Now}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// ToolMiddleware 统计进行中的工具调用数
+func (m *Monitor) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			m.mu.Lock()
+			m.toolsInFlight++
+			m.mu.Unlock()
+			defer func() {
+				m.mu.Lock()
+				m.toolsInFlight--
+				m.mu.Unlock()
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// InstrumentTransport 包装上游HTTP传输层，统计进行中的请求数和错误率
+// 网络错误、5xx、401 和 429 响应计为错误，404 等业务性错误不计入
+func (m *Monitor) InstrumentTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundtrip.Func(func(req *http.Request) (*http.Response, error) {
+		m.mu.Lock()
+		m.upstreamInFlight++
+		m.mu.Unlock()
+
+		resp, err := next.RoundTrip(req)
+
+		failure := ""
+		switch {
+		case err != nil:
+			failure = "request failed: " + logging.RedactString(err.Error())
+		case resp.StatusCode >= http.StatusInternalServerError,
+			resp.StatusCode == http.StatusUnauthorized,
+			resp.StatusCode == http.StatusTooManyRequests:
+			failure = "status: " + resp.Status
+		}
+		m.record(failure)
+		return resp, err
+	})
+}
+
+// record 记录一次上游请求结果，failure 为空表示成功
+func (m *Monitor) record(failure string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upstreamInFlight--
+	m.outcomes = append(m.outcomes, outcome{at: m.now(), failed: failure != ""})
+	if failure != "" {
+		m.lastError = failure
+	}
+	m.pruneLocked()
+}
+
+// pruneLocked 移除时间窗口外的请求结果
+func (m *Monitor) pruneLocked() {
+	cutoff := m.now().Add(-m.window)
+	i := 0
+	for i < len(m.outcomes) && m.outcomes[i].at.Before(cutoff) {
+		i++
+	}
+	m.outcomes = m.outcomes[i:]
+}
+
+// ToolsInFlight 获取进行中的工具调用数
+func (m *Monitor) ToolsInFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.toolsInFlight
+}
+
+// Upstream 获取上游请求统计
+func (m *Monitor) Upstream() UpstreamStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	stats := UpstreamStats{InFlight: m.upstreamInFlight, Requests: len(m.outcomes)}
+	for _, o := range m.outcomes {
+		if o.failed {
+			stats.Errors++
+		}
+	}
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+	}
+	if stats.Errors > 0 {
+		stats.LastError = m.lastError
+	}
+	return stats
+}
@@ -0,0 +1,209 @@
+// Package httpfixture 提供录制和回放上游HTTP响应的传输层
+// 录制模式下将真实请求的响应保存到夹具文件，回放模式下从夹具文件返回响应而不访问网络，
+// 使依赖上游API的测试在CI中离线、确定地运行。夹具中的API密钥等敏感查询参数在保存前已脱敏。
+package httpfixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"weather-mcp-server/internal/infrastructure/logging"
+)
+
+// 夹具的使用模式
+const (
+	// ModeReplay 从夹具文件回放响应，不访问网络
+	ModeReplay = "replay"
+	// ModeRecord 访问真实上游并将响应录制到夹具文件
+	ModeRecord = "record"
+)
+
+// Request 录制的请求，URL中的敏感参数已脱敏
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response 录制的响应
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// Interaction 一次请求及其响应
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette 夹具文件的内容
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Transport 录制或回放HTTP响应的传输层，实现 http.RoundTripper
+type Transport struct {
+	path string
+	mode string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Option 传输层配置项
+type Option func(*Transport)
+
+// WithMode 设置使用模式，默认为回放
+func WithMode(mode string) Option {
+	return func(t *Transport) {
+		t.mode = mode
+	}
+}
+
+// WithNext 设置录制模式下实际发送请求的传输层，默认为 http.DefaultTransport
+func WithNext(next http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.next = next
+	}
+}
+
+// New 创建夹具传输层
+// 回放模式下立即加载夹具文件；录制模式下在 Save 时写入夹具文件
+func New(path string, opts ...Option) (*Transport, error) {
+	t := &Transport{
+		path: path,
+		mode: ModeReplay,
+		next: http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	switch t.mode {
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		if err := json.Unmarshal(data, &t.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		t.used = make([]bool, len(t.cassette.Interactions))
+	case ModeRecord:
+	default:
+		return nil, fmt.Errorf("unsupported fixture mode: %s (supported: %s, %s)", t.mode, ModeReplay, ModeRecord)
+	}
+	return t, nil
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := Request{Method: req.Method, URL: logging.RedactURL(req.URL)}
+	if t.mode == ModeRecord {
+		return t.record(req, recorded)
+	}
+	return t.replay(req, recorded)
+}
+
+// replay 按顺序返回第一个尚未使用且请求相同的录制响应
+func (t *Transport) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || interaction.Request != recorded {
+			continue
+		}
+		t.used[i] = true
+		return newResponse(req, interaction.Response), nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s in %s", recorded.Method, recorded.URL, t.path)
+}
+
+// record 发送真实请求并录制响应
+func (t *Transport) record(req *http.Request, recorded Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// 错误响应体中可能回显请求参数，一并脱敏
+	response := Response{
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        logging.RedactString(string(body)),
+	}
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	t.used = append(t.used, true)
+	t.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// Save 录制模式下将录制的请求写入夹具文件，回放模式下不做任何操作
+func (t *Transport) Save() error {
+	if t.mode != ModeRecord {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(t.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// Unused 获取回放模式下未被请求的录制请求，用于检查测试是否与夹具一致
+func (t *Transport) Unused() []Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var unused []Request
+	for i, interaction := range t.cassette.Interactions {
+		if !t.used[i] {
+			unused = append(unused, interaction.Request)
+		}
+	}
+	return unused
+}
+
+// newResponse 根据录制的响应构造HTTP响应
+func newResponse(req *http.Request, r Response) *http.Response {
+	header := make(http.Header)
+	if r.ContentType != "" {
+		header.Set("Content-Type", r.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,123 @@
+package httpfixture
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, transport http.RoundTripper, url string) (int, string, error) {
+	t.Helper()
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected no error reading body, got %v", err)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "Nowhere" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"cod":"404","message":"city not found, appid=secret-key"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"` + r.URL.Query().Get("q") + `"}`))
+	}))
+	defer upstream.Close()
+	path := filepath.Join(t.TempDir(), "fixtures", "cities.json")
+
+	recorder, err := New(path, WithMode(ModeRecord))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, city := range []string{"Paris", "Nowhere", "Paris"} {
+		if _, _, err := get(t, recorder, upstream.URL+"/weather?appid=secret-key&q="+city); err != nil {
+			t.Fatalf("Expected no error recording %s, got %v", city, err)
+		}
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Expected no error saving fixture, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected fixture file, got %v", err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Errorf("Expected API key to be scrubbed from fixture, got %s", data)
+	}
+
+	upstream.Close()
+	replayer, err := New(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tests := []struct {
+		city   string
+		status int
+		body   string
+	}{
+		{"Paris", http.StatusOK, `{"name":"Paris"}`},
+		{"Nowhere", http.StatusNotFound, `{"cod":"404","message":"city not found, appid=REDACTED"}`},
+		{"Paris", http.StatusOK, `{"name":"Paris"}`},
+	}
+	for _, tt := range tests {
+		// 回放时使用不同的密钥，脱敏后仍能匹配
+		status, body, err := get(t, replayer, upstream.URL+"/weather?appid=other-key&q="+tt.city)
+		if err != nil {
+			t.Fatalf("Expected no error replaying %s, got %v", tt.city, err)
+		}
+		if status != tt.status || body != tt.body {
+			t.Errorf("Expected %d %s, got %d %s", tt.status, tt.body, status, body)
+		}
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("Expected all interactions to be used, got %v", unused)
+	}
+}
+
+func TestReplayUnmatchedRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	fixture := `{"interactions":[{"request":{"method":"GET","url":"https://example.com/weather?q=Paris"},"response":{"status":200,"body":"{}"}}]}`
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := New(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := get(t, replayer, "https://example.com/weather?q=London"); err == nil {
+		t.Error("Expected error for unrecorded request")
+	}
+	if unused := replayer.Unused(); len(unused) != 1 {
+		t.Errorf("Expected 1 unused interaction, got %d", len(unused))
+	}
+	if _, _, err := get(t, replayer, "https://example.com/weather?q=Paris"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, _, err := get(t, replayer, "https://example.com/weather?q=Paris"); err == nil {
+		t.Error("Expected error when the recorded interaction was already used")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing fixture")
+	}
+	if _, err := New("fixture.json", WithMode("live")); err == nil {
+		t.Error("Expected error for unsupported mode")
+	}
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// contextHandler 从上下文中提取请求关联ID并添加到每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler 包装日志处理器，为携带关联ID的上下文日志添加 request_id 字段
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+// Handle 实现 slog.Handler
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDAttr, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs 实现 slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 实现 slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// fanoutHandler 将日志分发给多个处理器
+type fanoutHandler []slog.Handler
+
+// Fanout 创建将每条日志分发给所有处理器的处理器
+func Fanout(handlers ...slog.Handler) slog.Handler {
+	return fanoutHandler(handlers)
+}
+
+// Enabled 任一处理器启用该级别时返回 true
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle 将日志交给启用该级别的处理器，返回所有错误
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs 实现 slog.Handler
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// WithGroup 实现 slog.Handler
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
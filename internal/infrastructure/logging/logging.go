@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config 日志配置
+type Config struct {
+	Level  string // debug、info、warn、error，默认 info
+	Format string // json 或 text，默认 json
+	File   string // 日志文件路径，为空时写入标准错误
+}
+
+// New 根据配置创建日志处理器
+// 日志只会写入标准错误或文件，不会写入标准输出，避免破坏 stdio 传输的协议数据。
+// 返回的 io.Closer 用于关闭日志文件，写入标准错误时为空操作。
+func New(cfg Config) (slog.Handler, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var w io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closer = f, f
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("unsupported log format: %s (supported: json, text)", cfg.Format)
+	}
+	return NewContextHandler(handler), closer, nil
+}
+
+// ParseLevel 解析日志级别名称
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unsupported log level: %s (supported: debug, info, warn, error)", s)
+	}
+}
+
+// nopCloser 空操作的 io.Closer
+type nopCloser struct{}
+
+// Close 实现 io.Closer
+func (nopCloser) Close() error { return nil }
+
+// requestIDKey 上下文中关联ID的键
+type requestIDKey struct{}
+
+// RequestIDAttr 日志中关联ID的字段名
+const RequestIDAttr = "request_id"
+
+// NewRequestID 生成随机的请求关联ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID 在上下文中设置请求关联ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 获取上下文中的请求关联ID，不存在时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+		wantErr  bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"WARN", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		level, err := ParseLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q): expected error %v, got %v", tt.input, tt.wantErr, err)
+			continue
+		}
+		if !tt.wantErr && level != tt.expected {
+			t.Errorf("ParseLevel(%q): expected %s, got %s", tt.input, tt.expected, level)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	u, _ := url.Parse("https://api.openweathermap.org/data/2.5/weather?q=Beijing&appid=secret")
+	got := RedactURL(u)
+	if strings.Contains(got, "secret") || !strings.Contains(got, "appid=REDACTED") || !strings.Contains(got, "q=Beijing") {
+		t.Errorf("Expected appid to be redacted, got %s", got)
+	}
+	if u.Query().Get("appid") != "secret" {
+		t.Error("Expected original URL to be unchanged")
+	}
+
+	plain, _ := url.Parse("https://example.com/a?q=1")
+	if got := RedactURL(plain); got != "https://example.com/a?q=1" {
+		t.Errorf("Expected URL without secrets unchanged, got %s", got)
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	input := `Get "https://x/weather?appid=abc123&q=Paris": timeout; token=xyz`
+	expected := `Get "https://x/weather?appid=REDACTED&q=Paris": timeout; token=REDACTED`
+	if got := RedactString(input); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestNewWritesRedactedJSONWithRequestID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	handler, closer, err := New(Config{Level: "debug", File: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "abc")
+	logger := slog.New(handler)
+	logger.DebugContext(ctx, "upstream request", "url", "https://x/weather?appid=secret")
+	logger.WarnContext(ctx, "upstream failed", "error", errors.New("GET https://x?apikey=secret failed"))
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Expected no error closing log file, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected log file, got %v", err)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Errorf("Expected secrets to be redacted, got %s", data)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d", len(lines))
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(lines[0], &entry); err != nil {
+		t.Fatalf("Expected JSON log line, got %v", err)
+	}
+	if entry[RequestIDAttr] != "abc" || entry["msg"] != "upstream request" {
+		t.Errorf("Unexpected log entry: %v", entry)
+	}
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+	if _, _, err := New(Config{Format: "xml"}); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+	if _, _, err := New(Config{Level: "loud"}); err == nil {
+		t.Error("Expected error for unsupported level")
+	}
+}
+
+func TestFanoutHonoursHandlerLevels(t *testing.T) {
+	var debugBuf, warnBuf bytes.Buffer
+	logger := slog.New(Fanout(
+		slog.NewTextHandler(&debugBuf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewTextHandler(&warnBuf, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)).With("component", "test")
+
+	logger.Info("hello")
+	logger.Warn("careful")
+
+	if !strings.Contains(debugBuf.String(), "hello") || !strings.Contains(debugBuf.String(), "careful") {
+		t.Errorf("Expected both messages in debug handler, got %s", debugBuf.String())
+	}
+	if strings.Contains(warnBuf.String(), "hello") || !strings.Contains(warnBuf.String(), "component=test") {
+		t.Errorf("Expected only warning with attrs in warn handler, got %s", warnBuf.String())
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("Expected empty request ID, got %s", id)
+	}
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 16 || a == b {
+		t.Errorf("Expected unique 16-char request IDs, got %s and %s", a, b)
+	}
+}
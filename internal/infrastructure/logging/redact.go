@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"log/slog"
+	"net/url"
+	"regexp"
+)
+
+// redacted 替换敏感信息的占位符
+const redacted = "REDACTED"
+
+// secretParams 需要脱敏的查询参数
+var secretParams = []string{"appid", "api_key", "apikey", "token"}
+
+// secretPattern 匹配文本中查询参数形式的密钥，用于兜底脱敏
+var secretPattern = regexp.MustCompile(`(?i)\b(appid|api_key|apikey|token)=[^&\s"]+`)
+
+// RedactURL 将URL中的API密钥等敏感查询参数替换为占位符
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	q := u.Query()
+	changed := false
+	for _, p := range secretParams {
+		if q.Has(p) {
+			q.Set(p, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// RedactString 将文本中查询参数形式的密钥替换为占位符
+func RedactString(s string) string {
+	return secretPattern.ReplaceAllString(s, "${1}="+redacted)
+}
+
+// redactAttr 对所有字符串类型的日志字段兜底脱敏，防止错误信息中携带的URL泄露密钥
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); secretPattern.MatchString(s) {
+			return slog.String(a.Key, RedactString(s))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok && secretPattern.MatchString(err.Error()) {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
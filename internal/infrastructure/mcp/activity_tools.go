@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"weather-mcp-server/internal/application/services"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultActivityHours 活动建议默认的预报时长
+const defaultActivityHours = 24
+
+// activityAdviceTool 活动适宜度建议工具
+func (wt *WeatherTools) activityAdviceTool() server.ServerTool {
+	rules := wt.weatherService.ActivityRules()
+	names := make([]string, 0, len(rules))
+	labels := make([]string, 0, len(rules))
+	for _, r := range rules {
+		names = append(names, r.Name)
+		labels = append(labels, fmt.Sprintf("%s(%s)", r.Name, r.Label))
+	}
+
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name: "activity_advice",
+			Description: "根据未来的小时预报评估户外活动的适宜度，返回每个活动的最佳时段及原因（预报为3小时间隔）。" +
+				"支持的活动：" + strings.Join(labels, "、"),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"location": map[string]any{
+						"type":        "string",
+						"description": "位置信息，可以是城市名（如：杭州）或坐标（如：30.2741,120.1551）",
+					},
+					"activities": map[string]any{
+						"type":        "array",
+						"description": "需要评估的活动，不传表示评估所有活动",
+						"items":       map[string]any{"type": "string", "enum": names},
+					},
+					"hours": map[string]any{
+						"type":        "integer",
+						"description": "评估的预报时长（小时），1-120",
+						"minimum":     1,
+						"maximum":     services.MaxForecastHours,
+						"default":     defaultActivityHours,
+					},
+				},
+				Required: []string{"location"},
+			},
+		},
+		Handler: wt.handleActivityAdvice,
+	}
+}
+
+// handleActivityAdvice 处理活动适宜度建议请求
+func (wt *WeatherTools) handleActivityAdvice(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Location   string   `json:"location"`
+		Activities []string `json:"activities"`
+		Hours      *int     `json:"hours"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if args.Location == "" {
+		return nil, fmt.Errorf("location parameter is required")
+	}
+	hours := defaultActivityHours
+	if args.Hours != nil {
+		hours = *args.Hours
+	}
+	if hours < 1 || hours > services.MaxForecastHours {
+		return nil, fmt.Errorf("hours parameter must be between 1 and %d", services.MaxForecastHours)
+	}
+
+	result, err := wt.weatherService.AdviseActivities(ctx, args.Location, args.Activities, hours)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 获取活动建议失败: %s", err.Error())), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal activity advice: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: wt.weatherService.FormatActivityAdviceResponse(result),
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
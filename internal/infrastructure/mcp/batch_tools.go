@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxBatchLocations 单次批量查询允许的最大位置数
+const maxBatchLocations = 10
+
+// batchItemData 批量查询中单个位置的结构化结果
+type batchItemData struct {
+	Location string                       `json:"location"`
+	Success  bool                         `json:"success"`
+	Error    string                       `json:"error,omitempty"`
+	Weather  *weather.Weather             `json:"weather,omitempty"`
+	Hourly   *weather.HourlyWeatherResult `json:"hourly,omitempty"`
+}
+
+// batchWeatherTool 批量天气查询工具
+func (wt *WeatherTools) batchWeatherTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "get_weather_batch",
+			Description: "一次查询多个位置的天气并返回对比表格和结构化数据，适用于比较多个目的地（注意：预报数据为3小时间隔）",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"locations": map[string]any{
+						"type":        "array",
+						"description": "位置列表，每项可以是城市名（如：上海）或坐标（如：39.9042,116.4074）",
+						"items":       map[string]any{"type": "string"},
+						"minItems":    1,
+						"maxItems":    maxBatchLocations,
+					},
+					"hours": map[string]any{
+						"type":        "integer",
+						"description": "需要查询的小时数，0或不传表示查询实时天气，1-12表示查询未来小时预报",
+						"minimum":     0,
+						"maximum":     12,
+						"default":     0,
+					},
+				},
+				Required: []string{"locations"},
+			},
+		},
+		Handler: wt.handleGetWeatherBatch,
+	}
+}
+
+// handleGetWeatherBatch 处理批量天气查询请求
+func (wt *WeatherTools) handleGetWeatherBatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Locations []string `json:"locations"`
+		Hours     int      `json:"hours"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if len(args.Locations) == 0 {
+		return nil, fmt.Errorf("locations parameter is required")
+	}
+	if len(args.Locations) > maxBatchLocations {
+		return nil, fmt.Errorf("at most %d locations are allowed", maxBatchLocations)
+	}
+	for i, location := range args.Locations {
+		args.Locations[i] = strings.TrimSpace(location)
+		if args.Locations[i] == "" {
+			return nil, fmt.Errorf("locations[%d] must not be empty", i)
+		}
+	}
+	if args.Hours < 0 || args.Hours > 12 {
+		return nil, fmt.Errorf("hours parameter must be between 0 and 12")
+	}
+
+	results := wt.weatherService.GetWeatherBatch(ctx, args.Locations, args.Hours)
+
+	items := make([]batchItemData, 0, len(results))
+	for _, r := range results {
+		item := batchItemData{
+			Location: r.Location,
+			Success:  r.Err == nil,
+			Weather:  r.Weather,
+			Hourly:   r.Hourly,
+		}
+		if r.Err != nil {
+			item.Error = r.Err.Error()
+		}
+		items = append(items, item)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch results: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: wt.weatherService.FormatBatchWeatherResponse(results, args.Hours),
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultCompareHours 比较工具默认的预报时长
+const defaultCompareHours = 12
+
+// compareLocationsTool 多位置比较排名工具
+func (wt *WeatherTools) compareLocationsTool() server.ServerTool {
+	criteria := make([]string, 0, len(weather.RankCriteria))
+	for _, c := range weather.RankCriteria {
+		criteria = append(criteria, string(c))
+	}
+
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name: "compare_locations",
+			Description: "比较多个位置未来一段时间的天气并按标准排名，返回排名及支撑数据。" +
+				"标准：warmest(最温暖)、least_rainy(降水最少)、lowest_wind(风力最小)、best_aqi(空气质量最好)、most_comfortable(体感最舒适)",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"locations": map[string]any{
+						"type":        "array",
+						"description": "位置列表，每项可以是城市名（如：成都）或坐标（如：39.9042,116.4074）",
+						"items":       map[string]any{"type": "string"},
+						"minItems":    2,
+						"maxItems":    maxBatchLocations,
+					},
+					"criterion": map[string]any{
+						"type":        "string",
+						"description": "排名标准",
+						"enum":        criteria,
+					},
+					"hours": map[string]any{
+						"type":        "integer",
+						"description": "比较的预报时长（小时），1-12",
+						"minimum":     1,
+						"maximum":     12,
+						"default":     defaultCompareHours,
+					},
+				},
+				Required: []string{"locations", "criterion"},
+			},
+		},
+		Handler: wt.handleCompareLocations,
+	}
+}
+
+// handleCompareLocations 处理多位置比较请求
+func (wt *WeatherTools) handleCompareLocations(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Locations []string `json:"locations"`
+		Criterion string   `json:"criterion"`
+		Hours     *int     `json:"hours"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if len(args.Locations) < 2 {
+		return nil, fmt.Errorf("at least 2 locations are required")
+	}
+	if len(args.Locations) > maxBatchLocations {
+		return nil, fmt.Errorf("at most %d locations are allowed", maxBatchLocations)
+	}
+	for i, location := range args.Locations {
+		args.Locations[i] = strings.TrimSpace(location)
+		if args.Locations[i] == "" {
+			return nil, fmt.Errorf("locations[%d] must not be empty", i)
+		}
+	}
+	criterion, err := weather.ParseRankCriterion(args.Criterion)
+	if err != nil {
+		return nil, err
+	}
+	hours := defaultCompareHours
+	if args.Hours != nil {
+		hours = *args.Hours
+	}
+	if hours < 1 || hours > 12 {
+		return nil, fmt.Errorf("hours parameter must be between 1 and 12")
+	}
+
+	result, err := wt.weatherService.CompareLocations(ctx, args.Locations, criterion, hours)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 比较天气失败: %s", err.Error())), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal comparison result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: wt.weatherService.FormatComparisonResponse(result),
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"context"
+
+	"weather-mcp-server/internal/infrastructure/auth"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// anonymousClient 无法识别会话时使用的身份
+const anonymousClient = "anonymous"
+
+// stdioSessionID mcp-go 的 stdio 传输使用的固定会话ID
+const stdioSessionID = "stdio"
+
+// ClientIdentity 获取请求所属客户端的身份，用于区分用户偏好设置、位置别名和监测规则
+// HTTP传输下优先使用认证的客户端ID，无法被其他客户端冒用；
+// 未认证时使用会话ID，不同会话的状态互不可见。客户端上报的名称可以任意伪造且常被多个客户端共用，不能作为身份。
+// stdio 传输只有一个固定ID的会话，偏好设置在重启后仍然有效。
+func ClientIdentity(ctx context.Context) string {
+	if id := auth.ClientID(ctx); id != "" {
+		return "user:" + id
+	}
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return anonymousClient
+	}
+	return "session:" + session.SessionID()
+}
+
+// durableIdentity 判断请求的客户端身份在会话结束和服务器重启后是否仍然有效
+// 认证的客户端ID和 stdio 的固定会话是持久的；未认证的HTTP会话ID随机生成，会话结束后无法再以该身份访问。
+func durableIdentity(ctx context.Context) bool {
+	if auth.ClientID(ctx) != "" {
+		return true
+	}
+	session := server.ClientSessionFromContext(ctx)
+	return session != nil && session.SessionID() == stdioSessionID
+}
@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"weather-mcp-server/internal/infrastructure/logging"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// serverLogger 转发给客户端的日志通知的 logger 名称
+const serverLogger = "weather-mcp-server"
+
+// logForwarder 将请求处理过程中的日志通过 notifications/message 转发给发起请求的客户端
+// 仅转发上下文中携带客户端会话的日志，级别由会话通过 logging/setLevel 设置。
+type logForwarder struct {
+	server *server.MCPServer
+	attrs  []slog.Attr
+	group  string
+}
+
+// NewLogForwarder 创建将日志转发给MCP客户端的处理器
+// 发送目标会话从上下文中获取，因此可作为全局默认日志处理器的一部分
+func NewLogForwarder(mcpServer *server.MCPServer) slog.Handler {
+	return &logForwarder{server: mcpServer}
+}
+
+// Enabled 仅在上下文携带支持日志的客户端会话且级别不低于会话设置的级别时启用
+func (h *logForwarder) Enabled(ctx context.Context, level slog.Level) bool {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithLogging)
+	if !ok || !session.Initialized() {
+		return false
+	}
+	return loggingLevel(level).ShouldSendTo(session.GetLogLevel())
+}
+
+// Handle 发送日志通知，客户端不支持日志时忽略
+func (h *logForwarder) Handle(ctx context.Context, r slog.Record) error {
+	data := map[string]any{"message": r.Message}
+	if id := logging.RequestID(ctx); id != "" {
+		data[logging.RequestIDAttr] = id
+	}
+	add := func(a slog.Attr) bool {
+		key := a.Key
+		if h.group != "" {
+			key = h.group + "." + key
+		}
+		data[key] = logValue(a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+
+	notification := mcp.NewLoggingMessageNotification(loggingLevel(r.Level), serverLogger, data)
+	// 客户端未初始化或不支持日志时不视为错误
+	_ = h.server.SendLogMessageToClient(ctx, notification)
+	return nil
+}
+
+// WithAttrs 实现 slog.Handler
+func (h *logForwarder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logForwarder{server: h.server, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...), group: h.group}
+}
+
+// WithGroup 实现 slog.Handler，分组以点号前缀展开
+func (h *logForwarder) WithGroup(name string) slog.Handler {
+	group := name
+	if h.group != "" {
+		group = h.group + "." + name
+	}
+	return &logForwarder{server: h.server, attrs: h.attrs, group: group}
+}
+
+// logValue 转换日志字段值为可JSON序列化的值，并对字符串脱敏
+func logValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindString:
+		return logging.RedactString(v.String())
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return logging.RedactString(err.Error())
+		}
+		return v.Any()
+	default:
+		return v.Any()
+	}
+}
+
+// loggingLevel 将 slog 级别映射为MCP日志级别
+func loggingLevel(level slog.Level) mcp.LoggingLevel {
+	switch {
+	case level >= slog.LevelError:
+		return mcp.LoggingLevelError
+	case level >= slog.LevelWarn:
+		return mcp.LoggingLevelWarning
+	case level >= slog.LevelInfo:
+		return mcp.LoggingLevelInfo
+	default:
+		return mcp.LoggingLevelDebug
+	}
+}
+
+// RequestIDMiddleware 为每次工具调用分配关联ID并记录调用日志
+// 关联ID随上下文传递到应用服务和上游HTTP客户端的日志中
+func RequestIDMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if logging.RequestID(ctx) == "" {
+				ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+			}
+			tool := request.Params.Name
+
+			start := time.Now()
+			slog.DebugContext(ctx, "tool call started", "tool", tool)
+			result, err := next(ctx, request)
+			if err != nil {
+				slog.WarnContext(ctx, "tool call failed", "tool", tool, "duration", time.Since(start), "error", err)
+			} else {
+				slog.InfoContext(ctx, "tool call finished", "tool", tool, "duration", time.Since(start))
+			}
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"weather-mcp-server/internal/infrastructure/logging"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// loggingSession 记录日志通知的测试会话
+type loggingSession struct {
+	id            string
+	level         mcp.LoggingLevel
+	notifications chan mcp.JSONRPCNotification
+}
+
+func newLoggingSession(id string, level mcp.LoggingLevel) *loggingSession {
+	return &loggingSession{id: id, level: level, notifications: make(chan mcp.JSONRPCNotification, 10)}
+}
+
+func (s *loggingSession) SessionID() string                                   { return s.id }
+func (s *loggingSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *loggingSession) Initialize()                                         {}
+func (s *loggingSession) Initialized() bool                                   { return true }
+func (s *loggingSession) SetLogLevel(level mcp.LoggingLevel)                  { s.level = level }
+func (s *loggingSession) GetLogLevel() mcp.LoggingLevel                       { return s.level }
+
+func TestLogForwarderRespectsSessionLevel(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithLogging())
+	session := newLoggingSession("s1", mcp.LoggingLevelWarning)
+	ctx := mcpServer.WithContext(context.Background(), session)
+	ctx = logging.WithRequestID(ctx, "req-1")
+
+	logger := slog.New(NewLogForwarder(mcpServer)).With("tool", "get_weather")
+	logger.InfoContext(ctx, "ignored below session level")
+	logger.WarnContext(ctx, "upstream failed", "error", errors.New("GET https://x/weather?appid=secret&q=Beijing"))
+
+	if len(session.notifications) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(session.notifications))
+	}
+	n := <-session.notifications
+	if n.Method != "notifications/message" {
+		t.Errorf("Expected notifications/message, got %s", n.Method)
+	}
+	if n.Params.AdditionalFields["level"] != mcp.LoggingLevelWarning {
+		t.Errorf("Expected warning level, got %v", n.Params.AdditionalFields["level"])
+	}
+	data, _ := n.Params.AdditionalFields["data"].(map[string]any)
+	if data["message"] != "upstream failed" || data["tool"] != "get_weather" || data[logging.RequestIDAttr] != "req-1" {
+		t.Errorf("Unexpected notification data: %v", data)
+	}
+	if data["error"] != "GET https://x/weather?appid=REDACTED&q=Beijing" {
+		t.Errorf("Expected redacted error, got %v", data["error"])
+	}
+}
+
+func TestLogForwarderWithoutSession(t *testing.T) {
+	if NewLogForwarder(server.NewMCPServer("test", "1.0.0")).Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected forwarder to be disabled without a client session")
+	}
+}
+
+func TestLoggingLevel(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected mcp.LoggingLevel
+	}{
+		{slog.LevelDebug, mcp.LoggingLevelDebug},
+		{slog.LevelInfo, mcp.LoggingLevelInfo},
+		{slog.LevelWarn, mcp.LoggingLevelWarning},
+		{slog.LevelError, mcp.LoggingLevelError},
+		{slog.LevelError + 4, mcp.LoggingLevelError},
+	}
+	for _, tt := range tests {
+		if got := loggingLevel(tt.level); got != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.level, got)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seen = logging.RequestID(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(seen) != 16 {
+		t.Errorf("Expected generated request ID, got %q", seen)
+	}
+
+	ctx := logging.WithRequestID(context.Background(), "upstream-id")
+	if _, err := handler(ctx, mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if seen != "upstream-id" {
+		t.Errorf("Expected existing request ID to be kept, got %q", seen)
+	}
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// 可设置的偏好项
+const (
+	preferenceLocation     = "location"
+	preferenceUnits        = "units"
+	preferenceLanguage     = "language"
+	preferenceDefaultHours = "default_hours"
+)
+
+// setPreferenceTool 设置偏好工具
+func (wt *WeatherTools) setPreferenceTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name: "set_preference",
+			Description: "设置当前用户的偏好：保存位置别名（如 home、office，之后可直接在 get_weather 中使用）、" +
+				"单位制、提示词语言或 get_weather 默认的预报小时数。语言只影响提示词，天气查询结果始终为中文",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"key": map[string]any{
+						"type":        "string",
+						"description": "偏好项：location（位置别名）、units（metric 或 imperial）、language（zh 或 en，仅用于提示词）、default_hours（0-12）",
+						"enum":        []string{preferenceLocation, preferenceUnits, preferenceLanguage, preferenceDefaultHours},
+					},
+					"name": map[string]any{
+						"type":        "string",
+						"description": "位置别名，key 为 location 时必需，如：home",
+					},
+					"value": map[string]any{
+						"type":        "string",
+						"description": "偏好值；key 为 location 时为城市名或坐标，为空表示删除该别名",
+					},
+				},
+				Required: []string{"key"},
+			},
+		},
+		Handler: wt.handleSetPreference,
+	}
+}
+
+// listLocationsTool 列出保存的位置和偏好工具
+func (wt *WeatherTools) listLocationsTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name:        "list_locations",
+			Description: "列出当前用户保存的位置别名及其他偏好设置",
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]any{},
+			},
+		},
+		Handler: wt.handleListLocations,
+	}
+}
+
+// handleSetPreference 处理设置偏好请求
+func (wt *WeatherTools) handleSetPreference(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Key   string `json:"key"`
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	// 偏好设置会写入偏好文件，未认证会话结束后没有人能再读取或删除这些数据
+	if !durableIdentity(ctx) {
+		return mcp.NewToolResultError("❌ 设置偏好失败: 未认证的HTTP会话不能保存偏好，请使用认证的客户端"), nil
+	}
+
+	user := ClientIdentity(ctx)
+	var prefs *weather.Preferences
+	switch args.Key {
+	case preferenceLocation:
+		prefs, err = wt.weatherService.SaveLocation(user, args.Name, args.Value)
+	case preferenceUnits:
+		prefs, err = wt.weatherService.SetUnits(user, args.Value)
+	case preferenceLanguage:
+		prefs, err = wt.weatherService.SetLanguage(user, args.Value)
+	case preferenceDefaultHours:
+		hours, convErr := strconv.Atoi(args.Value)
+		if convErr != nil {
+			return nil, fmt.Errorf("default_hours value must be an integer")
+		}
+		prefs, err = wt.weatherService.SetDefaultHours(user, hours)
+	default:
+		return nil, fmt.Errorf("unsupported preference key: %s", args.Key)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 设置偏好失败: %s", err.Error())), nil
+	}
+
+	return preferencesResult("✅ 偏好已更新\n"+wt.weatherService.FormatPreferencesResponse(prefs), prefs)
+}
+
+// handleListLocations 处理列出保存位置请求
+func (wt *WeatherTools) handleListLocations(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	prefs, err := wt.weatherService.GetPreferences(ClientIdentity(ctx))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 获取偏好失败: %s", err.Error())), nil
+	}
+	return preferencesResult(wt.weatherService.FormatPreferencesResponse(prefs), prefs)
+}
+
+// preferencesResult 构造包含文本和结构化数据的偏好设置结果
+func preferencesResult(text string, prefs *weather.Preferences) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/infrastructure/preferences"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestSetPreferenceRequiresDurableIdentity(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	path := filepath.Join(t.TempDir(), "preferences.json")
+	store, err := preferences.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wt := NewWeatherTools(services.NewWeatherApplicationService(&scriptedRepository{}, services.WithPreferences(store)))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		user    string
+		allowed bool
+	}{
+		{"anonymous HTTP session", sessionContext(mcpServer, "s2", ""), "session:s2", false},
+		{"authenticated", sessionContext(mcpServer, "s1", "alice"), "user:alice", true},
+		{"stdio", sessionContext(mcpServer, stdioSessionID, ""), "session:stdio", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request mcp.CallToolRequest
+			request.Params.Arguments = map[string]any{"key": "units", "value": "imperial"}
+			result, err := wt.handleSetPreference(tt.ctx, request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.IsError == tt.allowed {
+				t.Errorf("Expected allowed=%v, got result %+v", tt.allowed, result.Content)
+			}
+			prefs, _ := store.GetPreferences(tt.user)
+			if (prefs != nil) != tt.allowed {
+				t.Errorf("Expected allowed=%v, got stored preferences %+v", tt.allowed, prefs)
+			}
+		})
+	}
+
+	// 被拒绝的匿名会话不会写入偏好文件
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reloaded, err := preferences.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prefs, _ := reloaded.GetPreferences("session:s2"); prefs != nil {
+		t.Errorf("Expected no preferences for anonymous session in file, got %s", data)
+	}
+}
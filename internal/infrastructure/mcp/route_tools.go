@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"weather-mcp-server/internal/application/services"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxRouteWaypoints 路线天气单次请求的最大途经点数量
+const maxRouteWaypoints = 10
+
+// routeWeatherTool 路线天气工具
+func (wt *WeatherTools) routeWeatherTool() server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.Tool{
+			Name: "route_weather",
+			Description: "获取行程沿途各途经点在预计到达时间的天气预报，并标记存在大风、强降水、降雪、结冰、低能见度或高温的危险路段。" +
+				"到达时间可逐点指定，或由出发时间和平均速度按大圆距离推算；预报为3小时间隔，覆盖未来5天。",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"waypoints": map[string]any{
+						"type":        "array",
+						"description": "按顺序排列的途经点，2-10个",
+						"minItems":    2,
+						"maxItems":    maxRouteWaypoints,
+						"items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"location": map[string]any{
+									"type":        "string",
+									"description": "位置信息，可以是城市名（如：南京）或坐标（如：32.0603,118.7969）",
+								},
+								"eta": map[string]any{
+									"type":        "string",
+									"description": "预计到达时间，RFC 3339格式（如：2026-10-20T14:00:00+08:00），不传时按平均速度推算",
+								},
+							},
+							"required": []string{"location"},
+						},
+					},
+					"departure_time": map[string]any{
+						"type":        "string",
+						"description": "从第一个途经点出发的时间，RFC 3339格式，默认为当前时间",
+					},
+					"average_speed_kmh": map[string]any{
+						"type":             "number",
+						"description":      "平均速度（km/h），有途经点未指定到达时间时必需",
+						"exclusiveMinimum": 0,
+					},
+				},
+				Required: []string{"waypoints"},
+			},
+		},
+		Handler: wt.handleRouteWeather,
+	}
+}
+
+// handleRouteWeather 处理路线天气请求
+func (wt *WeatherTools) handleRouteWeather(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Waypoints []struct {
+			Location string `json:"location"`
+			ETA      string `json:"eta"`
+		} `json:"waypoints"`
+		DepartureTime   string  `json:"departure_time"`
+		AverageSpeedKmh float64 `json:"average_speed_kmh"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if len(args.Waypoints) < 2 || len(args.Waypoints) > maxRouteWaypoints {
+		return nil, fmt.Errorf("waypoints parameter must contain between 2 and %d items", maxRouteWaypoints)
+	}
+	if args.AverageSpeedKmh < 0 {
+		return nil, fmt.Errorf("average_speed_kmh parameter must be positive")
+	}
+
+	departure := time.Now()
+	if args.DepartureTime != "" {
+		if departure, err = time.Parse(time.RFC3339, args.DepartureTime); err != nil {
+			return nil, fmt.Errorf("invalid departure_time: %w", err)
+		}
+	}
+
+	waypoints := make([]services.RouteWaypoint, len(args.Waypoints))
+	for i, wp := range args.Waypoints {
+		if wp.Location == "" {
+			return nil, fmt.Errorf("waypoint %d: location is required", i+1)
+		}
+		waypoints[i].Location = wp.Location
+		if wp.ETA != "" {
+			eta, err := time.Parse(time.RFC3339, wp.ETA)
+			if err != nil {
+				return nil, fmt.Errorf("waypoint %d: invalid eta: %w", i+1, err)
+			}
+			waypoints[i].ETA = &eta
+		}
+	}
+
+	result, err := wt.weatherService.PlanRouteWeather(ctx, waypoints, departure, args.AverageSpeedKmh)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 获取路线天气失败: %s", err.Error())), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal route weather: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: wt.weatherService.FormatRouteWeatherResponse(result),
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
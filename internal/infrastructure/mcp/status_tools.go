@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"weather-mcp-server/internal/infrastructure/health"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// StatusTools MCP服务状态诊断工具
+type StatusTools struct {
+	checker *health.Checker
+}
+
+// NewStatusTools 创建服务状态诊断工具
+func NewStatusTools(checker *health.Checker) *StatusTools {
+	return &StatusTools{checker: checker}
+}
+
+// GetTools 获取服务状态诊断工具
+func (st *StatusTools) GetTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name: "server_status",
+				Description: "诊断服务器状态：数据源是否可达、API密钥是否有效、进行中的请求数、最近的上游错误率、" +
+					"运行时长、版本和配置摘要（密钥已脱敏）。天气查询持续失败时先调用此工具定位原因",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]any{},
+				},
+			},
+			Handler: st.handleServerStatus,
+		},
+	}
+}
+
+// handleServerStatus 处理服务状态查询请求
+func (st *StatusTools) handleServerStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	report := st.checker.Status(ctx)
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal status report: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: formatStatusReport(report),
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
+
+// statusLabels 服务状态的中文名称
+var statusLabels = map[string]string{
+	health.StatusOK:          "✅ 正常",
+	health.StatusDegraded:    "⚠️ 降级",
+	health.StatusUnavailable: "❌ 不可用",
+}
+
+// formatStatusReport 格式化服务状态报告
+func formatStatusReport(r health.Report) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🩺 服务状态: %s\n", statusLabels[r.Status]))
+	sb.WriteString(fmt.Sprintf("🏷️  版本: %s，已运行 %s\n", r.Version, r.Uptime))
+	if p := r.Provider; p != nil {
+		sb.WriteString(fmt.Sprintf("🌐 数据源 %s: 可达=%s，密钥有效=%s，延迟 %s",
+			p.Provider, yesNo(p.Reachable), yesNo(p.KeyValid), p.Latency.Round(time.Millisecond)))
+		if p.StatusCode != 0 {
+			sb.WriteString(fmt.Sprintf("，HTTP %d", p.StatusCode))
+		}
+		sb.WriteString("\n")
+		if len(p.Features) > 0 {
+			sb.WriteString(fmt.Sprintf("💳 可用付费功能: %s\n", strings.Join(p.Features, ", ")))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("⏳ 进行中: %d 个工具调用，%d 个上游请求\n", r.ToolsInFlight, r.Upstream.InFlight))
+	sb.WriteString(fmt.Sprintf("📉 最近上游请求: %d 次，失败 %d 次（%.0f%%）\n",
+		r.Upstream.Requests, r.Upstream.Errors, r.Upstream.ErrorRate*100))
+	if len(r.Problems) > 0 {
+		sb.WriteString("🔎 问题:\n")
+		for _, p := range r.Problems {
+			sb.WriteString(fmt.Sprintf("  - %s\n", p))
+		}
+	}
+	if len(r.Config) > 0 {
+		sb.WriteString("⚙️  配置:\n")
+		for _, k := range r.ConfigKeys() {
+			sb.WriteString(fmt.Sprintf("  %s=%s\n", k, r.Config[k]))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// yesNo 布尔值的中文表示
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
@@ -0,0 +1,342 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultSubscriptionInterval 订阅后台轮询的默认间隔
+const DefaultSubscriptionInterval = 5 * time.Minute
+
+// ErrSessionNotRegistered 会话未在服务器注册，无法接收订阅通知
+var ErrSessionNotRegistered = errors.New("subscriptions require a connected session that can receive notifications (stdio, SSE, or Streamable HTTP with a GET stream)")
+
+// Notifier 向指定会话发送通知，由 server.MCPServer 实现
+type Notifier interface {
+	SendNotificationToSpecificClient(sessionID string, method string, params map[string]any) error
+}
+
+// SubscriptionManager 天气资源订阅管理器
+// 同一客户端对同一位置的多个订阅共享一个后台轮询，天气显著变化时向所有订阅会话推送 notifications/resources/updated。
+// 轮询以首个订阅请求的身份（上游API密钥和客户端）查询，不同租户或客户端的订阅各自轮询。
+// 只有服务器注册的会话（stdio、SSE 和保持 GET 流的 Streamable HTTP）能接收通知并在结束时注销，
+// 其他会话（如只发送 POST 的 Streamable HTTP 会话）结束时不会通知服务器，不能订阅，否则轮询将永远不会停止。
+// mcp-go 当前版本不处理 resources/subscribe 请求，因此订阅通过工具管理。
+type SubscriptionManager struct {
+	weatherService *services.WeatherApplicationService
+	notifier       Notifier
+	interval       time.Duration
+	tempThreshold  float64
+
+	mu         sync.Mutex
+	watches    map[string]*watch // subscriptionKey -> 轮询
+	registered map[string]bool   // 服务器已注册的会话ID
+}
+
+// watch 单个客户端对单个位置的后台轮询
+type watch struct {
+	key      string
+	location string
+	// ctx 订阅请求的租户身份，轮询时恢复
+	ctx      context.Context
+	sessions map[string]bool
+	last     *weather.Weather
+	stop     chan struct{}
+}
+
+// NewSubscriptionManager 创建新的订阅管理器
+func NewSubscriptionManager(weatherService *services.WeatherApplicationService, notifier Notifier, interval time.Duration) *SubscriptionManager {
+	if interval <= 0 {
+		interval = DefaultSubscriptionInterval
+	}
+	return &SubscriptionManager{
+		weatherService: weatherService,
+		notifier:       notifier,
+		interval:       interval,
+		tempThreshold:  weather.DefaultTemperatureChangeThreshold,
+		watches:        make(map[string]*watch),
+		registered:     make(map[string]bool),
+	}
+}
+
+// RegisterSession 记录服务器已注册的会话，在会话注册时调用
+func (m *SubscriptionManager) RegisterSession(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.registered[sessionID] = true
+}
+
+// subscriptionKey 轮询的键
+// 位置可能是客户端保存的别名，且需使用租户自带的密钥查询，因此按租户和客户端身份区分
+func subscriptionKey(ctx context.Context, location string) string {
+	return services.TenantFromContext(ctx) + "|" + ClientIdentity(ctx) + "|" + location
+}
+
+// Subscribe 为会话订阅位置的天气变化
+// 首次订阅某位置时会立即查询一次作为变化比较的基准
+func (m *SubscriptionManager) Subscribe(ctx context.Context, sessionID, location string) error {
+	key := subscriptionKey(ctx, location)
+	m.mu.Lock()
+	if !m.registered[sessionID] {
+		m.mu.Unlock()
+		return ErrSessionNotRegistered
+	}
+	if w, ok := m.watches[key]; ok {
+		w.sessions[sessionID] = true
+		m.mu.Unlock()
+		return nil
+	}
+	m.mu.Unlock()
+
+	baseline, err := m.weatherService.GetWeatherByLocation(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 查询期间会话可能已经结束，或已有其他会话创建了同一位置的轮询
+	if !m.registered[sessionID] {
+		return ErrSessionNotRegistered
+	}
+	if w, ok := m.watches[key]; ok {
+		w.sessions[sessionID] = true
+		return nil
+	}
+	w := &watch{
+		key:      key,
+		location: location,
+		ctx:      services.DetachContext(ctx),
+		sessions: map[string]bool{sessionID: true},
+		last:     baseline,
+		stop:     make(chan struct{}),
+	}
+	m.watches[key] = w
+	go m.run(w)
+	return nil
+}
+
+// Unsubscribe 取消会话以 ctx 的身份对位置的订阅，返回是否存在该订阅
+func (m *SubscriptionManager) Unsubscribe(ctx context.Context, sessionID, location string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.watches[subscriptionKey(ctx, location)]
+	if !ok || !w.sessions[sessionID] {
+		return false
+	}
+	m.removeSessionLocked(w, sessionID)
+	return true
+}
+
+// UnsubscribeSession 取消会话的所有订阅，在会话注销时调用
+func (m *SubscriptionManager) UnsubscribeSession(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.registered, sessionID)
+	for _, w := range m.watches {
+		if w.sessions[sessionID] {
+			m.removeSessionLocked(w, sessionID)
+		}
+	}
+}
+
+// removeSessionLocked 移除会话，位置无订阅时停止轮询，调用方需持有锁
+func (m *SubscriptionManager) removeSessionLocked(w *watch, sessionID string) {
+	delete(w.sessions, sessionID)
+	if len(w.sessions) == 0 {
+		close(w.stop)
+		delete(m.watches, w.key)
+	}
+}
+
+// Subscriptions 获取会话订阅的位置列表
+func (m *SubscriptionManager) Subscriptions(sessionID string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var locations []string
+	for _, w := range m.watches {
+		if w.sessions[sessionID] {
+			locations = append(locations, w.location)
+		}
+	}
+	sort.Strings(locations)
+	return locations
+}
+
+// Close 停止所有后台轮询
+func (m *SubscriptionManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, w := range m.watches {
+		close(w.stop)
+		delete(m.watches, key)
+	}
+}
+
+// run 按间隔轮询位置天气，直到订阅全部取消
+func (m *SubscriptionManager) run(w *watch) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			m.poll(w)
+		}
+	}
+}
+
+// poll 以订阅请求的租户身份查询一次天气，有显著变化时通知所有订阅会话
+func (m *SubscriptionManager) poll(w *watch) {
+	current, err := m.weatherService.GetWeatherByLocation(w.ctx, w.location)
+	if err != nil {
+		slog.WarnContext(w.ctx, "subscription poll failed", "location", w.location, "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	changes := weather.DetectChanges(w.last, current, m.tempThreshold)
+	if len(changes) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	w.last = current
+	sessions := make([]string, 0, len(w.sessions))
+	for sessionID := range w.sessions {
+		sessions = append(sessions, sessionID)
+	}
+	m.mu.Unlock()
+
+	params := map[string]any{"uri": currentResourceURI(w.location)}
+	for _, sessionID := range sessions {
+		err := m.notifier.SendNotificationToSpecificClient(sessionID, string(mcp.MethodNotificationResourceUpdated), params)
+		switch {
+		case errors.Is(err, server.ErrSessionNotFound):
+			// 会话已不存在但未收到注销通知，取消其订阅
+			m.UnsubscribeSession(sessionID)
+		case err != nil:
+			slog.Warn("failed to notify subscriber", "session", sessionID, "location", w.location, "error", err)
+		}
+	}
+}
+
+// currentResourceURI 获取位置的实时天气资源URI
+func currentResourceURI(location string) string {
+	return currentResourcePrefix + url.PathEscape(location)
+}
+
+// GetTools 获取订阅管理工具
+func (m *SubscriptionManager) GetTools() []server.ServerTool {
+	locationSchema := map[string]any{
+		"type":        "string",
+		"description": "位置信息，可以是城市名（如：深圳）或坐标（如：22.5431,114.0579）",
+	}
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name: "subscribe_weather",
+				Description: fmt.Sprintf("订阅指定位置的天气变化。服务器每%s检查一次，温度、天气状况或预警发生显著变化时，"+
+					"推送 notifications/resources/updated 通知对应的 weather://current/{location} 资源", m.interval),
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]any{"location": locationSchema},
+					Required:   []string{"location"},
+				},
+			},
+			Handler: m.handleSubscribe,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "unsubscribe_weather",
+				Description: "取消对指定位置天气变化的订阅",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]any{"location": locationSchema},
+					Required:   []string{"location"},
+				},
+			},
+			Handler: m.handleUnsubscribe,
+		},
+	}
+}
+
+// handleSubscribe 处理订阅请求
+func (m *SubscriptionManager) handleSubscribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID, location, err := subscriptionArgs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Subscribe(ctx, sessionID, location); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 订阅天气失败: %s", err.Error())), nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("✅ 已订阅 %s 的天气变化，资源: %s\n当前订阅: %s",
+					location, currentResourceURI(location), strings.Join(m.Subscriptions(sessionID), ", ")),
+			},
+		},
+	}, nil
+}
+
+// handleUnsubscribe 处理取消订阅请求
+func (m *SubscriptionManager) handleUnsubscribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID, location, err := subscriptionArgs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("✅ 已取消订阅 %s 的天气变化", location)
+	if !m.Unsubscribe(ctx, sessionID, location) {
+		text = fmt.Sprintf("ℹ️ 未订阅 %s 的天气变化", location)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}, nil
+}
+
+// subscriptionArgs 解析订阅工具的会话和位置参数
+func subscriptionArgs(ctx context.Context, request mcp.CallToolRequest) (string, string, error) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return "", "", fmt.Errorf("subscriptions require a client session")
+	}
+
+	var args struct {
+		Location string `json:"location"`
+	}
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return "", "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	args.Location = strings.TrimSpace(args.Location)
+	if args.Location == "" {
+		return "", "", fmt.Errorf("location parameter is required")
+	}
+	return session.SessionID(), args.Location, nil
+}
@@ -0,0 +1,335 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// scriptedRepository 按顺序返回预设天气的测试仓储
+type scriptedRepository struct {
+	mu       sync.Mutex
+	weathers []*weather.Weather
+}
+
+func (r *scriptedRepository) next() (*weather.Weather, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.weathers) == 0 {
+		return nil, errors.New("no more weather")
+	}
+	w := r.weathers[0]
+	if len(r.weathers) > 1 {
+		r.weathers = r.weathers[1:]
+	}
+	return w, nil
+}
+
+func (r *scriptedRepository) GetCurrentWeather(ctx context.Context, lat, lon float64) (*weather.Weather, error) {
+	return r.next()
+}
+
+func (r *scriptedRepository) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	return r.next()
+}
+
+func (r *scriptedRepository) GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*weather.HourlyWeatherResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *scriptedRepository) GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*weather.HourlyWeatherResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+// recordingNotifier 记录发送通知的测试通知器
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (n *recordingNotifier) SendNotificationToSpecificClient(sessionID string, method string, params map[string]any) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, sessionID+" "+method+" "+params["uri"].(string))
+	return nil
+}
+
+func TestSubscriptionManagerNotifiesOnSignificantChange(t *testing.T) {
+	repo := &scriptedRepository{weathers: []*weather.Weather{
+		{Current: weather.CurrentWeather{Temperature: 28, Icon: "03d"}},
+		{Current: weather.CurrentWeather{Temperature: 28.5, Icon: "03d"}},
+		{Current: weather.CurrentWeather{Temperature: 24, Icon: "10d"}},
+	}}
+	notifier := &recordingNotifier{}
+	manager := NewSubscriptionManager(services.NewWeatherApplicationService(repo), notifier, time.Hour)
+	defer manager.Close()
+	manager.RegisterSession("s1")
+	manager.RegisterSession("s2")
+
+	if err := manager.Subscribe(context.Background(), "s1", "深圳"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := manager.Subscribe(context.Background(), "s2", "深圳"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	w := manager.watches[subscriptionKey(context.Background(), "深圳")]
+	manager.poll(w)
+	if len(notifier.sent) != 0 {
+		t.Errorf("Expected no notification for minor change, got %v", notifier.sent)
+	}
+
+	manager.poll(w)
+	expected := map[string]bool{
+		"s1 notifications/resources/updated weather://current/%E6%B7%B1%E5%9C%B3": true,
+		"s2 notifications/resources/updated weather://current/%E6%B7%B1%E5%9C%B3": true,
+	}
+	if len(notifier.sent) != len(expected) {
+		t.Fatalf("Expected %d notifications, got %v", len(expected), notifier.sent)
+	}
+	for _, n := range notifier.sent {
+		if !expected[n] {
+			t.Errorf("Unexpected notification %q", n)
+		}
+	}
+}
+
+func TestSubscriptionManagerSessionCleanup(t *testing.T) {
+	repo := &scriptedRepository{weathers: []*weather.Weather{{}}}
+	manager := NewSubscriptionManager(services.NewWeatherApplicationService(repo), &recordingNotifier{}, time.Hour)
+	defer manager.Close()
+	manager.RegisterSession("s1")
+	manager.RegisterSession("s2")
+
+	manager.Subscribe(context.Background(), "s1", "深圳")
+	manager.Subscribe(context.Background(), "s1", "厦门")
+	manager.Subscribe(context.Background(), "s2", "厦门")
+
+	if got := manager.Subscriptions("s1"); len(got) != 2 || got[0] != "厦门" || got[1] != "深圳" {
+		t.Errorf("Expected s1 to watch 厦门 and 深圳, got %v", got)
+	}
+
+	manager.UnsubscribeSession("s1")
+	if _, ok := manager.watches[subscriptionKey(context.Background(), "深圳")]; ok {
+		t.Errorf("Expected watch without sessions to be stopped")
+	}
+	if _, ok := manager.watches[subscriptionKey(context.Background(), "厦门")]; !ok {
+		t.Errorf("Expected watch with remaining sessions to continue")
+	}
+
+	if !manager.Unsubscribe(context.Background(), "s2", "厦门") {
+		t.Errorf("Expected s2 subscription to exist")
+	}
+	if manager.Unsubscribe(context.Background(), "s2", "厦门") {
+		t.Errorf("Expected second unsubscribe to report missing subscription")
+	}
+	if len(manager.watches) != 0 {
+		t.Errorf("Expected all watches to be stopped, got %d", len(manager.watches))
+	}
+}
+
+func TestSubscribeFailsForUnknownLocation(t *testing.T) {
+	manager := NewSubscriptionManager(services.NewWeatherApplicationService(&scriptedRepository{}), &recordingNotifier{}, time.Hour)
+	defer manager.Close()
+	manager.RegisterSession("s1")
+	manager.RegisterSession("s2")
+
+	if err := manager.Subscribe(context.Background(), "s1", "Atlantis"); err == nil {
+		t.Errorf("Expected error when baseline cannot be fetched")
+	}
+	if len(manager.watches) != 0 {
+		t.Errorf("Expected no watch after failed subscription")
+	}
+}
+
+// tenantRepository 记录查询所属租户的测试仓储
+type tenantRepository struct {
+	scriptedRepository
+	mu      sync.Mutex
+	tenants []string
+}
+
+func (r *tenantRepository) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	r.mu.Lock()
+	r.tenants = append(r.tenants, services.TenantFromContext(ctx))
+	r.mu.Unlock()
+	return r.next()
+}
+
+func TestSubscriptionManagerPollsAsTenant(t *testing.T) {
+	repo := &tenantRepository{scriptedRepository: scriptedRepository{weathers: []*weather.Weather{
+		{Current: weather.CurrentWeather{Temperature: 28, Icon: "03d"}},
+	}}}
+	manager := NewSubscriptionManager(services.NewWeatherApplicationService(repo), &recordingNotifier{}, time.Hour)
+	defer manager.Close()
+	manager.RegisterSession("s1")
+	manager.RegisterSession("s2")
+
+	ctx, cancel := context.WithCancel(services.WithTenant(context.Background(), "tenant-a"))
+	if err := manager.Subscribe(ctx, "s1", "深圳"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cancel()
+	if err := manager.Subscribe(context.Background(), "s2", "深圳"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(manager.watches) != 2 {
+		t.Fatalf("Expected separate polls per tenant, got %d", len(manager.watches))
+	}
+
+	// 订阅请求结束后，轮询仍以订阅时的租户身份查询
+	manager.poll(manager.watches[subscriptionKey(ctx, "深圳")])
+	if last := repo.tenants[len(repo.tenants)-1]; last != "tenant-a" {
+		t.Errorf("Expected poll as tenant-a, got %q", last)
+	}
+	if manager.Unsubscribe(context.Background(), "s1", "深圳") {
+		t.Error("Expected unsubscribe from another tenant to fail")
+	}
+	if !manager.Unsubscribe(ctx, "s1", "深圳") {
+		t.Error("Expected unsubscribe as tenant-a to succeed")
+	}
+}
+
+// startSubscriptionServer 启动提供订阅工具的 Streamable HTTP MCP 服务器，会话注册和注销与 main 中的连接方式相同
+func startSubscriptionServer(t *testing.T, manager **SubscriptionManager) string {
+	t.Helper()
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		(*manager).RegisterSession(session.SessionID())
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		(*manager).UnsubscribeSession(session.SessionID())
+	})
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	repo := &scriptedRepository{weathers: []*weather.Weather{{}}}
+	*manager = NewSubscriptionManager(services.NewWeatherApplicationService(repo), mcpServer, time.Hour)
+	t.Cleanup((*manager).Close)
+	mcpServer.AddTools((*manager).GetTools()...)
+
+	ts := httptest.NewServer(server.NewStreamableHTTPServer(mcpServer))
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// connectSubscriber 连接并初始化 Streamable HTTP 客户端
+func connectSubscriber(t *testing.T, url string, opts ...transport.StreamableHTTPCOption) *client.Client {
+	t.Helper()
+	c, err := client.NewStreamableHttpClient(url, opts...)
+	if err != nil {
+		t.Fatalf("Expected client, got %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	// GET 流在 Start 的上下文中保持，不能使用带超时的上下文
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, request); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	return c
+}
+
+func subscribeOverHTTP(t *testing.T, c *client.Client) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "subscribe_weather"
+	request.Params.Arguments = map[string]any{"location": "深圳"}
+	result, err := c.CallTool(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected tool result, got %v", err)
+	}
+	return result
+}
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscribeOverHTTPRequiresRegisteredSession(t *testing.T) {
+	var manager *SubscriptionManager
+	url := startSubscriptionServer(t, &manager)
+
+	// 只发送 POST 的会话不会注册，结束时也不会注销，订阅会使轮询永远不停止
+	c := connectSubscriber(t, url)
+	if result := subscribeOverHTTP(t, c); !result.IsError {
+		t.Errorf("Expected subscription from POST-only session to be refused, got %+v", result.Content)
+	}
+	manager.mu.Lock()
+	watches := len(manager.watches)
+	manager.mu.Unlock()
+	if watches != 0 {
+		t.Errorf("Expected no poller for refused subscription, got %d", watches)
+	}
+}
+
+func TestSubscriptionPollerStopsWhenHTTPSessionEnds(t *testing.T) {
+	var manager *SubscriptionManager
+	url := startSubscriptionServer(t, &manager)
+	watches := func() int {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.watches)
+	}
+	registered := func() int {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.registered)
+	}
+
+	// 保持 GET 流的会话由服务器注册，可以接收通知
+	c := connectSubscriber(t, url, transport.WithContinuousListening())
+	waitFor(t, "session registration", func() bool { return registered() == 1 })
+	if result := subscribeOverHTTP(t, c); result.IsError {
+		t.Fatalf("Expected subscription to succeed, got %+v", result.Content)
+	}
+	if watches() != 1 {
+		t.Fatalf("Expected 1 poller, got %d", watches())
+	}
+
+	// 客户端断开后服务器注销会话，轮询随之停止
+	c.Close()
+	waitFor(t, "poller to stop", func() bool { return watches() == 0 && registered() == 0 })
+}
+
+func TestPollDropsUnknownSessions(t *testing.T) {
+	repo := &scriptedRepository{weathers: []*weather.Weather{
+		{Current: weather.CurrentWeather{Temperature: 20, Icon: "01d"}},
+		{Current: weather.CurrentWeather{Temperature: 30, Icon: "10d"}},
+	}}
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	manager := NewSubscriptionManager(services.NewWeatherApplicationService(repo), mcpServer, time.Hour)
+	defer manager.Close()
+	manager.RegisterSession("gone")
+
+	if err := manager.Subscribe(context.Background(), "gone", "深圳"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	manager.poll(manager.watches[subscriptionKey(context.Background(), "深圳")])
+	if len(manager.watches) != 0 {
+		t.Errorf("Expected poller for a session unknown to the server to stop, got %d", len(manager.watches))
+	}
+}
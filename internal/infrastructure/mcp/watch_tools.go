@@ -0,0 +1,343 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// watchLogger 监测事件日志通知的 logger 名称
+const watchLogger = "weather-watch"
+
+// maxWatchSessions 最多记录的会话数
+// 只发送 POST 的 Streamable HTTP 会话结束时不会注销，超出时淘汰最久未活动的会话
+const maxWatchSessions = 1000
+
+// WatchNotifier 以MCP日志通知（notifications/message）的形式发布监测事件
+// 事件发送给规则所有者当前连接的所有会话，所有者离线时忽略。
+type WatchNotifier struct {
+	notifier    Notifier
+	maxSessions int
+
+	mu       sync.Mutex
+	sessions map[string]map[string]bool // 客户端身份 -> 会话ID集合
+	tracked  map[string]trackedSession  // 会话ID -> 记录
+	seq      uint64
+}
+
+// trackedSession 已记录会话的客户端身份和最近活动顺序
+type trackedSession struct {
+	identity string
+	seq      uint64
+}
+
+// NewWatchNotifier 创建监测事件的MCP通知发布器
+func NewWatchNotifier(notifier Notifier) *WatchNotifier {
+	return &WatchNotifier{
+		notifier:    notifier,
+		maxSessions: maxWatchSessions,
+		sessions:    make(map[string]map[string]bool),
+		tracked:     make(map[string]trackedSession),
+	}
+}
+
+// TrackSession 记录请求所属会话与客户端身份的对应关系，在会话初始化后调用
+func (n *WatchNotifier) TrackSession(ctx context.Context) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return
+	}
+	identity := ClientIdentity(ctx)
+	sessionID := session.SessionID()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if t, ok := n.tracked[sessionID]; ok && t.identity != identity {
+		n.forgetLocked(sessionID)
+	}
+	if n.sessions[identity] == nil {
+		n.sessions[identity] = make(map[string]bool)
+	}
+	n.sessions[identity][sessionID] = true
+	n.seq++
+	n.tracked[sessionID] = trackedSession{identity: identity, seq: n.seq}
+
+	for len(n.tracked) > n.maxSessions {
+		oldest, oldestSeq := "", uint64(0)
+		for id, t := range n.tracked {
+			if oldest == "" || t.seq < oldestSeq {
+				oldest, oldestSeq = id, t.seq
+			}
+		}
+		n.forgetLocked(oldest)
+	}
+}
+
+// ForgetSession 移除已结束的会话
+func (n *WatchNotifier) ForgetSession(sessionID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.forgetLocked(sessionID)
+}
+
+// forgetLocked 移除会话，调用方需持有锁
+func (n *WatchNotifier) forgetLocked(sessionID string) {
+	t, ok := n.tracked[sessionID]
+	if !ok {
+		return
+	}
+	delete(n.tracked, sessionID)
+	delete(n.sessions[t.identity], sessionID)
+	if len(n.sessions[t.identity]) == 0 {
+		delete(n.sessions, t.identity)
+	}
+}
+
+// PublishWatchEvent 向规则所有者的会话发送警告级别的日志通知
+// 服务器已不存在的会话（未注销就结束的会话）不计为错误，直接移除
+func (n *WatchNotifier) PublishWatchEvent(event weather.WatchEvent) error {
+	n.mu.Lock()
+	sessions := make([]string, 0, len(n.sessions[event.Rule.Owner]))
+	for sessionID := range n.sessions[event.Rule.Owner] {
+		sessions = append(sessions, sessionID)
+	}
+	n.mu.Unlock()
+
+	params := map[string]any{
+		"level":  mcp.LoggingLevelWarning,
+		"logger": watchLogger,
+		"data":   event,
+	}
+	var firstErr error
+	for _, sessionID := range sessions {
+		err := n.notifier.SendNotificationToSpecificClient(sessionID, "notifications/message", params)
+		switch {
+		case errors.Is(err, server.ErrSessionNotFound):
+			n.ForgetSession(sessionID)
+		case err != nil && firstErr == nil:
+			firstErr = fmt.Errorf("failed to notify session %s: %w", sessionID, err)
+		}
+	}
+	return firstErr
+}
+
+// WatchTools 天气监测规则工具
+type WatchTools struct {
+	engine   *services.WatchEngine
+	notifier *WatchNotifier
+}
+
+// NewWatchTools 创建天气监测规则工具
+func NewWatchTools(engine *services.WatchEngine, notifier *WatchNotifier) *WatchTools {
+	return &WatchTools{
+		engine:   engine,
+		notifier: notifier,
+	}
+}
+
+// GetTools 获取监测规则管理工具
+func (wt *WatchTools) GetTools() []server.ServerTool {
+	metrics := make([]string, 0, len(weather.WatchMetrics))
+	for _, m := range weather.WatchMetrics {
+		metrics = append(metrics, string(m))
+	}
+	operators := make([]string, 0, len(weather.WatchOperators))
+	for _, op := range weather.WatchOperators {
+		operators = append(operators, string(op))
+	}
+
+	return []server.ServerTool{
+		{
+			Tool: mcp.Tool{
+				Name: "watch_weather",
+				Description: "添加天气阈值监测规则，如「北京 PM2.5 > 150」或「office 未来6小时降水概率 > 70%」。" +
+					"服务器定期检查，条件满足时通过MCP日志通知（以及配置的Webhook）提醒，触发后进入冷却期。相同规则不会重复添加。未认证的HTTP会话不能添加规则",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]any{
+						"location": map[string]any{
+							"type":        "string",
+							"description": "位置信息，可以是城市名、坐标或保存的位置别名",
+						},
+						"metric": map[string]any{
+							"type": "string",
+							"description": "监测指标：temperature（°C）、wind_speed（m/s）、pm25（μg/m³）、aqi（1-5）、" +
+								"precip_probability（未来若干小时内的最高降水概率，%）",
+							"enum": metrics,
+						},
+						"operator": map[string]any{
+							"type":        "string",
+							"description": "比较运算符",
+							"enum":        operators,
+						},
+						"threshold": map[string]any{
+							"type":        "number",
+							"description": "阈值",
+						},
+						"within_hours": map[string]any{
+							"type":        "integer",
+							"description": "precip_probability 的预报时长，1-120，默认6",
+							"minimum":     1,
+							"maximum":     services.MaxForecastHours,
+						},
+						"cooldown_minutes": map[string]any{
+							"type":        "integer",
+							"description": "触发后的冷却时间（分钟），不传使用服务器默认值",
+							"minimum":     1,
+						},
+					},
+					Required: []string{"location", "metric", "operator", "threshold"},
+				},
+			},
+			Handler: wt.handleWatch,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "list_watches",
+				Description: "列出当前用户的天气监测规则",
+				InputSchema: mcp.ToolInputSchema{
+					Type:       "object",
+					Properties: map[string]any{},
+				},
+			},
+			Handler: wt.handleListWatches,
+		},
+		{
+			Tool: mcp.Tool{
+				Name:        "unwatch_weather",
+				Description: "删除天气监测规则",
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]any{
+						"id": map[string]any{
+							"type":        "string",
+							"description": "规则ID，可通过 list_watches 查询",
+						},
+					},
+					Required: []string{"id"},
+				},
+			},
+			Handler: wt.handleUnwatch,
+		},
+	}
+}
+
+// handleWatch 处理添加监测规则请求
+func (wt *WatchTools) handleWatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Location        string   `json:"location"`
+		Metric          string   `json:"metric"`
+		Operator        string   `json:"operator"`
+		Threshold       *float64 `json:"threshold"`
+		WithinHours     int      `json:"within_hours"`
+		CooldownMinutes int      `json:"cooldown_minutes"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if args.Threshold == nil {
+		return nil, fmt.Errorf("threshold parameter is required")
+	}
+	// 规则在后台持续检查并可能写入规则文件，未认证会话结束后没有人能查看、删除规则或接收通知
+	if !durableIdentity(ctx) {
+		return mcp.NewToolResultError("❌ 添加监测规则失败: 未认证的HTTP会话不能添加监测规则，请使用认证的客户端"), nil
+	}
+
+	wt.notifier.TrackSession(ctx)
+	rule, created, err := wt.engine.AddRule(ctx, weather.WatchRule{
+		Owner:           ClientIdentity(ctx),
+		Location:        args.Location,
+		Metric:          weather.WatchMetric(args.Metric),
+		Operator:        weather.WatchOperator(args.Operator),
+		Threshold:       *args.Threshold,
+		WithinHours:     args.WithinHours,
+		CooldownMinutes: args.CooldownMinutes,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 添加监测规则失败: %s", err.Error())), nil
+	}
+
+	text := fmt.Sprintf("✅ 已添加监测规则 [%s] %s", rule.ID, rule.Describe())
+	if !created {
+		text = fmt.Sprintf("ℹ️ 监测规则已存在 [%s] %s", rule.ID, rule.Describe())
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}, nil
+}
+
+// handleListWatches 处理列出监测规则请求
+func (wt *WatchTools) handleListWatches(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	wt.notifier.TrackSession(ctx)
+	rules := wt.engine.Rules(ClientIdentity(ctx))
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal watch rules: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: wt.engine.FormatWatchRulesResponse(rules),
+			},
+			mcp.TextContent{
+				Type: "text",
+				Text: string(data),
+			},
+		},
+	}, nil
+}
+
+// handleUnwatch 处理删除监测规则请求
+func (wt *WatchTools) handleUnwatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		ID string `json:"id"`
+	}
+
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if err := json.Unmarshal(argsBytes, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if args.ID == "" {
+		return nil, fmt.Errorf("id parameter is required")
+	}
+
+	removed, err := wt.engine.RemoveRule(ClientIdentity(ctx), args.ID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ 删除监测规则失败: %s", err.Error())), nil
+	}
+	text := fmt.Sprintf("✅ 已删除监测规则 %s", args.ID)
+	if !removed {
+		text = fmt.Sprintf("ℹ️ 未找到监测规则 %s", args.ID)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}, nil
+}
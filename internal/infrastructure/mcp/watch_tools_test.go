@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/auth"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionRecorder 记录通知目标会话的测试通知器
+type sessionRecorder struct {
+	mu       sync.Mutex
+	sessions []string
+	methods  []string
+}
+
+func (r *sessionRecorder) SendNotificationToSpecificClient(sessionID string, method string, params map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = append(r.sessions, sessionID)
+	r.methods = append(r.methods, method)
+	return nil
+}
+
+// sessionContext 构造携带指定客户端会话的上下文，clientID 为空时表示未认证的会话
+func sessionContext(mcpServer *server.MCPServer, sessionID, clientID string) context.Context {
+	session := server.NewInProcessSession(sessionID, nil)
+	session.SetClientInfo(mcp.Implementation{Name: "desktop"})
+	ctx := context.Background()
+	if clientID != "" {
+		ctx = auth.WithClientID(ctx, clientID)
+	}
+	return mcpServer.WithContext(ctx, session)
+}
+
+func TestWatchNotifierRoutesByIdentity(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	recorder := &sessionRecorder{}
+	notifier := NewWatchNotifier(recorder)
+
+	notifier.TrackSession(sessionContext(mcpServer, "s1", "alice"))
+	notifier.TrackSession(sessionContext(mcpServer, "s2", "alice"))
+	notifier.TrackSession(sessionContext(mcpServer, "s3", "bob"))
+	// 同名的未认证客户端不会收到 alice 的通知
+	notifier.TrackSession(sessionContext(mcpServer, "s4", ""))
+
+	event := weather.WatchEvent{Rule: weather.WatchRule{Owner: "user:alice"}}
+	if err := notifier.PublishWatchEvent(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sort.Strings(recorder.sessions)
+	if len(recorder.sessions) != 2 || recorder.sessions[0] != "s1" || recorder.sessions[1] != "s2" {
+		t.Errorf("Expected notifications to s1 and s2, got %v", recorder.sessions)
+	}
+	if recorder.methods[0] != "notifications/message" {
+		t.Errorf("Expected logging notification, got %s", recorder.methods[0])
+	}
+
+	notifier.ForgetSession("s1")
+	notifier.ForgetSession("s2")
+	recorder.sessions = nil
+	if err := notifier.PublishWatchEvent(event); err != nil || len(recorder.sessions) != 0 {
+		t.Errorf("Expected no notifications for offline owner, got %v (%v)", recorder.sessions, err)
+	}
+}
+
+func TestWatchNotifierDropsUnknownSessions(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	notifier := NewWatchNotifier(mcpServer)
+
+	// 会话未在服务器注册（如只发送 POST 的 Streamable HTTP 会话），发送通知返回 ErrSessionNotFound
+	notifier.TrackSession(sessionContext(mcpServer, "gone", "alice"))
+	event := weather.WatchEvent{Rule: weather.WatchRule{Owner: "user:alice"}}
+	if err := notifier.PublishWatchEvent(event); err != nil {
+		t.Errorf("Expected unknown session not to be reported as an error, got %v", err)
+	}
+	if len(notifier.tracked) != 0 || len(notifier.sessions) != 0 {
+		t.Errorf("Expected unknown session to be dropped, got %v", notifier.sessions)
+	}
+}
+
+func TestWatchNotifierBoundsTrackedSessions(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	notifier := NewWatchNotifier(&sessionRecorder{})
+	notifier.maxSessions = 2
+
+	notifier.TrackSession(sessionContext(mcpServer, "s1", "alice"))
+	notifier.TrackSession(sessionContext(mcpServer, "s2", "bob"))
+	notifier.TrackSession(sessionContext(mcpServer, "s1", "alice"))
+	notifier.TrackSession(sessionContext(mcpServer, "s3", "carol"))
+
+	if len(notifier.tracked) != 2 {
+		t.Fatalf("Expected 2 tracked sessions, got %d", len(notifier.tracked))
+	}
+	if _, ok := notifier.sessions["user:bob"]; ok {
+		t.Error("Expected least recently active session s2 to be evicted")
+	}
+	if !notifier.sessions["user:alice"]["s1"] || !notifier.sessions["user:carol"]["s3"] {
+		t.Errorf("Expected s1 and s3 to remain tracked, got %v", notifier.sessions)
+	}
+}
+
+func TestWatchRequiresDurableIdentity(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	engine, err := services.NewWatchEngine(services.NewWeatherApplicationService(&scriptedRepository{}), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	wt := NewWatchTools(engine, NewWatchNotifier(&sessionRecorder{}))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		owner   string
+		allowed bool
+	}{
+		{"authenticated", sessionContext(mcpServer, "s1", "alice"), "user:alice", true},
+		{"stdio", sessionContext(mcpServer, stdioSessionID, ""), "session:stdio", true},
+		{"anonymous HTTP session", sessionContext(mcpServer, "s2", ""), "session:s2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request mcp.CallToolRequest
+			request.Params.Arguments = map[string]any{"location": "北京", "metric": "temperature", "operator": ">", "threshold": 35}
+			result, err := wt.handleWatch(tt.ctx, request)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.IsError == tt.allowed {
+				t.Errorf("Expected allowed=%v, got result %+v", tt.allowed, result.Content)
+			}
+			if rules := engine.Rules(tt.owner); (len(rules) == 1) != tt.allowed {
+				t.Errorf("Expected allowed=%v, got rules %+v", tt.allowed, rules)
+			}
+		})
+	}
+}
+
+func TestClientIdentity(t *testing.T) {
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{"authenticated", sessionContext(mcpServer, "s1", "alice"), "user:alice"},
+		{"anonymous session", sessionContext(mcpServer, "s2", ""), "session:s2"},
+		{"other anonymous session with the same client name", sessionContext(mcpServer, "s3", ""), "session:s3"},
+		{"no session", context.Background(), anonymousClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientIdentity(tt.ctx); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
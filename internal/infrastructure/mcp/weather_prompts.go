@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"weather-mcp-server/internal/application/services"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// defaultPromptLang 提示词默认语言
+	defaultPromptLang = "zh"
+	// promptForecastHours 活动和通勤提示词使用的预报小时数
+	promptForecastHours = 12
+	// maxTravelDays 旅行简报允许的最大天数
+	maxTravelDays = 14
+	// maxPromptArgLength 文本参数的最大长度
+	maxPromptArgLength = 100
+)
+
+// promptLocale 提示词模板的本地化文本
+// 只本地化指令模板和标题，嵌入的天气数据沿用服务的中文格式
+type promptLocale struct {
+	travelTitle     string
+	activityTitle   string
+	commuteTitle    string
+	travelBriefing  string // 参数: 位置, 日期, 实时天气, 预报
+	outdoorActivity string // 参数: 位置, 活动, 实时天气, 预报
+	dailyCommute    string // 参数: 位置, 实时天气, 预报
+	noForecast      string // 参数: 日期
+	unavailable     string // 参数: 错误信息
+}
+
+// enDataNote 英文提示词中说明天气数据为中文格式
+const enDataNote = "The weather data below is reported in Chinese; please answer in English.\n\n"
+
+// promptLocales 支持的提示词语言
+var promptLocales = map[string]promptLocale{
+	"zh": {
+		travelTitle:   "旅行天气简报",
+		activityTitle: "户外活动天气检查",
+		commuteTitle:  "通勤天气建议",
+		travelBriefing: "请为前往 %s 的旅行（日期：%s）准备一份天气简报。\n" +
+			"请概括每天的天气趋势、温度范围和降水风险，给出穿衣和行李建议，并指出可能影响行程的天气。\n\n" +
+			"## 实时天气\n%s\n\n## 行程期间预报\n%s",
+		outdoorActivity: "我计划在 %s 进行户外活动：%s。\n" +
+			"请根据以下天气数据判断未来12小时是否适合该活动，给出最佳时段、需要注意的风险（降水、大风、高温或低温）和装备建议。\n\n" +
+			"## 实时天气\n%s\n\n## 未来12小时预报\n%s",
+		dailyCommute: "请根据以下 %s 的天气数据给出今天的通勤建议。\n" +
+			"包括是否需要带伞、穿衣建议、早晚高峰时段的天气，以及对出行方式（步行、骑行、驾车、公共交通）的建议。\n\n" +
+			"## 实时天气\n%s\n\n## 未来12小时预报\n%s",
+		noForecast:  "%s 超出预报覆盖范围（未来5天），暂无预报数据，请根据实时天气和当地气候常识给出建议。",
+		unavailable: "暂时无法获取天气数据：%s",
+	},
+	"en": {
+		travelTitle:   "Travel weather briefing",
+		activityTitle: "Outdoor activity weather check",
+		commuteTitle:  "Commute weather advice",
+		travelBriefing: "Please prepare a weather briefing for a trip to %s (dates: %s).\n" +
+			"Summarize the daily trend, temperature range and precipitation risk, suggest what to wear and pack, and point out any weather that could disrupt the itinerary.\n" +
+			enDataNote +
+			"## Current weather\n%s\n\n## Forecast for the trip\n%s",
+		outdoorActivity: "I am planning to go %[2]s in %[1]s.\n" +
+			"Based on the weather data below, assess whether the next 12 hours are suitable for this activity, recommend the best time slots, call out risks (precipitation, wind, heat or cold) and suggest gear.\n" +
+			enDataNote +
+			"## Current weather\n%[3]s\n\n## Forecast for the next 12 hours\n%[4]s",
+		dailyCommute: "Please give today's commute advice based on the weather data for %s below.\n" +
+			"Cover whether an umbrella is needed, what to wear, conditions during the morning and evening rush hours, and advice on walking, cycling, driving or public transport.\n" +
+			enDataNote +
+			"## Current weather\n%s\n\n## Forecast for the next 12 hours\n%s",
+		noForecast:  "%s is beyond the forecast range (next 5 days), so no forecast is available. Please advise based on the current weather and the local climate.",
+		unavailable: "Weather data is temporarily unavailable: %s",
+	},
+}
+
+// WeatherPrompts MCP天气提示词
+type WeatherPrompts struct {
+	weatherService *services.WeatherApplicationService
+}
+
+// NewWeatherPrompts 创建新的天气提示词
+func NewWeatherPrompts(weatherService *services.WeatherApplicationService) *WeatherPrompts {
+	return &WeatherPrompts{
+		weatherService: weatherService,
+	}
+}
+
+// GetPrompts 获取所有天气提示词
+func (wp *WeatherPrompts) GetPrompts() []server.ServerPrompt {
+	locationArg := mcp.WithArgument("location",
+		mcp.ArgumentDescription("位置信息，可以是城市名（如：北京）、坐标（如：39.9042,116.4074）、城市ID（如：id:1816670）或邮政编码（如：zip:100000,CN）"),
+		mcp.RequiredArgument(),
+	)
+	langArg := mcp.WithArgument("lang",
+		mcp.ArgumentDescription("提示词语言：zh（默认）或 en，只影响指令和标题，天气数据保持中文"),
+	)
+
+	return []server.ServerPrompt{
+		{
+			Prompt: mcp.NewPrompt("travel_briefing",
+				mcp.WithPromptDescription("生成旅行目的地在行程日期内的天气简报"),
+				locationArg,
+				mcp.WithArgument("dates",
+					mcp.ArgumentDescription("行程日期，格式为 2006-01-02 或 2006-01-02~2006-01-05"),
+					mcp.RequiredArgument(),
+				),
+				langArg,
+			),
+			Handler: wp.handleTravelBriefing,
+		},
+		{
+			Prompt: mcp.NewPrompt("outdoor_activity_check",
+				mcp.WithPromptDescription("判断未来12小时是否适合进行指定的户外活动"),
+				locationArg,
+				mcp.WithArgument("activity",
+					mcp.ArgumentDescription("户外活动，如：跑步、骑行、徒步"),
+					mcp.RequiredArgument(),
+				),
+				langArg,
+			),
+			Handler: wp.handleOutdoorActivityCheck,
+		},
+		{
+			Prompt: mcp.NewPrompt("daily_commute",
+				mcp.WithPromptDescription("根据今天的天气生成通勤建议"),
+				locationArg,
+				langArg,
+			),
+			Handler: wp.handleDailyCommute,
+		},
+	}
+}
+
+// handleTravelBriefing 处理旅行简报提示词
+func (wp *WeatherPrompts) handleTravelBriefing(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	locale, err := wp.promptLocale(ctx, request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	location, err := requiredPromptArg(request.Params.Arguments, "location")
+	if err != nil {
+		return nil, err
+	}
+	dates, err := requiredPromptArg(request.Params.Arguments, "dates")
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(dates)
+	if err != nil {
+		return nil, err
+	}
+
+	current := wp.currentWeatherText(ctx, locale, location)
+	forecast := fmt.Sprintf(locale.noForecast, dates)
+	if hw, err := wp.weatherService.GetForecastForDates(ctx, location, from, to); err != nil {
+		forecast = fmt.Sprintf(locale.unavailable, err.Error())
+	} else if len(hw.Hourly) > 0 {
+		forecast = wp.weatherService.FormatHourlyWeatherResponse(hw)
+	}
+
+	return promptResult(locale.travelTitle, fmt.Sprintf(locale.travelBriefing, location, dates, current, forecast)), nil
+}
+
+// handleOutdoorActivityCheck 处理户外活动检查提示词
+func (wp *WeatherPrompts) handleOutdoorActivityCheck(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	locale, err := wp.promptLocale(ctx, request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	location, err := requiredPromptArg(request.Params.Arguments, "location")
+	if err != nil {
+		return nil, err
+	}
+	activity, err := requiredPromptArg(request.Params.Arguments, "activity")
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf(locale.outdoorActivity, location, activity,
+		wp.currentWeatherText(ctx, locale, location), wp.forecastText(ctx, locale, location))
+	return promptResult(locale.activityTitle, text), nil
+}
+
+// handleDailyCommute 处理通勤建议提示词
+func (wp *WeatherPrompts) handleDailyCommute(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	locale, err := wp.promptLocale(ctx, request.Params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	location, err := requiredPromptArg(request.Params.Arguments, "location")
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf(locale.dailyCommute, location,
+		wp.currentWeatherText(ctx, locale, location), wp.forecastText(ctx, locale, location))
+	return promptResult(locale.commuteTitle, text), nil
+}
+
+// currentWeatherText 获取实时天气文本，失败时返回本地化的错误说明
+func (wp *WeatherPrompts) currentWeatherText(ctx context.Context, locale promptLocale, location string) string {
+	w, err := wp.weatherService.GetCurrentSnapshot(ctx, location)
+	if err != nil {
+		return fmt.Sprintf(locale.unavailable, err.Error())
+	}
+	return wp.weatherService.FormatWeatherResponse(w)
+}
+
+// forecastText 获取未来12小时预报文本，失败时返回本地化的错误说明
+func (wp *WeatherPrompts) forecastText(ctx context.Context, locale promptLocale, location string) string {
+	hw, err := wp.weatherService.GetForecastSnapshot(ctx, location, promptForecastHours)
+	if err != nil {
+		return fmt.Sprintf(locale.unavailable, err.Error())
+	}
+	return wp.weatherService.FormatHourlyWeatherResponse(hw)
+}
+
+// promptResult 构造单条用户消息的提示词结果
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+// promptLocale 获取提示词语言，未指定 lang 参数时使用用户偏好的语言
+func (wp *WeatherPrompts) promptLocale(ctx context.Context, args map[string]string) (promptLocale, error) {
+	lang := strings.TrimSpace(args["lang"])
+	if lang == "" {
+		if prefs, err := wp.weatherService.GetPreferences(ClientIdentity(ctx)); err == nil {
+			lang = prefs.Language
+		}
+	}
+	return promptLocaleArg(lang)
+}
+
+// promptLocaleArg 解析并校验语言参数
+func promptLocaleArg(lang string) (promptLocale, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = defaultPromptLang
+	}
+	locale, ok := promptLocales[lang]
+	if !ok {
+		return promptLocale{}, fmt.Errorf("unsupported lang: %s (supported: zh, en)", lang)
+	}
+	return locale, nil
+}
+
+// requiredPromptArg 获取并校验必需的文本参数
+func requiredPromptArg(args map[string]string, name string) (string, error) {
+	value := strings.TrimSpace(args[name])
+	if value == "" {
+		return "", fmt.Errorf("%s argument is required", name)
+	}
+	if utf8.RuneCountInString(value) > maxPromptArgLength {
+		return "", fmt.Errorf("%s argument must be at most %d characters", name, maxPromptArgLength)
+	}
+	return value, nil
+}
+
+// parseDateRange 解析 2006-01-02 或 2006-01-02~2006-01-05 格式的日期范围
+func parseDateRange(dates string) (time.Time, time.Time, error) {
+	parts := strings.Split(dates, "~")
+	if len(parts) > 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid dates: %s", dates)
+	}
+
+	from, err := time.Parse("2006-01-02", strings.TrimSpace(parts[0]))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date: %w", err)
+	}
+	to := from
+	if len(parts) == 2 {
+		to, err = time.Parse("2006-01-02", strings.TrimSpace(parts[1]))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end date: %w", err)
+		}
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date must not be before start date")
+	}
+	if to.Sub(from) >= maxTravelDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("dates must span at most %d days", maxTravelDays)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		dates     string
+		days      int
+		expectErr bool
+	}{
+		{"2026-10-20", 0, false},
+		{"2026-10-20~2026-10-22", 2, false},
+		{" 2026-10-20 ~ 2026-10-22 ", 2, false},
+		{"2026-10-22~2026-10-20", 0, true},
+		{"2026-10-01~2026-10-20", 0, true},
+		{"2026/10/20", 0, true},
+		{"2026-10-20~2026-10-21~2026-10-22", 0, true},
+	}
+
+	for _, test := range tests {
+		from, to, err := parseDateRange(test.dates)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("For %q, expected error", test.dates)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("For %q, unexpected error: %v", test.dates, err)
+			continue
+		}
+		if days := int(to.Sub(from).Hours() / 24); days != test.days {
+			t.Errorf("For %q, expected %d days, got %d", test.dates, test.days, days)
+		}
+	}
+}
+
+func TestPromptArgumentValidation(t *testing.T) {
+	prompts := NewWeatherPrompts(services.NewWeatherApplicationService(&scriptedRepository{}))
+
+	tests := []struct {
+		name string
+		args map[string]string
+	}{
+		{"missing location", map[string]string{"activity": "跑步"}},
+		{"missing activity", map[string]string{"location": "北京"}},
+		{"unsupported lang", map[string]string{"location": "北京", "activity": "跑步", "lang": "fr"}},
+		{"location too long", map[string]string{"location": strings.Repeat("北", maxPromptArgLength+1), "activity": "跑步"}},
+	}
+
+	for _, test := range tests {
+		var request mcp.GetPromptRequest
+		request.Params.Arguments = test.args
+		if _, err := prompts.handleOutdoorActivityCheck(context.Background(), request); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestDailyCommutePromptLocalized(t *testing.T) {
+	repo := &scriptedRepository{weathers: []*weather.Weather{
+		{Location: weather.Location{City: "Beijing", Country: "CN"}, Current: weather.CurrentWeather{Temperature: 18, Description: "晴"}},
+	}}
+	prompts := NewWeatherPrompts(services.NewWeatherApplicationService(repo))
+
+	var request mcp.GetPromptRequest
+	request.Params.Arguments = map[string]string{"location": "Beijing", "lang": "en"}
+	result, err := prompts.handleDailyCommute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Role != mcp.RoleUser {
+		t.Fatalf("Expected one user message, got %+v", result.Messages)
+	}
+
+	if result.Description != "Commute weather advice" {
+		t.Errorf("Expected English title, got %q", result.Description)
+	}
+
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	for _, expected := range []string{
+		"Please give today's commute advice based on the weather data for Beijing",
+		"The weather data below is reported in Chinese",
+		"📍 Beijing, CN",
+		"Weather data is temporarily unavailable: not implemented",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected prompt to contain %q, got:\n%s", expected, text)
+		}
+	}
+}
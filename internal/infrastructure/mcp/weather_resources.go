@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"weather-mcp-server/internal/application/services"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// locationsResourceURI 已知位置列表资源
+	locationsResourceURI = "weather://locations"
+	// currentResourcePrefix 实时天气资源URI前缀
+	currentResourcePrefix = "weather://current/"
+	// forecastResourcePrefix 小时预报资源URI前缀
+	forecastResourcePrefix = "weather://forecast/"
+	// defaultResourceForecastHours 预报资源默认的小时数
+	defaultResourceForecastHours = 12
+)
+
+// WeatherResources MCP天气资源
+type WeatherResources struct {
+	weatherService *services.WeatherApplicationService
+}
+
+// NewWeatherResources 创建新的天气资源
+func NewWeatherResources(weatherService *services.WeatherApplicationService) *WeatherResources {
+	return &WeatherResources{
+		weatherService: weatherService,
+	}
+}
+
+// GetResources 获取所有静态资源
+func (wr *WeatherResources) GetResources() []server.ServerResource {
+	return []server.ServerResource{
+		{
+			Resource: mcp.NewResource(
+				locationsResourceURI,
+				"已知位置",
+				mcp.WithResourceDescription("最近查询过的位置及内置支持的城市列表"),
+				mcp.WithMIMEType("application/json"),
+			),
+			Handler: wr.handleReadLocations,
+		},
+	}
+}
+
+// GetResourceTemplates 获取所有资源模板
+func (wr *WeatherResources) GetResourceTemplates() []server.ServerResourceTemplate {
+	return []server.ServerResourceTemplate{
+		{
+			Template: mcp.NewResourceTemplate(
+				currentResourcePrefix+"{location}",
+				"实时天气",
+				mcp.WithTemplateDescription("指定位置的实时天气快照，location 为URL编码的城市名或坐标（如：%E5%8C%97%E4%BA%AC、39.9042%2C116.4074）"),
+				mcp.WithTemplateMIMEType("text/plain"),
+			),
+			Handler: wr.handleReadCurrent,
+		},
+		{
+			Template: mcp.NewResourceTemplate(
+				forecastResourcePrefix+"{location}{?hours}",
+				"小时天气预报",
+				mcp.WithTemplateDescription("指定位置的小时预报快照（3小时间隔），hours 为1-12，默认12"),
+				mcp.WithTemplateMIMEType("text/plain"),
+			),
+			Handler: wr.handleReadForecast,
+		},
+	}
+}
+
+// handleReadLocations 读取已知位置列表
+func (wr *WeatherResources) handleReadLocations(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(wr.weatherService.KnownLocations(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal locations: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// handleReadCurrent 读取实时天气快照
+func (wr *WeatherResources) handleReadCurrent(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	location, _, err := parseResourceURI(request.Params.URI, currentResourcePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := wr.weatherService.GetCurrentSnapshot(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weather for %s: %w", location, err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/plain",
+			Text:     wr.weatherService.FormatWeatherResponse(w),
+		},
+	}, nil
+}
+
+// handleReadForecast 读取小时预报快照
+func (wr *WeatherResources) handleReadForecast(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	location, query, err := parseResourceURI(request.Params.URI, forecastResourcePrefix)
+	if err != nil {
+		return nil, err
+	}
+	hours := defaultResourceForecastHours
+	if v := query.Get("hours"); v != "" {
+		hours, err = strconv.Atoi(v)
+		if err != nil || hours < 1 || hours > 12 {
+			return nil, fmt.Errorf("hours must be between 1 and 12")
+		}
+	}
+
+	hw, err := wr.weatherService.GetForecastSnapshot(ctx, location, hours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forecast for %s: %w", location, err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/plain",
+			Text:     wr.weatherService.FormatHourlyWeatherResponse(hw),
+		},
+	}, nil
+}
+
+// parseResourceURI 从资源URI中解析位置和查询参数
+// 直接解析URI而不是使用模板变量，因为坐标中的逗号会被模板拆分为列表
+func parseResourceURI(uri, prefix string) (string, url.Values, error) {
+	rest := strings.TrimPrefix(uri, prefix)
+	rawQuery := ""
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, rawQuery = rest[:i], rest[i+1:]
+	}
+
+	location, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid location in resource URI: %w", err)
+	}
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return "", nil, fmt.Errorf("location is required in resource URI")
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid query in resource URI: %w", err)
+	}
+	return location, query, nil
+}
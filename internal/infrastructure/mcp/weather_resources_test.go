@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/application/services"
+	"weather-mcp-server/internal/domain/weather"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// catalogRepository 带内置位置列表的测试仓储
+type catalogRepository struct {
+	scriptedRepository
+	hourly *weather.HourlyWeatherResult
+}
+
+func (r *catalogRepository) GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*weather.HourlyWeatherResult, error) {
+	if r.hourly == nil {
+		return nil, errors.New("not implemented")
+	}
+	// 与 OpenWeatherMap 客户端一致，按3小时间隔返回覆盖hours小时的时段
+	hw := *r.hourly
+	hw.Hourly = hw.Hourly[:min(len(hw.Hourly), (hours+2)/3)]
+	return &hw, nil
+}
+
+func (r *catalogRepository) KnownLocations() []string {
+	return []string{"北京", "上海"}
+}
+
+// readResource 通过资源处理函数读取资源，返回文本内容
+func readResource(t *testing.T, handler func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error), uri string) (string, error) {
+	t.Helper()
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	contents, err := handler(context.Background(), request)
+	if err != nil {
+		return "", err
+	}
+	if len(contents) != 1 {
+		t.Fatalf("Expected 1 resource content, got %d", len(contents))
+	}
+	text, ok := contents[0].(mcp.TextResourceContents)
+	if !ok || text.URI != uri {
+		t.Fatalf("Expected text content for %s, got %+v", uri, contents[0])
+	}
+	return text.Text, nil
+}
+
+func TestReadLocationsResource(t *testing.T) {
+	repo := &catalogRepository{scriptedRepository: scriptedRepository{weathers: []*weather.Weather{
+		{Location: weather.Location{City: "Beijing", Country: "CN"}},
+	}}}
+	service := services.NewWeatherApplicationService(repo)
+	resources := NewWeatherResources(service)
+	if _, err := service.GetWeatherByLocation(context.Background(), "北京"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	text, err := readResource(t, resources.GetResources()[0].Handler, locationsResourceURI)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var locations []map[string]any
+	if err := json.Unmarshal([]byte(text), &locations); err != nil {
+		t.Fatalf("Expected JSON location list, got %s", text)
+	}
+	if len(locations) != 2 || locations[0]["name"] != "北京" || locations[1]["name"] != "上海" {
+		t.Fatalf("Expected queried location then catalog entry, got %s", text)
+	}
+	if _, err := time.Parse(time.RFC3339, locations[0]["last_queried"].(string)); err != nil {
+		t.Errorf("Expected last_queried for queried location, got %v", locations[0]["last_queried"])
+	}
+	if _, ok := locations[1]["last_queried"]; ok {
+		t.Errorf("Expected no last_queried for catalog entry, got %s", text)
+	}
+}
+
+func TestReadWeatherResourceTemplates(t *testing.T) {
+	start := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	var slots []weather.HourlyWeather
+	for i := 0; i < 4; i++ {
+		slots = append(slots, weather.HourlyWeather{Date: start.Add(time.Duration(3*i) * time.Hour), Temperature: float64(20 + i), Description: "晴"})
+	}
+	location := weather.Location{City: "Shanghai", Country: "CN"}
+	repo := &catalogRepository{
+		scriptedRepository: scriptedRepository{weathers: []*weather.Weather{
+			{Location: location, Current: weather.CurrentWeather{Temperature: 25, Description: "多云"}},
+		}},
+		hourly: &weather.HourlyWeatherResult{Location: location, Hourly: slots},
+	}
+	templates := NewWeatherResources(services.NewWeatherApplicationService(repo)).GetResourceTemplates()
+	current, forecast := templates[0].Handler, templates[1].Handler
+
+	text, err := readResource(t, current, "weather://current/Shanghai")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(text, "Shanghai") || !strings.Contains(text, "多云") {
+		t.Errorf("Expected current weather for Shanghai, got %s", text)
+	}
+
+	text, err = readResource(t, forecast, "weather://forecast/Shanghai?hours=6")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Count(text, "晴") != 2 {
+		t.Errorf("Expected 2 forecast slots for 6 hours, got %s", text)
+	}
+
+	if _, err := readResource(t, forecast, "weather://forecast/Shanghai?hours=13"); err == nil {
+		t.Error("Expected error for hours out of range")
+	}
+	if _, err := readResource(t, current, "weather://current/"); err == nil {
+		t.Error("Expected error for missing location")
+	}
+}
+
+func TestParseResourceURI(t *testing.T) {
+	tests := []struct {
+		uri          string
+		prefix       string
+		expected     string
+		expectedHour string
+		expectErr    bool
+	}{
+		{"weather://current/%E5%8C%97%E4%BA%AC", currentResourcePrefix, "北京", "", false},
+		{"weather://current/39.9042,116.4074", currentResourcePrefix, "39.9042,116.4074", "", false},
+		{"weather://current/39.9042%2C116.4074", currentResourcePrefix, "39.9042,116.4074", "", false},
+		{"weather://forecast/New%20York?hours=6", forecastResourcePrefix, "New York", "6", false},
+		{"weather://forecast/?hours=6", forecastResourcePrefix, "", "", true},
+		{"weather://current/%ZZ", currentResourcePrefix, "", "", true},
+	}
+
+	for _, test := range tests {
+		location, query, err := parseResourceURI(test.uri, test.prefix)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("For %s, expected error", test.uri)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("For %s, unexpected error: %v", test.uri, err)
+			continue
+		}
+		if location != test.expected {
+			t.Errorf("For %s, expected location %s, got %s", test.uri, test.expected, location)
+		}
+		if query.Get("hours") != test.expectedHour {
+			t.Errorf("For %s, expected hours %s, got %s", test.uri, test.expectedHour, query.Get("hours"))
+		}
+	}
+}
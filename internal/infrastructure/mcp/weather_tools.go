@@ -35,14 +35,13 @@ func (wt *WeatherTools) GetTools() []server.ServerTool {
 					Properties: map[string]any{
 						"location": map[string]any{
 							"type":        "string",
-							"description": "位置信息，可以是城市名（如：北京）或坐标（如：39.9042,116.4074）",
+							"description": "位置信息，可以是城市名（如：北京）、坐标（如：39.9042,116.4074）、城市ID（如：id:1816670，结果中会返回该ID，用于后续查询固定到同一位置）、邮政编码（如：zip:100000,CN，未指定国家时为CN）或通过 set_preference 保存的别名（如：home）",
 						},
 						"hours": map[string]any{
 							"type":        "integer",
-							"description": "需要查询的小时数，0或不传表示查询实时天气，1-12表示查询未来小时预报",
+							"description": "需要查询的小时数，0表示查询实时天气，1-12表示查询未来小时预报；不传时使用偏好中的默认值（未设置为0）",
 							"minimum":     0,
 							"maximum":     12,
-							"default":     0,
 						},
 					},
 					Required: []string{"location"},
@@ -50,6 +49,12 @@ func (wt *WeatherTools) GetTools() []server.ServerTool {
 			},
 			Handler: wt.handleGetWeather,
 		},
+		wt.batchWeatherTool(),
+		wt.compareLocationsTool(),
+		wt.activityAdviceTool(),
+		wt.routeWeatherTool(),
+		wt.setPreferenceTool(),
+		wt.listLocationsTool(),
 	}
 }
 
@@ -57,7 +62,7 @@ func (wt *WeatherTools) GetTools() []server.ServerTool {
 func (wt *WeatherTools) handleGetWeather(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args struct {
 		Location string `json:"location"`
-		Hours    int    `json:"hours"`
+		Hours    *int   `json:"hours"`
 	}
 
 	argsBytes, err := json.Marshal(request.Params.Arguments)
@@ -70,25 +75,30 @@ func (wt *WeatherTools) handleGetWeather(ctx context.Context, request mcp.CallTo
 	if args.Location == "" {
 		return nil, fmt.Errorf("location parameter is required")
 	}
-	if args.Hours < 0 || args.Hours > 12 {
+
+	// 应用用户偏好：默认预报小时数和单位制，位置别名由应用服务解析
+	prefs, err := wt.weatherService.GetPreferences(ClientIdentity(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	location := args.Location
+	hours := prefs.DefaultHours
+	if args.Hours != nil {
+		hours = *args.Hours
+	}
+	if hours < 0 || hours > 12 {
 		return nil, fmt.Errorf("hours parameter must be between 0 and 12")
 	}
+	units := prefs.EffectiveUnits()
 
 	// 根据 hours 参数决定查询类型
-	if args.Hours == 0 {
+	if hours == 0 {
 		// 查询实时天气
-		weather, err := wt.weatherService.GetWeatherByLocation(args.Location)
+		weather, err := wt.weatherService.GetWeatherByLocation(ctx, location)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: fmt.Sprintf("❌ 获取实时天气信息失败: %s", err.Error()),
-					},
-				},
-			}, nil
+			return mcp.NewToolResultError(fmt.Sprintf("❌ 获取实时天气信息失败: %s", err.Error())), nil
 		}
-		formattedResponse := wt.weatherService.FormatWeatherResponse(weather)
+		formattedResponse := wt.weatherService.FormatWeatherResponseInUnits(weather, units)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -99,18 +109,11 @@ func (wt *WeatherTools) handleGetWeather(ctx context.Context, request mcp.CallTo
 		}, nil
 	} else {
 		// 查询小时级天气预报
-		hourly, err := wt.weatherService.GetHourlyWeatherByLocation(args.Location, args.Hours)
+		hourly, err := wt.weatherService.GetHourlyWeatherByLocation(ctx, location, hours)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: fmt.Sprintf("❌ 获取小时级天气预报失败: %s", err.Error()),
-					},
-				},
-			}, nil
+			return mcp.NewToolResultError(fmt.Sprintf("❌ 获取小时级天气预报失败: %s", err.Error())), nil
 		}
-		formattedResponse := wt.weatherService.FormatHourlyWeatherResponse(hourly)
+		formattedResponse := wt.weatherService.FormatHourlyWeatherResponseInUnits(hourly, units)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/infrastructure/roundtrip"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名称前缀
+const namespace = "weather_mcp"
+
+// Metrics 服务的Prometheus指标
+type Metrics struct {
+	registry *prometheus.Registry
+
+	toolCalls        *prometheus.CounterVec
+	toolDuration     *prometheus.HistogramVec
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+	cacheLookups     *prometheus.CounterVec
+
+	now    func() time.Time
+	tenant func(context.Context) string
+	mu     sync.Mutex
+	quotas map[string]*dailyQuota
+}
+
+// Option 指标配置项
+type Option func(*Metrics)
+
+// WithClock 设置配额统计使用的时钟，主要用于测试
+func WithClock(now func() time.Time) Option {
+	return func(m *Metrics) {
+		m.now = now
+	}
+}
+
+// WithTenantScope 设置识别租户请求的函数，返回非空时表示请求使用租户自带的上游API密钥
+// 租户请求仍计入上游请求指标，但不消耗服务器密钥的每日配额
+func WithTenantScope(tenant func(context.Context) string) Option {
+	return func(m *Metrics) {
+		m.tenant = tenant
+	}
+}
+
+// New 创建指标并注册到独立的注册表，同时包含Go运行时和进程指标
+func New(opts ...Option) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "Number of MCP tool invocations by tool and status.",
+		}, []string{"tool", "status"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Latency of MCP tool invocations.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tool"}),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Number of upstream weather API requests by provider, endpoint and status code.",
+		}, []string{"provider", "endpoint", "code"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of upstream weather API requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"provider", "endpoint"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Number of weather snapshot cache lookups by cache and result.",
+		}, []string{"cache", "result"}),
+		now:    time.Now,
+		quotas: make(map[string]*dailyQuota),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.registry.MustRegister(
+		m.toolCalls, m.toolDuration,
+		m.upstreamRequests, m.upstreamDuration,
+		m.cacheLookups,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler 获取以Prometheus文本格式输出指标的HTTP处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ToolMiddleware 统计工具调用次数、耗时和错误
+// 处理器返回错误或结果标记为 IsError 时记为 error
+func (m *Metrics) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+
+			tool := request.Params.Name
+			status := "ok"
+			if err != nil || (result != nil && result.IsError) {
+				status = "error"
+			}
+			m.toolCalls.WithLabelValues(tool, status).Inc()
+			m.toolDuration.WithLabelValues(tool).Observe(time.Since(start).Seconds())
+			return result, err
+		}
+	}
+}
+
+// ObserveCacheLookup 记录一次快照缓存查询
+func (m *Metrics) ObserveCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// InstrumentTransport 包装上游HTTP传输层，统计请求次数、状态码和耗时
+// endpoint 取URL路径的最后一段（如 weather、forecast），不记录查询参数以免泄露API密钥
+func (m *Metrics) InstrumentTransport(provider string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundtrip.Func(func(req *http.Request) (*http.Response, error) {
+		endpoint := path.Base(req.URL.Path)
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		m.upstreamDuration.WithLabelValues(provider, endpoint).Observe(time.Since(start).Seconds())
+
+		code := "error"
+		if err == nil {
+			code = strconv.Itoa(resp.StatusCode)
+		}
+		m.upstreamRequests.WithLabelValues(provider, endpoint, code).Inc()
+		if m.tenant == nil || m.tenant(req.Context()) == "" {
+			m.recordQuotaUsage(provider)
+		}
+		return resp, err
+	})
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// scrape 获取Prometheus文本格式的指标输出
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestToolMiddleware(t *testing.T) {
+	m := New()
+	ok := m.ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	failing := m.ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("location parameter is required")
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "get_weather"
+	ok(context.Background(), request)
+	ok(context.Background(), request)
+	failing(context.Background(), request)
+
+	output := scrape(t, m)
+	for _, line := range []string{
+		`weather_mcp_tool_calls_total{status="ok",tool="get_weather"} 2`,
+		`weather_mcp_tool_calls_total{status="error",tool="get_weather"} 1`,
+		`weather_mcp_tool_call_duration_seconds_count{tool="get_weather"} 3`,
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected metrics to contain %q", line)
+		}
+	}
+}
+
+func TestInstrumentTransport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/forecast") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	now := time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC)
+	m := New(WithClock(func() time.Time { return now }))
+	m.TrackDailyQuota("openweathermap", 1000)
+	client := &http.Client{Transport: m.InstrumentTransport("openweathermap", nil)}
+
+	for _, p := range []string{"/data/2.5/weather?appid=secret", "/data/2.5/weather?appid=secret", "/data/2.5/forecast?appid=secret"} {
+		resp, err := client.Get(upstream.URL + p)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	output := scrape(t, m)
+	for _, line := range []string{
+		`weather_mcp_upstream_requests_total{code="200",endpoint="weather",provider="openweathermap"} 2`,
+		`weather_mcp_upstream_requests_total{code="429",endpoint="forecast",provider="openweathermap"} 1`,
+		`weather_mcp_upstream_request_duration_seconds_count{endpoint="weather",provider="openweathermap"} 2`,
+		`weather_mcp_upstream_quota_remaining{provider="openweathermap"} 997`,
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected metrics to contain %q", line)
+		}
+	}
+	if strings.Contains(output, "secret") {
+		t.Error("Expected metrics not to contain query parameters")
+	}
+
+	// 配额在UTC零点重置
+	now = now.Add(2 * time.Hour)
+	if remaining := m.QuotaRemaining("openweathermap"); remaining != 1000 {
+		t.Errorf("Expected quota to reset on a new day, got %d", remaining)
+	}
+	if remaining := m.QuotaRemaining("other"); remaining != -1 {
+		t.Errorf("Expected -1 for untracked provider, got %d", remaining)
+	}
+}
+
+func TestQuotaExcludesTenantRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	type tenantKey struct{}
+	m := New(WithTenantScope(func(ctx context.Context) string {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return tenant
+	}))
+	m.TrackDailyQuota("openweathermap", 1000)
+	client := &http.Client{Transport: m.InstrumentTransport("openweathermap", nil)}
+
+	for _, ctx := range []context.Context{
+		context.Background(),
+		context.WithValue(context.Background(), tenantKey{}, "tenant-a"),
+		context.WithValue(context.Background(), tenantKey{}, "tenant-b"),
+	} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/data/2.5/weather", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if remaining := m.QuotaRemaining("openweathermap"); remaining != 999 {
+		t.Errorf("Expected only the server key request to use quota, got %d remaining", remaining)
+	}
+	output := scrape(t, m)
+	if line := `weather_mcp_upstream_requests_total{code="200",endpoint="weather",provider="openweathermap"} 3`; !strings.Contains(output, line) {
+		t.Errorf("Expected tenant requests to still be counted, missing %q", line)
+	}
+}
+
+func TestObserveCacheLookup(t *testing.T) {
+	m := New()
+	m.ObserveCacheLookup("current", true)
+	m.ObserveCacheLookup("current", false)
+	m.ObserveCacheLookup("current", true)
+
+	output := scrape(t, m)
+	for _, line := range []string{
+		`weather_mcp_cache_lookups_total{cache="current",result="hit"} 2`,
+		`weather_mcp_cache_lookups_total{cache="current",result="miss"} 1`,
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected metrics to contain %q", line)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dailyQuota 按UTC自然日统计的上游调用配额
+type dailyQuota struct {
+	limit int
+	day   string
+	used  int
+}
+
+// TrackDailyQuota 为数据源设置每日调用配额，并导出剩余配额指标
+// 上游API不返回剩余配额时，按经过 InstrumentTransport 的请求数在本地统计（不含租户请求，见 WithTenantScope），每个UTC自然日重置。
+func (m *Metrics) TrackDailyQuota(provider string, limit int) {
+	m.mu.Lock()
+	m.quotas[provider] = &dailyQuota{limit: limit}
+	m.mu.Unlock()
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "upstream_quota_remaining",
+		Help:        "Remaining upstream API calls for the current UTC day.",
+		ConstLabels: prometheus.Labels{"provider": provider},
+	}, func() float64 {
+		return float64(m.QuotaRemaining(provider))
+	}))
+}
+
+// QuotaRemaining 获取数据源当天的剩余配额，未设置配额时返回 -1
+func (m *Metrics) QuotaRemaining(provider string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.quotas[provider]
+	if !ok {
+		return -1
+	}
+	q.rollover(m.now())
+	if remaining := q.limit - q.used; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// recordQuotaUsage 记录一次上游调用
+func (m *Metrics) recordQuotaUsage(provider string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if q, ok := m.quotas[provider]; ok {
+		q.rollover(m.now())
+		q.used++
+	}
+}
+
+// rollover 跨过UTC自然日时重置已用次数
+func (q *dailyQuota) rollover(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if q.day != day {
+		q.day = day
+		q.used = 0
+	}
+}
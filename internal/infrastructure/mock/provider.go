@@ -0,0 +1,568 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+	openweather "weather-mcp-server/internal/infrastructure/weather"
+)
+
+// ProviderName 数据源名称
+const ProviderName = "mock"
+
+// maxNearbyDistanceKm 按坐标查询时匹配场景位置的最大距离
+const maxNearbyDistanceKm = 100
+
+// forecastInterval 和 maxForecastPoints 与 OpenWeatherMap 预报接口一致：3小时间隔，最多5天
+const (
+	forecastInterval  = 3 * time.Hour
+	maxForecastPoints = 40
+)
+
+// Provider 场景驱动的模拟天气数据源
+// 实现 WeatherRepository、PlaceLookupRepository、AirQualityRepository、LocationCatalog 和 ProviderProber
+type Provider struct {
+	scenario *Scenario
+	latency  time.Duration
+	jitter   time.Duration
+	step     time.Duration
+
+	mu      sync.Mutex
+	now     time.Time
+	rng     *rand.Rand
+	aliases map[string]*Location
+}
+
+// Option 模拟数据源配置项
+type Option func(*Provider)
+
+// WithLatency 覆盖场景配置的请求延迟和抖动
+func WithLatency(latency, jitter time.Duration) Option {
+	return func(p *Provider) {
+		p.latency, p.jitter = latency, jitter
+	}
+}
+
+// WithStep 覆盖场景配置的每次请求后时钟前进的时长
+func WithStep(step time.Duration) Option {
+	return func(p *Provider) {
+		p.step = step
+	}
+}
+
+// WithStartTime 覆盖场景的开始时间
+func WithStartTime(start time.Time) Option {
+	return func(p *Provider) {
+		p.scenario.Start = start
+	}
+}
+
+// NewProvider 创建模拟天气数据源
+func NewProvider(scenario *Scenario, opts ...Option) *Provider {
+	s := *scenario
+	p := &Provider{
+		scenario: &s,
+		latency:  s.Latency,
+		jitter:   s.Jitter,
+		step:     s.Step,
+		rng:      rand.New(rand.NewSource(s.Seed)),
+		aliases:  make(map[string]*Location),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.now = p.scenario.Start
+	for i := range p.scenario.Locations {
+		l := &p.scenario.Locations[i]
+		for _, name := range append([]string{l.Name}, l.Aliases...) {
+			p.aliases[normalizeName(name)] = l
+		}
+	}
+	return p
+}
+
+// TB 测试辅助函数需要的 testing.TB 子集
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...any)
+}
+
+// Fixture 在测试中创建使用内置场景或场景文件的模拟数据源
+// 默认不模拟延迟，场景加载失败时测试立即失败
+func Fixture(tb TB, scenario string, opts ...Option) *Provider {
+	tb.Helper()
+	s, err := LoadScenario(scenario)
+	if err != nil {
+		tb.Fatalf("failed to load mock scenario: %v", err)
+	}
+	return NewProvider(s, append([]Option{WithLatency(0, 0)}, opts...)...)
+}
+
+// Scenario 获取数据源使用的场景
+func (p *Provider) Scenario() *Scenario {
+	return p.scenario
+}
+
+// Now 获取场景时钟的当前时间
+func (p *Provider) Now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.now
+}
+
+// Advance 将场景时钟前进指定时长
+func (p *Provider) Advance(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.now = p.now.Add(d)
+}
+
+// SetTime 将场景时钟设置为指定时间
+func (p *Provider) SetTime(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.now = t
+}
+
+// request 处理一次请求：确定本次请求的场景时间、模拟延迟并执行故障注入
+// 延迟和故障的随机数按请求顺序生成，相同的请求序列得到相同的结果
+func (p *Provider) request(ctx context.Context, operation string, loc *Location) (time.Time, error) {
+	p.mu.Lock()
+	now := p.now
+	p.now = p.now.Add(p.step)
+	delay := p.latency
+	if p.jitter > 0 {
+		delay += time.Duration(p.rng.Int63n(int64(p.jitter) + 1))
+	}
+	fault := p.matchFault(operation, loc, now)
+	p.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return now, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if fault != nil {
+		return now, &openweather.StatusError{StatusCode: fault.Status, Message: fault.Message}
+	}
+	return now, ctx.Err()
+}
+
+// matchFault 查找对本次请求生效的故障，调用方需持有锁
+func (p *Provider) matchFault(operation string, loc *Location, now time.Time) *Fault {
+	offset := now.Sub(p.scenario.Start)
+	for i := range p.scenario.Faults {
+		f := &p.scenario.Faults[i]
+		if offset < f.From || (f.Until != 0 && offset >= f.Until) {
+			continue
+		}
+		if len(f.Operations) > 0 && !contains(f.Operations, operation) {
+			continue
+		}
+		if len(f.Locations) > 0 && !p.faultCoversLocation(f, loc) {
+			continue
+		}
+		if f.Rate >= 1 || p.rng.Float64() < f.Rate {
+			return f
+		}
+	}
+	return nil
+}
+
+// faultCoversLocation 判断故障是否作用于位置，故障中的位置可以是名称或别名
+func (p *Provider) faultCoversLocation(f *Fault, loc *Location) bool {
+	for _, name := range f.Locations {
+		if p.aliases[normalizeName(name)] == loc {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError 构造与 OpenWeatherMap 客户端相同类型的状态码错误，使调用方走同样的错误处理路径
+func statusError(status int, format string, args ...any) error {
+	return &openweather.StatusError{StatusCode: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// lookupCity 按名称或别名查找场景位置
+func (p *Provider) lookupCity(city string) (*Location, error) {
+	if loc, ok := p.aliases[normalizeName(city)]; ok {
+		return loc, nil
+	}
+	return nil, statusError(http.StatusNotFound, "city not found in scenario %s", p.scenario.Name)
+}
+
+// lookupCityID 按城市ID查找场景位置
+func (p *Provider) lookupCityID(id int64) (*Location, error) {
+	for i := range p.scenario.Locations {
+		if l := &p.scenario.Locations[i]; l.ID != 0 && l.ID == id {
+			return l, nil
+		}
+	}
+	return nil, statusError(http.StatusNotFound, "city ID %d not found in scenario %s", id, p.scenario.Name)
+}
+
+// lookupPostalCode 按邮政编码查找场景位置
+func (p *Provider) lookupPostalCode(code weather.PostalCode) (*Location, error) {
+	key := postalCodeKey(code)
+	for i := range p.scenario.Locations {
+		l := &p.scenario.Locations[i]
+		for _, c := range l.PostalCodes {
+			if postalCodeKey(weather.PostalCode{Code: c, Country: l.Country}) == key {
+				return l, nil
+			}
+		}
+	}
+	return nil, statusError(http.StatusNotFound, "postal code %s not found in scenario %s", code, p.scenario.Name)
+}
+
+// lookupCoords 查找距离坐标最近的场景位置
+func (p *Provider) lookupCoords(lat, lon float64) (*Location, error) {
+	var nearest *Location
+	best := math.Inf(1)
+	for i := range p.scenario.Locations {
+		l := &p.scenario.Locations[i]
+		if d := weather.GreatCircleDistance(lat, lon, l.Lat, l.Lon); d < best {
+			nearest, best = l, d
+		}
+	}
+	if best > maxNearbyDistanceKm {
+		return nil, statusError(http.StatusNotFound, "no scenario location within %dkm of %v,%v", maxNearbyDistanceKm, lat, lon)
+	}
+	return nearest, nil
+}
+
+// GetCurrentWeather 实现 WeatherRepository
+func (p *Provider) GetCurrentWeather(ctx context.Context, lat, lon float64) (*weather.Weather, error) {
+	loc, err := p.lookupCoords(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	return p.current(ctx, loc)
+}
+
+// GetWeatherByCity 实现 WeatherRepository
+func (p *Provider) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	loc, err := p.lookupCity(city)
+	if err != nil {
+		return nil, err
+	}
+	return p.current(ctx, loc)
+}
+
+// GetHourlyWeatherByCoords 实现 WeatherRepository
+func (p *Provider) GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*weather.HourlyWeatherResult, error) {
+	loc, err := p.lookupCoords(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	return p.forecast(ctx, loc, hours)
+}
+
+// GetHourlyWeatherByCity 实现 WeatherRepository
+func (p *Provider) GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*weather.HourlyWeatherResult, error) {
+	loc, err := p.lookupCity(city)
+	if err != nil {
+		return nil, err
+	}
+	return p.forecast(ctx, loc, hours)
+}
+
+// GetWeatherByCityID 实现 PlaceLookupRepository
+func (p *Provider) GetWeatherByCityID(ctx context.Context, id int64) (*weather.Weather, error) {
+	loc, err := p.lookupCityID(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.current(ctx, loc)
+}
+
+// GetHourlyWeatherByCityID 实现 PlaceLookupRepository
+func (p *Provider) GetHourlyWeatherByCityID(ctx context.Context, id int64, hours int) (*weather.HourlyWeatherResult, error) {
+	loc, err := p.lookupCityID(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.forecast(ctx, loc, hours)
+}
+
+// GetWeatherByPostalCode 实现 PlaceLookupRepository
+func (p *Provider) GetWeatherByPostalCode(ctx context.Context, code weather.PostalCode) (*weather.Weather, error) {
+	loc, err := p.lookupPostalCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return p.current(ctx, loc)
+}
+
+// GetHourlyWeatherByPostalCode 实现 PlaceLookupRepository
+func (p *Provider) GetHourlyWeatherByPostalCode(ctx context.Context, code weather.PostalCode, hours int) (*weather.HourlyWeatherResult, error) {
+	loc, err := p.lookupPostalCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return p.forecast(ctx, loc, hours)
+}
+
+// GetAirQuality 实现 AirQualityRepository
+func (p *Provider) GetAirQuality(ctx context.Context, lat, lon float64) (*weather.AirQuality, error) {
+	loc, err := p.lookupCoords(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	now, err := p.request(ctx, OperationAirQuality, loc)
+	if err != nil {
+		return nil, err
+	}
+	f := loc.observe(p.scenario.Start, now)
+	if f.AQI == 0 {
+		return nil, fmt.Errorf("no air pollution data available")
+	}
+	return &weather.AirQuality{
+		AQI:        f.AQI,
+		PM25:       f.PM25,
+		PM10:       f.PM10,
+		O3:         f.O3,
+		NO2:        f.NO2,
+		SO2:        f.SO2,
+		CO:         f.CO,
+		MeasuredAt: now,
+	}, nil
+}
+
+// KnownLocations 实现 LocationCatalog
+func (p *Provider) KnownLocations() []string {
+	names := make([]string, 0, len(p.scenario.Locations))
+	for _, l := range p.scenario.Locations {
+		names = append(names, l.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Probe 实现 ProviderProber，模拟数据源始终可用
+func (p *Provider) Probe(ctx context.Context) weather.ProviderProbe {
+	return weather.ProviderProbe{
+		Provider:   ProviderName,
+		Reachable:  true,
+		KeyValid:   true,
+		StatusCode: http.StatusOK,
+		CheckedAt:  time.Now(),
+	}
+}
+
+// current 生成位置在当前场景时间的实时天气
+func (p *Provider) current(ctx context.Context, loc *Location) (*weather.Weather, error) {
+	now, err := p.request(ctx, OperationCurrent, loc)
+	if err != nil {
+		return nil, err
+	}
+	location := loc.domainLocation(now)
+	f, observedAt, ok := loc.reading(p.scenario.Start, now)
+	if !ok {
+		return nil, statusError(http.StatusServiceUnavailable, "sensor data unavailable")
+	}
+
+	w := &weather.Weather{
+		Location: location,
+		Current: weather.CurrentWeather{
+			Temperature: f.Temperature,
+			FeelsLike:   f.FeelsLike,
+			Humidity:    f.Humidity,
+			Pressure:    f.Pressure,
+			WindSpeed:   f.WindSpeed,
+			WindDir:     windDirection(f.WindDeg),
+			Description: f.Description,
+			Icon:        f.Icon,
+			UVIndex:     f.UVIndex,
+		},
+		LastUpdated: location.LocalTime(observedAt),
+	}
+	for _, a := range loc.Alerts {
+		start, end := p.scenario.Start.Add(a.Start), p.scenario.Start.Add(a.End)
+		if end.After(now) {
+			w.Alerts = append(w.Alerts, weather.Alert{
+				Event:       a.Event,
+				Sender:      a.Sender,
+				Start:       location.LocalTime(start),
+				End:         location.LocalTime(end),
+				Description: a.Description,
+			})
+		}
+	}
+	return w, nil
+}
+
+// forecast 生成位置从当前场景时间开始的3小时间隔预报
+// 与 OpenWeatherMap 一致，数据点从下一个整3小时（UTC）开始，请求 hours 小时返回 ceil(hours/3) 个数据点
+func (p *Provider) forecast(ctx context.Context, loc *Location, hours int) (*weather.HourlyWeatherResult, error) {
+	now, err := p.request(ctx, OperationForecast, loc)
+	if err != nil {
+		return nil, err
+	}
+	location := loc.domainLocation(now)
+
+	points := (hours + 2) / 3
+	if points > maxForecastPoints {
+		points = maxForecastPoints
+	}
+	first := now.Truncate(forecastInterval).Add(forecastInterval)
+	hourly := make([]weather.HourlyWeather, 0, max(points, 0))
+	for i := 0; i < points; i++ {
+		at := first.Add(time.Duration(i) * forecastInterval)
+		f := loc.observe(p.scenario.Start, at)
+		// 插值得到的降水量与关键帧的降水类型可能不一致，以降水量为准
+		precipType := f.PrecipType
+		switch {
+		case f.PrecipAmount == 0:
+			precipType = weather.PrecipitationNone
+		case precipType == "" || precipType == weather.PrecipitationNone:
+			precipType = weather.PrecipitationRain
+		}
+		hourly = append(hourly, weather.HourlyWeather{
+			Date:              location.LocalTime(at),
+			Temperature:       f.Temperature,
+			FeelsLike:         f.FeelsLike,
+			Humidity:          f.Humidity,
+			Pressure:          f.Pressure,
+			WindSpeed:         f.WindSpeed,
+			WindDir:           windDirection(f.WindDeg),
+			Description:       f.Description,
+			Icon:              f.Icon,
+			PrecipProbability: f.PrecipProbability,
+			PrecipAmount:      f.PrecipAmount,
+			PrecipType:        precipType,
+			Visibility:        f.Visibility,
+		})
+	}
+	return &weather.HourlyWeatherResult{
+		Location:    location,
+		Hourly:      hourly,
+		LastUpdated: location.LocalTime(now),
+	}, nil
+}
+
+// domainLocation 转换为领域位置，UTC偏移按查询时间计算
+func (l *Location) domainLocation(at time.Time) weather.Location {
+	location := weather.Location{
+		ID:       l.ID,
+		City:     l.Name,
+		Country:  l.Country,
+		Lat:      l.Lat,
+		Lon:      l.Lon,
+		Timezone: l.Timezone,
+	}
+	_, location.UTCOffset = location.LocalTime(at).Zone()
+	return location
+}
+
+// reading 获取传感器在指定时间的读数及读数时间
+// 处于传感器中断时返回中断前的最后一次读数；场景从中断开始时没有可用读数
+func (l *Location) reading(start, at time.Time) (Frame, time.Time, bool) {
+	offset := at.Sub(start)
+	i := l.frameIndex(offset)
+	if i < 0 || !l.Frames[i].Dropout {
+		return l.observe(start, at), at, true
+	}
+	for i >= 0 && l.Frames[i].Dropout {
+		i--
+	}
+	if i < 0 {
+		return Frame{}, time.Time{}, false
+	}
+	// 最后一次读数在中断开始的时刻
+	lastAt := start.Add(l.Frames[i+1].At)
+	return l.observe(start, lastAt), lastAt, true
+}
+
+// frameIndex 获取时间偏移所在的关键帧序号，早于第一帧时返回 -1
+func (l *Location) frameIndex(offset time.Duration) int {
+	return sort.Search(len(l.Frames), func(i int) bool { return l.Frames[i].At > offset }) - 1
+}
+
+// observe 计算指定时间的天气，在相邻关键帧之间线性插值
+// 早于第一帧或晚于最后一帧时保持首尾关键帧的值
+func (l *Location) observe(start, at time.Time) Frame {
+	offset := at.Sub(start)
+	i := l.frameIndex(offset)
+	if i < 0 {
+		return l.Frames[0]
+	}
+	if i == len(l.Frames)-1 {
+		return l.Frames[i]
+	}
+	a, b := l.Frames[i], l.Frames[i+1]
+	ratio := float64(offset-a.At) / float64(b.At-a.At)
+	return interpolate(a, b, ratio)
+}
+
+// interpolate 在两个关键帧之间插值，文本字段使用前一帧的值
+func interpolate(a, b Frame, ratio float64) Frame {
+	f := a
+	f.Temperature = lerp(a.Temperature, b.Temperature, ratio)
+	f.FeelsLike = lerp(a.FeelsLike, b.FeelsLike, ratio)
+	f.Humidity = lerpInt(a.Humidity, b.Humidity, ratio)
+	f.Pressure = lerpInt(a.Pressure, b.Pressure, ratio)
+	f.WindSpeed = lerp(a.WindSpeed, b.WindSpeed, ratio)
+	f.WindDeg = lerpAngle(a.WindDeg, b.WindDeg, ratio)
+	f.PrecipProbability = lerp(a.PrecipProbability, b.PrecipProbability, ratio)
+	f.PrecipAmount = lerp(a.PrecipAmount, b.PrecipAmount, ratio)
+	if a.Visibility != nil && b.Visibility != nil {
+		visibility := lerpInt(*a.Visibility, *b.Visibility, ratio)
+		f.Visibility = &visibility
+	}
+	if a.UVIndex != nil && b.UVIndex != nil {
+		uv := lerp(*a.UVIndex, *b.UVIndex, ratio)
+		f.UVIndex = &uv
+	}
+	f.AQI = lerpInt(a.AQI, b.AQI, ratio)
+	f.PM25 = lerp(a.PM25, b.PM25, ratio)
+	f.PM10 = lerp(a.PM10, b.PM10, ratio)
+	f.O3 = lerp(a.O3, b.O3, ratio)
+	f.NO2 = lerp(a.NO2, b.NO2, ratio)
+	f.SO2 = lerp(a.SO2, b.SO2, ratio)
+	f.CO = lerp(a.CO, b.CO, ratio)
+	return f
+}
+
+// lerp 线性插值，结果保留两位小数使输出稳定易读
+func lerp(a, b, ratio float64) float64 {
+	return math.Round((a+(b-a)*ratio)*100) / 100
+}
+
+func lerpInt(a, b int, ratio float64) int {
+	return int(math.Round(float64(a) + float64(b-a)*ratio))
+}
+
+// lerpAngle 沿较短的方向在两个风向角度之间插值
+func lerpAngle(a, b int, ratio float64) int {
+	diff := ((b-a)%360+540)%360 - 180
+	return (int(math.Round(float64(a)+float64(diff)*ratio))%360 + 360) % 360
+}
+
+// windDirection 根据角度获取风向
+func windDirection(deg int) string {
+	directions := []string{"北", "东北", "东", "东南", "南", "西南", "西", "西北"}
+	deg = (deg%360 + 360) % 360
+	return directions[(deg+22)/45%8]
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+	openweather "weather-mcp-server/internal/infrastructure/weather"
+)
+
+func TestTyphoonScenarioTimeline(t *testing.T) {
+	p := Fixture(t, "typhoon-xiamen")
+	ctx := context.Background()
+
+	tests := []struct {
+		offset      time.Duration
+		temperature float64
+		pressure    int
+		windSpeed   float64
+		windDir     string
+		description string
+		alerts      []string
+	}{
+		{0, 30.5, 1004, 5.5, "东", "多云", []string{"台风蓝色预警", "台风橙色预警", "台风红色预警"}},
+		// 关键帧之间线性插值，文本沿用前一帧
+		{6 * time.Hour, 29.25, 1000, 9.65, "东", "多云", []string{"台风蓝色预警", "台风橙色预警", "台风红色预警"}},
+		{30 * time.Hour, 25.8, 962, 42.6, "北", "大暴雨", []string{"台风红色预警"}},
+		// 超出最后一帧保持最后一帧的值，预警全部结束
+		{72 * time.Hour, 28.5, 998, 8.2, "西南", "阵雨", nil},
+	}
+
+	for _, tt := range tests {
+		p.SetTime(p.Scenario().Start.Add(tt.offset))
+		w, err := p.GetWeatherByCity(ctx, "xiamen")
+		if err != nil {
+			t.Fatalf("At %v: expected no error, got %v", tt.offset, err)
+		}
+		c := w.Current
+		if c.Temperature != tt.temperature || c.Pressure != tt.pressure || c.WindSpeed != tt.windSpeed || c.WindDir != tt.windDir || c.Description != tt.description {
+			t.Errorf("At %v: expected %v°C %dhPa %vm/s %s %s, got %v°C %dhPa %vm/s %s %s", tt.offset,
+				tt.temperature, tt.pressure, tt.windSpeed, tt.windDir, tt.description,
+				c.Temperature, c.Pressure, c.WindSpeed, c.WindDir, c.Description)
+		}
+		var alerts []string
+		for _, a := range w.Alerts {
+			alerts = append(alerts, a.Event)
+		}
+		if strings.Join(alerts, ",") != strings.Join(tt.alerts, ",") {
+			t.Errorf("At %v: expected alerts %v, got %v", tt.offset, tt.alerts, alerts)
+		}
+		if w.Location.City != "厦门" || w.Location.UTCOffset != 8*3600 {
+			t.Errorf("Expected 厦门 at UTC+8, got %s at %d", w.Location.City, w.Location.UTCOffset)
+		}
+		if !w.LastUpdated.Equal(p.Now()) {
+			t.Errorf("Expected last updated at scenario time %v, got %v", p.Now(), w.LastUpdated)
+		}
+	}
+}
+
+func TestLookupByCoordinates(t *testing.T) {
+	p := Fixture(t, "typhoon-xiamen")
+	ctx := context.Background()
+
+	w, err := p.GetCurrentWeather(ctx, 24.88, 118.67)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if w.Location.City != "泉州" {
+		t.Errorf("Expected nearest location 泉州, got %s", w.Location.City)
+	}
+
+	if _, err := p.GetCurrentWeather(ctx, 39.9042, 116.4074); !hasStatus(err, 404) {
+		t.Errorf("Expected 404 error for location outside the scenario, got %v", err)
+	}
+	if _, err := p.GetWeatherByCity(ctx, "北京"); !hasStatus(err, 404) {
+		t.Errorf("Expected 404 error for unknown city, got %v", err)
+	}
+}
+
+func TestLookupByCityIDAndPostalCode(t *testing.T) {
+	p := Fixture(t, "typhoon-xiamen")
+	ctx := context.Background()
+
+	byID, err := p.GetWeatherByCityID(ctx, 1797353)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if byID.Location.City != "泉州" || byID.Location.ID != 1797353 {
+		t.Errorf("Expected 泉州 with ID 1797353, got %s with ID %d", byID.Location.City, byID.Location.ID)
+	}
+	byCode, err := p.GetHourlyWeatherByPostalCode(ctx, weather.PostalCode{Code: "361000", Country: "cn"}, 6)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if byCode.Location.City != "厦门" || byCode.Location.ID != 1790645 {
+		t.Errorf("Expected 厦门 with ID 1790645, got %s with ID %d", byCode.Location.City, byCode.Location.ID)
+	}
+
+	if _, err := p.GetWeatherByCityID(ctx, 1816670); !hasStatus(err, 404) {
+		t.Errorf("Expected 404 error for unknown city ID, got %v", err)
+	}
+	if _, err := p.GetWeatherByPostalCode(ctx, weather.PostalCode{Code: "361000", Country: "US"}); !hasStatus(err, 404) {
+		t.Errorf("Expected 404 error for postal code in another country, got %v", err)
+	}
+}
+
+func TestForecast(t *testing.T) {
+	p := Fixture(t, "typhoon-xiamen")
+	ctx := context.Background()
+
+	tests := []struct {
+		hours    int
+		expected int
+	}{
+		{1, 1},
+		{24, 8},
+		{500, maxForecastPoints},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		hw, err := p.GetHourlyWeatherByCity(ctx, "厦门", tt.hours)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(hw.Hourly) != tt.expected {
+			t.Errorf("For %d hours, expected %d points, got %d", tt.hours, tt.expected, len(hw.Hourly))
+		}
+	}
+
+	hw, _ := p.GetHourlyWeatherByCoords(ctx, 24.4798, 118.0894, 48)
+	// 场景从 08:00（UTC 00:00）开始，第一个数据点为下一个整3小时
+	first := time.Date(2024, 9, 14, 11, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	if !hw.Hourly[0].Date.Equal(first) {
+		t.Errorf("Expected first point at %v, got %v", first, hw.Hourly[0].Date)
+	}
+	for i := 1; i < len(hw.Hourly); i++ {
+		if gap := hw.Hourly[i].Date.Sub(hw.Hourly[i-1].Date); gap != 3*time.Hour {
+			t.Errorf("Expected 3h between points, got %v", gap)
+		}
+	}
+	// 登陆前后降水量最大
+	peak := hw.Hourly[0]
+	for _, h := range hw.Hourly {
+		// 降水类型与插值得到的降水量一致
+		if (h.PrecipAmount > 0) == (h.PrecipType == weather.PrecipitationNone) {
+			t.Errorf("At %v: precipitation type %s does not match amount %v", h.Date, h.PrecipType, h.PrecipAmount)
+		}
+		if h.PrecipAmount > peak.PrecipAmount {
+			peak = h
+		}
+	}
+	if peak.PrecipType != weather.PrecipitationRain || peak.PrecipAmount < 80 {
+		t.Errorf("Expected heavy rain peak, got %s %v", peak.PrecipType, peak.PrecipAmount)
+	}
+}
+
+func TestSensorDropout(t *testing.T) {
+	s, err := ParseScenario([]byte(minimalScenario))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProvider(s)
+	ctx := context.Background()
+
+	tests := []struct {
+		offset      time.Duration
+		temperature float64
+		observedAt  time.Duration
+	}{
+		{3 * time.Hour, 13, 3 * time.Hour},
+		// 中断期间停留在中断开始时的读数
+		{9 * time.Hour, 16, 6 * time.Hour},
+		{12 * time.Hour, 16, 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		p.SetTime(s.Start.Add(tt.offset))
+		w, err := p.GetWeatherByCity(ctx, "Testville")
+		if err != nil {
+			t.Fatalf("At %v: expected no error, got %v", tt.offset, err)
+		}
+		if w.Current.Temperature != tt.temperature {
+			t.Errorf("At %v: expected temperature %v, got %v", tt.offset, tt.temperature, w.Current.Temperature)
+		}
+		if expected := s.Start.Add(tt.observedAt); !w.LastUpdated.Equal(expected) {
+			t.Errorf("At %v: expected reading from %v, got %v", tt.offset, expected, w.LastUpdated)
+		}
+	}
+
+	// 场景从中断开始时没有可用读数，但预报不受影响
+	dropout := Fixture(t, "sensor-dropout")
+	if _, err := dropout.GetCurrentWeather(ctx, 23.1291, 113.2644); !hasStatus(err, 503) {
+		t.Errorf("Expected 503 error while the station is offline, got %v", err)
+	}
+}
+
+func TestFaultInjectionIsDeterministic(t *testing.T) {
+	run := func() string {
+		p := Fixture(t, "sensor-dropout")
+		p.Advance(3 * time.Hour)
+		var results []string
+		for i := 0; i < 20; i++ {
+			if _, err := p.GetWeatherByCity(context.Background(), "深圳"); err != nil {
+				var statusErr *openweather.StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != 503 || statusErr.Message != "station offline" {
+					t.Errorf("Expected injected 503 error, got %v", err)
+				}
+				results = append(results, "E")
+			} else {
+				results = append(results, "ok")
+			}
+		}
+		return strings.Join(results, ",")
+	}
+
+	first := run()
+	if second := run(); first != second {
+		t.Errorf("Expected identical fault sequence, got %s and %s", first, second)
+	}
+	if !strings.Contains(first, "E") || !strings.Contains(first, "ok") {
+		t.Errorf("Expected a mix of failures and successes at rate 0.5, got %s", first)
+	}
+
+	// 故障窗口之外不注入故障
+	p := Fixture(t, "sensor-dropout")
+	for i := 0; i < 10; i++ {
+		if _, err := p.GetWeatherByCity(context.Background(), "深圳"); err != nil {
+			t.Errorf("Expected no error outside the fault window, got %v", err)
+		}
+	}
+}
+
+func TestStepAndLatency(t *testing.T) {
+	p := Fixture(t, "heatwave", WithStep(time.Hour))
+	start := p.Now()
+	for i := 0; i < 3; i++ {
+		p.GetWeatherByCity(context.Background(), "重庆")
+	}
+	if elapsed := p.Now().Sub(start); elapsed != 3*time.Hour {
+		t.Errorf("Expected clock to advance 3h after 3 requests, got %v", elapsed)
+	}
+
+	slow := Fixture(t, "heatwave", WithLatency(time.Minute, 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := slow.GetWeatherByCity(ctx, "重庆"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded during simulated latency, got %v", err)
+	}
+}
+
+func TestAirQuality(t *testing.T) {
+	p := Fixture(t, "heatwave")
+	p.Advance(7 * time.Hour)
+
+	aq, err := p.GetAirQuality(context.Background(), 29.563, 106.5516)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if aq.AQI != 4 || aq.O3 != 205 {
+		t.Errorf("Expected AQI 4 with ozone 205, got %d %v", aq.AQI, aq.O3)
+	}
+
+	typhoon := Fixture(t, "typhoon-xiamen")
+	if _, err := typhoon.GetAirQuality(context.Background(), 24.8741, 118.6757); err == nil {
+		t.Error("Expected error for location without air quality data")
+	}
+}
+
+func TestWindDirectionNormalizesAngles(t *testing.T) {
+	tests := map[int]string{0: "北", 90: "东", 338: "北", 359: "北", -90: "西", 450: "东"}
+	for deg, want := range tests {
+		if got := windDirection(deg); got != want {
+			t.Errorf("windDirection(%d) = %q, want %q", deg, got, want)
+		}
+	}
+}
+
+// hasStatus 判断错误是否为指定状态码的 *openweather.StatusError
+func hasStatus(err error, status int) bool {
+	var statusErr *openweather.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == status
+}
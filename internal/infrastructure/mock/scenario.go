@@ -0,0 +1,303 @@
+// Package mock 提供由脚本化场景驱动的模拟天气数据源
+// 场景以YAML描述若干位置随时间变化的天气关键帧、预警、传感器中断和故障注入，
+// 数据源使用确定的场景时钟，无需网络和API密钥即可得到可重复的天气数据，供开发调试和测试使用。
+package mock
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultScenario 未指定场景时使用的内置场景
+const DefaultScenario = "typhoon-xiamen"
+
+//go:embed scenarios/*.yaml
+var builtinScenarios embed.FS
+
+// 可注入故障的操作
+const (
+	OperationCurrent    = "current"
+	OperationForecast   = "forecast"
+	OperationAirQuality = "air_quality"
+)
+
+// Scenario 模拟天气场景
+type Scenario struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Start 场景开始时间，数据源的时钟从该时间开始
+	Start time.Time `yaml:"start"`
+	// Step 每次请求后场景时钟前进的时长，为0时时间静止
+	Step time.Duration `yaml:"step"`
+	// Latency 和 Jitter 模拟的请求延迟及随机抖动
+	Latency time.Duration `yaml:"latency"`
+	Jitter  time.Duration `yaml:"jitter"`
+	// Seed 抖动和故障注入使用的随机数种子，相同的请求序列得到相同的结果
+	Seed      int64      `yaml:"seed"`
+	Locations []Location `yaml:"locations"`
+	Faults    []Fault    `yaml:"faults"`
+}
+
+// Location 场景中的位置及其天气关键帧
+type Location struct {
+	// ID 模拟的数据源城市ID，为0时不支持按城市ID查询
+	ID      int64    `yaml:"id"`
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+	Country string   `yaml:"country"`
+	// PostalCodes 可用于查询该位置的邮政编码，国家为 Country
+	PostalCodes []string `yaml:"postal_codes"`
+	Lat         float64  `yaml:"lat"`
+	Lon         float64  `yaml:"lon"`
+	Timezone    string   `yaml:"timezone"`
+	Frames      Frames   `yaml:"frames"`
+	Alerts      []Alert  `yaml:"alerts"`
+}
+
+// Frame 相对场景开始时间的天气关键帧
+// 关键帧之间的数值线性插值；未填写的字段沿用上一帧的值
+type Frame struct {
+	At time.Duration `yaml:"at"`
+	// Dropout 传感器中断：直到下一个正常关键帧之前，实时天气停留在中断前的最后一次读数
+	Dropout bool `yaml:"dropout"`
+
+	Temperature       float64                   `yaml:"temperature"`
+	FeelsLike         float64                   `yaml:"feels_like"`
+	Humidity          int                       `yaml:"humidity"`
+	Pressure          int                       `yaml:"pressure"`
+	WindSpeed         float64                   `yaml:"wind_speed"`
+	WindDeg           int                       `yaml:"wind_deg"`
+	Description       string                    `yaml:"description"`
+	Icon              string                    `yaml:"icon"`
+	PrecipProbability float64                   `yaml:"precip_probability"`
+	PrecipAmount      float64                   `yaml:"precip_amount"` // 每3小时降水量 (mm)
+	PrecipType        weather.PrecipitationType `yaml:"precip_type"`
+	Visibility        *int                      `yaml:"visibility"`
+	UVIndex           *float64                  `yaml:"uv_index"`
+
+	AQI  int     `yaml:"aqi"`
+	PM25 float64 `yaml:"pm25"`
+	PM10 float64 `yaml:"pm10"`
+	O3   float64 `yaml:"o3"`
+	NO2  float64 `yaml:"no2"`
+	SO2  float64 `yaml:"so2"`
+	CO   float64 `yaml:"co"`
+}
+
+// Frames 关键帧列表，解析时每一帧继承上一帧未填写的字段
+type Frames []Frame
+
+// UnmarshalYAML 实现 yaml.Unmarshaler
+func (fs *Frames) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: frames must be a list", value.Line)
+	}
+	var prev Frame
+	frames := make(Frames, 0, len(value.Content))
+	for _, node := range value.Content {
+		frame := prev
+		frame.Dropout = false
+		// 复制指针字段，避免解析本帧时改写上一帧的值
+		if prev.UVIndex != nil {
+			uv := *prev.UVIndex
+			frame.UVIndex = &uv
+		}
+		if err := decodeStrict(node, &frame); err != nil {
+			return err
+		}
+		frames = append(frames, frame)
+		prev = frame
+	}
+	*fs = frames
+	return nil
+}
+
+// decodeStrict 解析节点并拒绝未知字段，避免关键帧中的拼写错误被静默忽略
+func decodeStrict(node *yaml.Node, out any) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("frame at line %d: %w", node.Line, err)
+	}
+	return nil
+}
+
+// Alert 场景中的气象预警，时间相对场景开始时间
+type Alert struct {
+	Event       string        `yaml:"event"`
+	Sender      string        `yaml:"sender"`
+	Start       time.Duration `yaml:"start"`
+	End         time.Duration `yaml:"end"`
+	Description string        `yaml:"description"`
+}
+
+// Fault 故障注入规则
+type Fault struct {
+	// Operations 和 Locations 限定故障作用的操作和位置，为空时作用于全部
+	Operations []string `yaml:"operations"`
+	Locations  []string `yaml:"locations"`
+	// From 和 Until 限定故障生效的时间窗口（相对场景开始时间），Until 为0时一直生效
+	From  time.Duration `yaml:"from"`
+	Until time.Duration `yaml:"until"`
+	// Rate 请求失败的概率 (0-1]
+	Rate    float64 `yaml:"rate"`
+	Status  int     `yaml:"status"`
+	Message string  `yaml:"message"`
+}
+
+// ScenarioNames 获取内置场景名称
+func ScenarioNames() []string {
+	entries, _ := builtinScenarios.ReadDir("scenarios")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadScenario 加载场景：可以是内置场景名称或YAML文件路径，为空时使用默认场景
+func LoadScenario(nameOrPath string) (*Scenario, error) {
+	if nameOrPath == "" {
+		nameOrPath = DefaultScenario
+	}
+	data, err := builtinScenarios.ReadFile("scenarios/" + nameOrPath + ".yaml")
+	if err != nil {
+		data, err = os.ReadFile(nameOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("unknown scenario %q (built-in: %s): %w", nameOrPath, strings.Join(ScenarioNames(), ", "), err)
+		}
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario 解析并校验YAML场景
+func ParseScenario(data []byte) (*Scenario, error) {
+	var s Scenario
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %q: %w", s.Name, err)
+	}
+	return &s, nil
+}
+
+// validate 校验场景内容
+func (s *Scenario) validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if s.Start.IsZero() {
+		return errors.New("start is required")
+	}
+	if s.Step < 0 || s.Latency < 0 || s.Jitter < 0 {
+		return errors.New("step, latency and jitter must not be negative")
+	}
+	if len(s.Locations) == 0 {
+		return errors.New("at least one location is required")
+	}
+
+	names := make(map[string]bool)
+	ids := make(map[int64]bool)
+	postalCodes := make(map[string]bool)
+	for _, l := range s.Locations {
+		if l.Name == "" {
+			return errors.New("location name is required")
+		}
+		if l.ID < 0 || (l.ID != 0 && ids[l.ID]) {
+			return fmt.Errorf("location %s: id must be positive and unique", l.Name)
+		}
+		ids[l.ID] = true
+		for _, code := range l.PostalCodes {
+			key := postalCodeKey(weather.PostalCode{Code: code, Country: l.Country})
+			if postalCodes[key] {
+				return fmt.Errorf("duplicate postal code %q", code)
+			}
+			postalCodes[key] = true
+		}
+		for _, name := range append([]string{l.Name}, l.Aliases...) {
+			key := normalizeName(name)
+			if names[key] {
+				return fmt.Errorf("duplicate location name %q", name)
+			}
+			names[key] = true
+		}
+		if l.Lat < -90 || l.Lat > 90 || l.Lon < -180 || l.Lon > 180 {
+			return fmt.Errorf("location %s: invalid coordinates %v,%v", l.Name, l.Lat, l.Lon)
+		}
+		if l.Timezone != "" {
+			if _, err := time.LoadLocation(l.Timezone); err != nil {
+				return fmt.Errorf("location %s: invalid timezone: %w", l.Name, err)
+			}
+		}
+		if len(l.Frames) == 0 {
+			return fmt.Errorf("location %s: at least one frame is required", l.Name)
+		}
+		for i := 1; i < len(l.Frames); i++ {
+			if l.Frames[i].At <= l.Frames[i-1].At {
+				return fmt.Errorf("location %s: frames must be in increasing order of at", l.Name)
+			}
+		}
+		for _, f := range l.Frames {
+			if f.WindDeg < 0 || f.WindDeg > 359 {
+				return fmt.Errorf("location %s: wind_deg %d at %s must be in [0, 359]", l.Name, f.WindDeg, f.At)
+			}
+		}
+		for _, a := range l.Alerts {
+			if a.Event == "" || a.End <= a.Start {
+				return fmt.Errorf("location %s: alert requires an event and end after start", l.Name)
+			}
+		}
+	}
+
+	for i, f := range s.Faults {
+		if f.Rate <= 0 || f.Rate > 1 {
+			return fmt.Errorf("fault %d: rate must be in (0, 1]", i)
+		}
+		if f.Status < 400 || f.Status > 599 {
+			return fmt.Errorf("fault %d: status must be an HTTP error status", i)
+		}
+		if f.Until != 0 && f.Until <= f.From {
+			return fmt.Errorf("fault %d: until must be after from", i)
+		}
+		for _, op := range f.Operations {
+			if op != OperationCurrent && op != OperationForecast && op != OperationAirQuality {
+				return fmt.Errorf("fault %d: unknown operation %q", i, op)
+			}
+		}
+		for _, name := range f.Locations {
+			if !names[normalizeName(name)] {
+				return fmt.Errorf("fault %d: unknown location %q", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// postalCodeKey 规范化邮政编码用于匹配
+func postalCodeKey(code weather.PostalCode) string {
+	return strings.ToUpper(strings.TrimSpace(code.Code) + "," + strings.TrimSpace(code.Country))
+}
+
+// normalizeName 规范化位置名称用于匹配
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,132 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinScenarios(t *testing.T) {
+	names := ScenarioNames()
+	expected := []string{"heatwave", "sensor-dropout", "typhoon-xiamen"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected scenarios %v, got %v", expected, names)
+	}
+	for _, name := range names {
+		s, err := LoadScenario(name)
+		if err != nil {
+			t.Errorf("Expected built-in scenario %s to load, got %v", name, err)
+			continue
+		}
+		if s.Name != name {
+			t.Errorf("Expected scenario name %s, got %s", name, s.Name)
+		}
+	}
+
+	s, err := LoadScenario("")
+	if err != nil || s.Name != DefaultScenario {
+		t.Errorf("Expected default scenario %s, got %v (%v)", DefaultScenario, s, err)
+	}
+	if _, err := LoadScenario("blizzard"); err == nil {
+		t.Error("Expected error for unknown scenario")
+	}
+}
+
+func TestLoadScenarioFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom.yaml")
+	if err := os.WriteFile(path, []byte(minimalScenario), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Name != "minimal" || len(s.Locations) != 1 {
+		t.Errorf("Expected minimal scenario with 1 location, got %s with %d", s.Name, len(s.Locations))
+	}
+}
+
+const minimalScenario = `
+name: minimal
+start: 2024-01-01T00:00:00Z
+locations:
+  - name: Testville
+    lat: 10
+    lon: 20
+    frames:
+      - at: 0h
+        temperature: 10
+        humidity: 50
+        description: 晴
+        uv_index: 5
+      - at: 6h
+        temperature: 16
+        dropout: true
+      - at: 12h
+        humidity: 70
+        uv_index: 1
+`
+
+func TestFramesInheritPreviousValues(t *testing.T) {
+	s, err := ParseScenario([]byte(minimalScenario))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	frames := s.Locations[0].Frames
+	tests := []struct {
+		at          time.Duration
+		temperature float64
+		humidity    int
+		description string
+		uvIndex     float64
+		dropout     bool
+	}{
+		{0, 10, 50, "晴", 5, false},
+		{6 * time.Hour, 16, 50, "晴", 5, true},
+		{12 * time.Hour, 16, 70, "晴", 1, false},
+	}
+	for i, tt := range tests {
+		f := frames[i]
+		if f.At != tt.at || f.Temperature != tt.temperature || f.Humidity != tt.humidity || f.Description != tt.description || *f.UVIndex != tt.uvIndex || f.Dropout != tt.dropout {
+			t.Errorf("Frame %d: expected %+v, got at=%v temp=%v humidity=%d desc=%s uv=%v dropout=%v",
+				i, tt, f.At, f.Temperature, f.Humidity, f.Description, *f.UVIndex, f.Dropout)
+		}
+	}
+}
+
+func TestParseScenarioValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+		errText string
+	}{
+		{"unknown field", [2]string{"humidity: 50", "humidty: 50"}, "humidty"},
+		{"missing name", [2]string{"name: minimal", "description: x"}, "name is required"},
+		{"frames out of order", [2]string{"at: 12h", "at: 3h"}, "increasing order"},
+		{"invalid coordinates", [2]string{"lat: 10", "lat: 100"}, "invalid coordinates"},
+		{"negative wind direction", [2]string{"humidity: 50", "humidity: 50\n        wind_deg: -90"}, "wind_deg"},
+		{"wind direction out of range", [2]string{"humidity: 70", "humidity: 70\n        wind_deg: 360"}, "wind_deg"},
+		{"invalid timezone", [2]string{"lon: 20", "lon: 20\n    timezone: Mars/Olympus"}, "invalid timezone"},
+		{"fault rate", [2]string{"locations:", "faults:\n  - rate: 2\n    status: 503\nlocations:"}, "rate"},
+		{"fault status", [2]string{"locations:", "faults:\n  - rate: 1\n    status: 200\nlocations:"}, "status"},
+		{"fault operation", [2]string{"locations:", "faults:\n  - rate: 1\n    status: 503\n    operations: [hourly]\nlocations:"}, "unknown operation"},
+		{"fault location", [2]string{"locations:", "faults:\n  - rate: 1\n    status: 503\n    locations: [Nowhere]\nlocations:"}, "unknown location"},
+		{"negative id", [2]string{"lat: 10", "id: -1\n    lat: 10"}, "id must be positive"},
+		{"duplicate postal code", [2]string{"lat: 10", "postal_codes: [\"100000\", \"100000\"]\n    lat: 10"}, "duplicate postal code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := strings.Replace(minimalScenario, tt.replace[0], tt.replace[1], 1)
+			_, err := ParseScenario([]byte(data))
+			if err == nil {
+				t.Fatalf("Expected error containing %q", tt.errText)
+			}
+			if !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("Expected error containing %q, got %v", tt.errText, err)
+			}
+		})
+	}
+}
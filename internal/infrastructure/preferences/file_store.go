@@ -0,0 +1,83 @@
+package preferences
+
+import (
+	"sync"
+
+	"weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/storage"
+)
+
+// FileStore 基于JSON文件的用户偏好设置存储
+// path 为空时仅保存在内存中，进程退出后丢失
+type FileStore struct {
+	path  string
+	mu    sync.RWMutex
+	users map[string]*weather.Preferences
+}
+
+// NewFileStore 创建偏好设置存储，文件存在时加载已有数据
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:  path,
+		users: make(map[string]*weather.Preferences),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	if _, err := storage.ReadJSONFile(path, &s.users); err != nil {
+		return nil, err
+	}
+	if s.users == nil {
+		s.users = make(map[string]*weather.Preferences)
+	}
+	return s, nil
+}
+
+// GetPreferences 获取用户偏好设置的副本，不存在时返回 nil
+func (s *FileStore) GetPreferences(user string) (*weather.Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return clonePreferences(s.users[user]), nil
+}
+
+// SavePreferences 保存用户偏好设置并写入文件
+func (s *FileStore) SavePreferences(user string, prefs *weather.Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.users[user]
+	s.users[user] = clonePreferences(prefs)
+	if err := s.flush(); err != nil {
+		if existed {
+			s.users[user] = previous
+		} else {
+			delete(s.users, user)
+		}
+		return err
+	}
+	return nil
+}
+
+// flush 将所有用户的偏好设置写入文件
+func (s *FileStore) flush() error {
+	if s.path == "" {
+		return nil
+	}
+	return storage.WriteJSONFile(s.path, s.users)
+}
+
+// clonePreferences 深拷贝偏好设置，避免调用方修改存储中的数据
+func clonePreferences(p *weather.Preferences) *weather.Preferences {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	if p.Locations != nil {
+		c.Locations = make(map[string]string, len(p.Locations))
+		for k, v := range p.Locations {
+			c.Locations[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,65 @@
+package preferences
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestFileStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	prefs := &weather.Preferences{
+		Locations:    map[string]string{"home": "北京海淀"},
+		Units:        weather.UnitsImperial,
+		DefaultHours: 6,
+	}
+	if err := store.SavePreferences("client:claude", prefs); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 修改调用方的副本不影响已保存的数据
+	prefs.Locations["home"] = "上海"
+
+	reloaded, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, _ := reloaded.GetPreferences("client:claude")
+	if got == nil || got.Locations["home"] != "北京海淀" || got.Units != weather.UnitsImperial || got.DefaultHours != 6 {
+		t.Errorf("Expected saved preferences after reload, got %+v", got)
+	}
+	if other, _ := reloaded.GetPreferences("client:other"); other != nil {
+		t.Errorf("Expected nil preferences for unknown user, got %+v", other)
+	}
+}
+
+func TestFileStoreInMemory(t *testing.T) {
+	store, err := NewFileStore("")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.SavePreferences("stdio", &weather.Preferences{Language: "en"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, _ := store.GetPreferences("stdio")
+	if got == nil || got.Language != "en" {
+		t.Errorf("Expected in-memory preferences, got %+v", got)
+	}
+}
+
+func TestNewFileStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.json")
+	if err := os.WriteFile(path, []byte("{invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path); err == nil {
+		t.Error("Expected error for invalid file, got nil")
+	}
+}
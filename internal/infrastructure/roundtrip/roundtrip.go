@@ -0,0 +1,13 @@
+// Package roundtrip 提供包装上游HTTP传输层的公共类型
+// 指标、追踪和健康监测都以包装 http.RoundTripper 的方式接入上游请求。
+package roundtrip
+
+import "net/http"
+
+// Func 函数形式的 http.RoundTripper
+type Func func(*http.Request) (*http.Response, error)
+
+// RoundTrip 实现 http.RoundTripper
+func (f Func) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+
+	"weather-mcp-server/internal/infrastructure/logging"
+	"weather-mcp-server/internal/infrastructure/roundtrip"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// span 属性名
+const (
+	AttrTool     = attribute.Key("mcp.tool.name")
+	AttrLocation = attribute.Key("weather.location")
+	AttrProvider = attribute.Key("weather.provider")
+	AttrEndpoint = attribute.Key("weather.endpoint")
+)
+
+// ToolMiddleware 为每次工具调用创建根 span
+// 应作为最外层的中间件注册，使根 span 覆盖指标、认证和配额等所有中间件；
+// 请求ID在此处生成，内层中间件沿用同一ID。
+// 处理器返回错误或结果标记为 IsError 时 span 状态记为 Error
+func ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if logging.RequestID(ctx) == "" {
+				ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+			}
+			tool := request.Params.Name
+			attrs := []attribute.KeyValue{
+				AttrTool.String(tool),
+				attribute.String(logging.RequestIDAttr, logging.RequestID(ctx)),
+			}
+			if location, ok := request.GetArguments()["location"].(string); ok && location != "" {
+				attrs = append(attrs, AttrLocation.String(location))
+			}
+
+			ctx, span := tracer().Start(ctx, "tools/call "+tool,
+				trace.WithNewRoot(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			result, err := next(ctx, request)
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case result != nil && result.IsError:
+				span.SetStatus(codes.Error, "tool returned an error result")
+			default:
+				span.SetStatus(codes.Ok, "")
+			}
+			return result, err
+		}
+	}
+}
+
+// InstrumentTransport 包装上游HTTP传输层，为每个请求创建子 span
+// span 中的URL已去除API密钥，endpoint 取URL路径的最后一段（如 weather、forecast）
+func InstrumentTransport(provider string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundtrip.Func(func(req *http.Request) (*http.Response, error) {
+		endpoint := path.Base(req.URL.Path)
+		ctx, span := tracer().Start(req.Context(), req.Method+" "+endpoint,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				AttrProvider.String(provider),
+				AttrEndpoint.String(endpoint),
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.full", logging.RedactURL(req.URL)),
+			),
+		)
+		defer span.End()
+
+		resp, err := next.RoundTrip(req.WithContext(ctx))
+		if err != nil {
+			span.RecordError(errors.New(logging.RedactString(err.Error())))
+			span.SetStatus(codes.Error, "upstream request failed")
+			return nil, err
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+		return resp, nil
+	})
+}
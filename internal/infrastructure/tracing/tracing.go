@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 支持的追踪导出器
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+	// ExporterStdout 写入标准错误，标准输出留给 stdio 传输的协议数据
+	ExporterStdout = "stdout"
+)
+
+// instrumentationName 基础设施层追踪器名称
+const instrumentationName = "weather-mcp-server/internal/infrastructure/tracing"
+
+// Config 追踪配置
+type Config struct {
+	Exporter       string // none（默认）、otlp、file 或 stdout
+	File           string // file 导出器写入的文件路径
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Setup 根据配置创建并注册全局 TracerProvider
+// otlp 导出器的地址、请求头等通过标准的 OTEL_EXPORTER_OTLP_* 环境变量配置。
+// 返回的函数用于在退出前导出剩余的 span 并关闭导出器；未启用追踪时为空操作。
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// newExporter 创建 span 导出器，未启用追踪时返回 nil
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return nil, nil, nil
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, nil, fmt.Errorf("trace file path is required for the file exporter")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		return exporter, f, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported trace exporter: %s (supported: none, otlp, file, stdout)", cfg.Exporter)
+	}
+}
+
+// tracer 获取基础设施层追踪器，未注册 TracerProvider 时为空操作
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
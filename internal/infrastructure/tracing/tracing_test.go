@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"weather-mcp-server/internal/infrastructure/logging"
+	"weather-mcp-server/internal/infrastructure/roundtrip"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// installRecorder 注册记录 span 的全局 TracerProvider，测试结束后恢复
+func installRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+// spanAttr 获取 span 的属性值
+func spanAttr(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestToolMiddlewareCreatesRootSpan(t *testing.T) {
+	exporter := installRecorder(t)
+
+	var child string
+	handler := ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		_, span := otel.Tracer("test").Start(ctx, "child")
+		child = span.SpanContext().TraceID().String()
+		span.End()
+		return mcp.NewToolResultError("boom"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "get_weather"
+	request.Params.Arguments = map[string]any{"location": "北京"}
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	if _, err := handler(ctx, request); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	root := spans[1]
+	if root.Name != "tools/call get_weather" || root.Parent.IsValid() {
+		t.Errorf("Expected root span for tool call, got %s (parent valid: %v)", root.Name, root.Parent.IsValid())
+	}
+	if root.SpanContext.TraceID().String() != child {
+		t.Error("Expected child span to share the tool call trace")
+	}
+	if v, _ := spanAttr(root.Attributes, AttrLocation); v.AsString() != "北京" {
+		t.Errorf("Expected location attribute, got %q", v.AsString())
+	}
+	if v, _ := spanAttr(root.Attributes, attribute.Key(logging.RequestIDAttr)); v.AsString() != "req-1" {
+		t.Errorf("Expected request ID attribute, got %q", v.AsString())
+	}
+	if root.Status.Code != codes.Error {
+		t.Errorf("Expected error status for IsError result, got %v", root.Status.Code)
+	}
+}
+
+func TestToolMiddlewareAssignsRequestID(t *testing.T) {
+	exporter := installRecorder(t)
+
+	var inner string
+	handler := ToolMiddleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		inner = logging.RequestID(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	})
+	if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	v, _ := spanAttr(spans[0].Attributes, attribute.Key(logging.RequestIDAttr))
+	if inner == "" || v.AsString() != inner {
+		t.Errorf("Expected inner handlers to share the span's request ID, got %q and %q", inner, v.AsString())
+	}
+}
+
+func TestInstrumentTransport(t *testing.T) {
+	exporter := installRecorder(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: InstrumentTransport("openweathermap", nil)}
+	resp, err := client.Get(upstream.URL + "/data/2.5/weather?q=Paris&appid=secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET weather" {
+		t.Errorf("Expected span name 'GET weather', got %s", span.Name)
+	}
+	if v, _ := spanAttr(span.Attributes, AttrProvider); v.AsString() != "openweathermap" {
+		t.Errorf("Expected provider attribute, got %q", v.AsString())
+	}
+	if v, _ := spanAttr(span.Attributes, "url.full"); strings.Contains(v.AsString(), "secret") {
+		t.Errorf("Expected API key to be redacted, got %s", v.AsString())
+	}
+	if v, _ := spanAttr(span.Attributes, "http.response.status_code"); v.AsInt64() != http.StatusUnauthorized {
+		t.Errorf("Expected status code 401, got %d", v.AsInt64())
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected error status, got %v", span.Status.Code)
+	}
+}
+
+func TestInstrumentTransportError(t *testing.T) {
+	exporter := installRecorder(t)
+
+	failing := roundtrip.Func(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("dial failed for appid=secret")
+	})
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/forecast?appid=secret", nil)
+	if _, err := InstrumentTransport("openweathermap", failing).RoundTrip(req); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	span := exporter.GetSpans()[0]
+	if len(span.Events) != 1 {
+		t.Fatalf("Expected recorded error event, got %d events", len(span.Events))
+	}
+	if v, _ := spanAttr(span.Events[0].Attributes, "exception.message"); strings.Contains(v.AsString(), "secret") {
+		t.Errorf("Expected redacted error message, got %s", v.AsString())
+	}
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(context.Background(), Config{})
+	if err != nil {
+		t.Fatalf("Expected no error for disabled tracing, got %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no-op shutdown, got %v", err)
+	}
+
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Error("Expected error for unsupported exporter")
+	}
+	if _, err := Setup(context.Background(), Config{Exporter: ExporterFile}); err == nil {
+		t.Error("Expected error for file exporter without path")
+	}
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err = Setup(context.Background(), Config{Exporter: ExporterFile, File: path, ServiceName: "test"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "offline")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error on shutdown, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected trace file, got %v", err)
+	}
+	if !strings.Contains(string(data), `"Name":"offline"`) {
+		t.Errorf("Expected exported span in trace file, got %s", data)
+	}
+}
@@ -0,0 +1,33 @@
+package watch
+
+import (
+	"weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/storage"
+)
+
+// FileStore 基于JSON文件的监测规则存储
+type FileStore struct {
+	path string
+}
+
+// NewFileStore 创建监测规则存储
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// LoadWatchRules 加载所有监测规则，文件不存在时返回空列表
+func (s *FileStore) LoadWatchRules() ([]weather.WatchRule, error) {
+	var rules []weather.WatchRule
+	if _, err := storage.ReadJSONFile(s.path, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SaveWatchRules 保存所有监测规则
+func (s *FileStore) SaveWatchRules(rules []weather.WatchRule) error {
+	if rules == nil {
+		rules = []weather.WatchRule{}
+	}
+	return storage.WriteJSONFile(s.path, rules)
+}
@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "watches.json"))
+
+	rules, err := store.LoadWatchRules()
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("Expected no rules for missing file, got %v (%v)", rules, err)
+	}
+
+	triggered := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	saved := []weather.WatchRule{{ID: "abc", Owner: "stdio", Location: "北京", Metric: weather.WatchPM25, Operator: weather.WatchAbove, Threshold: 150, LastTriggered: &triggered}}
+	if err := store.SaveWatchRules(saved); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rules, err = store.LoadWatchRules()
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %v (%v)", rules, err)
+	}
+	if rules[0].ID != "abc" || rules[0].LastTriggered == nil || !rules[0].LastTriggered.Equal(triggered) {
+		t.Errorf("Expected saved rule, got %+v", rules[0])
+	}
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var received weather.WatchEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %s", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := weather.WatchEvent{Rule: weather.WatchRule{ID: "abc"}, Value: 180, Message: "PM2.5"}
+	if err := NewWebhookPublisher(server.URL).PublishWatchEvent(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Rule.ID != "abc" || received.Value != 180 {
+		t.Errorf("Expected event to be delivered, got %+v", received)
+	}
+}
+
+func TestWebhookPublisherFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookPublisher(server.URL).PublishWatchEvent(weather.WatchEvent{}); err == nil {
+		t.Error("Expected error for non-2xx response, got nil")
+	}
+}
@@ -0,0 +1,49 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// webhookTimeout Webhook请求超时时间
+const webhookTimeout = 10 * time.Second
+
+// WebhookPublisher 以JSON POST请求将监测事件发送到Webhook
+type WebhookPublisher struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookPublisher 创建Webhook发布器
+func NewWebhookPublisher(url string) *WebhookPublisher {
+	return &WebhookPublisher{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: webhookTimeout,
+		},
+	}
+}
+
+// PublishWatchEvent 发送监测事件，非2xx响应视为失败
+func (p *WebhookPublisher) PublishWatchEvent(event weather.WatchEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch event: %w", err)
+	}
+
+	resp, err := p.httpClient.Post(p.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,168 +1,371 @@
 package weather
 
 import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed default_cities.json
+var defaultCities []byte
+
+// City 城市别名数据
+type City struct {
+	// Name 简体中文名
+	Name string `json:"name"`
+	// Query 上游查询使用的英文名
+	Query string `json:"query"`
+	// Traditional 繁体中文名，与简体相同时可省略
+	Traditional string `json:"traditional,omitempty"`
+	// Pinyin 拼音，可带声调（如 Xī'ān），匹配时忽略声调、大小写和分隔符
+	Pinyin string `json:"pinyin,omitempty"`
+	// Aliases 其他中文别名，如旧称和下辖区县名
+	// 外文旧称（如 Canton、Peking）常与其他国家的地名重名，不参与匹配，由上游按原名查询
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// CityMatch 城市名匹配结果
+type CityMatch struct {
+	City City
+	// Alias 命中的别名（规范化后）
+	Alias string
+	// Distance 输入与别名的编辑距离，精确匹配为0
+	Distance int
+}
+
+// adminSuffixes 匹配时去除的行政区划后缀（含繁体），较长的后缀在前
+var adminSuffixes = []string{
+	"特别行政区", "特別行政區", "自治区", "自治區", "自治州", "自治县", "自治縣",
+	"省", "市", "区", "區", "县", "縣",
+}
+
+// placeSuffixes 街道、车站等地点名的后缀，以这些后缀结尾的输入（如"南京路"）不按包含的城市名匹配
+var placeSuffixes = []string{"路", "街", "巷", "弄", "胡同", "站", "桥", "广场", "公园", "大学", "机场"}
+
+// pinyinNormalizer 去除拼音声调和分隔符
+var pinyinNormalizer = strings.NewReplacer(
+	"ā", "a", "á", "a", "ǎ", "a", "à", "a",
+	"ē", "e", "é", "e", "ě", "e", "è", "e",
+	"ī", "i", "í", "i", "ǐ", "i", "ì", "i",
+	"ō", "o", "ó", "o", "ǒ", "o", "ò", "o",
+	"ū", "u", "ú", "u", "ǔ", "u", "ù", "u",
+	"ǖ", "v", "ǘ", "v", "ǚ", "v", "ǜ", "v", "ü", "v",
+	"ń", "n", "ň", "n", "ǹ", "n",
+	"'", "", "’", "", "-", "", " ", "", "·", "", ".", "",
 )
 
 // CityMapping 城市名映射
+// 支持简体、繁体、带或不带声调的拼音和中文别名，可去除行政区划后缀；
+// 中文输入无法精确匹配时按编辑距离选择最接近的城市，相同输入总是得到相同结果。
+// 中文输入只与中文名和别名匹配，其他输入只与拼音和上游查询名匹配，外文地名不会被改写为同名的中国城市。
 type CityMapping struct {
-	chineseToEnglish map[string]string
-	englishToChinese map[string]string
+	cities []City
+	// han 规范化中文名和别名 -> cities 下标，同一别名对应多个城市时取数据中靠前的城市
+	han cityIndex
+	// latin 规范化拼音和上游查询名 -> cities 下标
+	latin cityIndex
+}
+
+// cityIndex 规范化名称到城市的索引
+type cityIndex struct {
+	index map[string]int
+	// keys 排序后的名称，保证模糊匹配的遍历顺序确定
+	keys []string
 }
 
-// NewCityMapping 创建新的城市名映射
+// add 添加名称及其去除行政区划后缀后的形式，已存在的名称保留先添加的城市
+func (ci *cityIndex) add(name string, i int) {
+	key := normalizeCityName(name)
+	for _, k := range []string{key, stripAdminSuffix(key)} {
+		if _, exists := ci.index[k]; k != "" && !exists {
+			ci.index[k] = i
+			ci.keys = append(ci.keys, k)
+		}
+	}
+}
+
+// NewCityMapping 创建使用内置城市数据的城市名映射
+// 内置数据的有效性由测试保证，解析失败时返回空映射
 func NewCityMapping() *CityMapping {
-	cm := &CityMapping{
-		chineseToEnglish: make(map[string]string),
-		englishToChinese: make(map[string]string),
+	cities, err := parseCities(defaultCities)
+	if err != nil {
+		return newCityMapping(nil)
 	}
+	return newCityMapping(cities)
+}
 
-	// 初始化城市名映射
-	cm.initCityMappings()
+// LoadCityMapping 加载城市名映射
+// 先加载内置数据，再用 userFile（可为空）中的城市按中文名覆盖或追加，
+// 无需修改代码即可补充城市和别名。
+func LoadCityMapping(userFile string) (*CityMapping, error) {
+	cities, err := parseCities(defaultCities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default cities: %w", err)
+	}
+	if userFile == "" {
+		return newCityMapping(cities), nil
+	}
 
+	data, err := os.ReadFile(userFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cities file: %w", err)
+	}
+	custom, err := parseCities(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cities file %s: %w", userFile, err)
+	}
+	return newCityMapping(mergeCities(cities, custom)), nil
+}
+
+// parseCities 解析并校验JSON格式的城市数据
+func parseCities(data []byte) ([]City, error) {
+	var cities []City
+	if err := json.Unmarshal(data, &cities); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(cities))
+	for _, c := range cities {
+		if c.Name == "" || c.Query == "" {
+			return nil, fmt.Errorf("city requires name and query: %+v", c)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicate city: %s", c.Name)
+		}
+		seen[c.Name] = true
+	}
+	return cities, nil
+}
+
+// mergeCities 按中文名用 custom 覆盖 base 中的城市，新城市追加在末尾
+func mergeCities(base, custom []City) []City {
+	index := make(map[string]int, len(base))
+	for i, c := range base {
+		index[c.Name] = i
+	}
+	merged := append([]City(nil), base...)
+	for _, c := range custom {
+		if i, ok := index[c.Name]; ok {
+			merged[i] = c
+			continue
+		}
+		index[c.Name] = len(merged)
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// newCityMapping 为城市数据建立中文名和拼音的索引
+func newCityMapping(cities []City) *CityMapping {
+	cm := &CityMapping{
+		cities: cities,
+		han:    cityIndex{index: make(map[string]int)},
+		latin:  cityIndex{index: make(map[string]int)},
+	}
+	for i, c := range cities {
+		for _, name := range append([]string{c.Name, c.Traditional}, c.Aliases...) {
+			if containsHan(name) {
+				cm.han.add(name, i)
+			}
+		}
+		cm.latin.add(c.Query, i)
+		cm.latin.add(c.Pinyin, i)
+	}
+	sort.Strings(cm.han.keys)
+	sort.Strings(cm.latin.keys)
 	return cm
 }
 
-// initCityMappings 初始化城市名映射
-func (cm *CityMapping) initCityMappings() {
-	// 主要城市映射
-	mappings := map[string]string{
-		// 直辖市
-		"北京": "Beijing",
-		"上海": "Shanghai",
-		"天津": "Tianjin",
-		"重庆": "Chongqing",
-
-		// 省会城市
-		"广州":   "Guangzhou",
-		"深圳":   "Shenzhen",
-		"杭州":   "Hangzhou",
-		"南京":   "Nanjing",
-		"成都":   "Chengdu",
-		"武汉":   "Wuhan",
-		"西安":   "Xian",
-		"济南":   "Jinan",
-		"青岛":   "Qingdao",
-		"大连":   "Dalian",
-		"沈阳":   "Shenyang",
-		"哈尔滨":  "Harbin",
-		"长春":   "Changchun",
-		"石家庄":  "Shijiazhuang",
-		"太原":   "Taiyuan",
-		"呼和浩特": "Hohhot",
-		"郑州":   "Zhengzhou",
-		"合肥":   "Hefei",
-		"南昌":   "Nanchang",
-		"福州":   "Fuzhou",
-		"厦门":   "Xiamen",
-		"长沙":   "Changsha",
-		"南宁":   "Nanning",
-		"海口":   "Haikou",
-		"贵阳":   "Guiyang",
-		"昆明":   "Kunming",
-		"拉萨":   "Lhasa",
-		"兰州":   "Lanzhou",
-		"西宁":   "Xining",
-		"银川":   "Yinchuan",
-		"乌鲁木齐": "Urumqi",
-
-		// 其他重要城市
-		"苏州": "Suzhou",
-		"无锡": "Wuxi",
-		"宁波": "Ningbo",
-		"温州": "Wenzhou",
-		"佛山": "Foshan",
-		"东莞": "Dongguan",
-		"中山": "Zhongshan",
-		"珠海": "Zhuhai",
-		"惠州": "Huizhou",
-		"江门": "Jiangmen",
-		"肇庆": "Zhaoqing",
-		"清远": "Qingyuan",
-		"韶关": "Shaoguan",
-		"河源": "Heyuan",
-		"梅州": "Meizhou",
-		"汕尾": "Shanwei",
-		"阳江": "Yangjiang",
-		"茂名": "Maoming",
-		"湛江": "Zhanjiang",
-		"潮州": "Chaozhou",
-		"揭阳": "Jieyang",
-		"云浮": "Yunfu",
-
-		// 区级地名映射（部分）
-		"北京海淀":  "Beijing",
-		"北京朝阳":  "Beijing",
-		"北京西城":  "Beijing",
-		"北京东城":  "Beijing",
-		"北京丰台":  "Beijing",
-		"北京石景山": "Beijing",
-		"北京门头沟": "Beijing",
-		"北京房山":  "Beijing",
-		"北京通州":  "Beijing",
-		"北京顺义":  "Beijing",
-		"北京昌平":  "Beijing",
-		"北京大兴":  "Beijing",
-		"北京怀柔":  "Beijing",
-		"北京平谷":  "Beijing",
-		"北京密云":  "Beijing",
-		"北京延庆":  "Beijing",
-
-		"上海浦东": "Shanghai",
-		"上海黄浦": "Shanghai",
-		"上海徐汇": "Shanghai",
-		"上海长宁": "Shanghai",
-		"上海静安": "Shanghai",
-		"上海普陀": "Shanghai",
-		"上海虹口": "Shanghai",
-		"上海杨浦": "Shanghai",
-		"上海闵行": "Shanghai",
-		"上海宝山": "Shanghai",
-		"上海嘉定": "Shanghai",
-		"上海金山": "Shanghai",
-		"上海松江": "Shanghai",
-		"上海青浦": "Shanghai",
-		"上海奉贤": "Shanghai",
-		"上海崇明": "Shanghai",
-	}
-
-	// 填充映射表
-	for chinese, english := range mappings {
-		cm.chineseToEnglish[chinese] = english
-		cm.englishToChinese[english] = chinese
-	}
-}
-
-// GetEnglishName 获取英文城市名
-func (cm *CityMapping) GetEnglishName(chineseName string) (string, bool) {
-	// 直接匹配
-	if english, exists := cm.chineseToEnglish[chineseName]; exists {
-		return english, true
-	}
-
-	// 模糊匹配：检查是否包含已知的中文城市名
-	for chinese, english := range cm.chineseToEnglish {
-		if strings.Contains(chineseName, chinese) {
-			return english, true
+// Match 匹配城市名
+// 依次尝试：精确匹配、去除行政区划后缀后匹配；中文输入还会尝试包含已知中文地名（取最长的地名，如"北京海淀区"）
+// 和按编辑距离匹配最接近的名称。拉丁字母输入只精确匹配，拼写错误见 Suggest。
+func (cm *CityMapping) Match(name string) (CityMatch, bool) {
+	key := normalizeCityName(name)
+	if key == "" {
+		return CityMatch{}, false
+	}
+	idx := &cm.latin
+	if containsHan(key) {
+		idx = &cm.han
+	}
+	stripped := stripAdminSuffix(key)
+	for _, k := range []string{key, stripped} {
+		if i, ok := idx.index[k]; ok {
+			return CityMatch{City: cm.cities[i], Alias: k}, true
+		}
+	}
+	if idx != &cm.han {
+		return CityMatch{}, false
+	}
+	if m, ok := cm.matchContained(stripped); ok {
+		return m, true
+	}
+	return cm.matchNearest(idx, key)
+}
+
+// Suggest 为无法精确匹配的拉丁字母输入查找拼写最接近的城市（容忍拼写错误，如"sian"）
+// 拼音的拼写错误与真实的外国地名无法区分（如美国的"Banning"与南宁只差一个字母），
+// 调用方应先按原名查询上游，查不到时再使用建议的城市。
+func (cm *CityMapping) Suggest(name string) (CityMatch, bool) {
+	key := normalizeCityName(name)
+	if key == "" || containsHan(key) {
+		return CityMatch{}, false
+	}
+	if _, ok := cm.latin.index[key]; ok {
+		return CityMatch{}, false
+	}
+	return cm.matchNearest(&cm.latin, key)
+}
+
+// matchContained 查找中文输入中包含的最长中文名或别名
+// 街道、车站等地点名（如"南京路"、"北京西站"）不是对应城市，不匹配
+func (cm *CityMapping) matchContained(key string) (CityMatch, bool) {
+	for _, suffix := range placeSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return CityMatch{}, false
 		}
 	}
+	best, bestLen := "", 0
+	for _, k := range cm.han.keys {
+		n := utf8.RuneCountInString(k)
+		if n < 2 || !strings.Contains(key, k) {
+			continue
+		}
+		if n > bestLen || (n == bestLen && cm.han.index[k] < cm.han.index[best]) {
+			best, bestLen = k, n
+		}
+	}
+	if best == "" {
+		return CityMatch{}, false
+	}
+	return CityMatch{City: cm.cities[cm.han.index[best]], Alias: best, Distance: utf8.RuneCountInString(key) - bestLen}, true
+}
 
-	return "", false
+// matchNearest 在索引中查找编辑距离最小且在容忍范围内的名称
+// 最小距离上有多个不同城市时无法判断用户所指（如"xuzhou"与苏州、福州的距离都是1），不匹配，交给上游按原名查询
+func (cm *CityMapping) matchNearest(idx *cityIndex, key string) (CityMatch, bool) {
+	limit := maxEditDistance(utf8.RuneCountInString(key))
+	if limit == 0 {
+		return CityMatch{}, false
+	}
+	best, bestDistance, ambiguous := "", limit+1, false
+	for _, k := range idx.keys {
+		d := editDistance(key, k)
+		switch {
+		case d < bestDistance:
+			best, bestDistance, ambiguous = k, d, false
+		case d == bestDistance && best != "" && idx.index[k] != idx.index[best]:
+			ambiguous = true
+		}
+	}
+	if best == "" || ambiguous {
+		return CityMatch{}, false
+	}
+	return CityMatch{City: cm.cities[idx.index[best]], Alias: best, Distance: bestDistance}, true
 }
 
-// GetChineseName 获取中文城市名
-func (cm *CityMapping) GetChineseName(englishName string) (string, bool) {
-	chinese, exists := cm.englishToChinese[englishName]
-	return chinese, exists
+// maxEditDistance 模糊匹配容忍的编辑距离，输入越短越严格，避免短名称误匹配到其他城市
+func maxEditDistance(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 9:
+		return 1
+	default:
+		return 2
+	}
 }
 
-// IsChineseCity 判断是否为中文城市名
-func (cm *CityMapping) IsChineseCity(cityName string) bool {
-	// 简单的判断：包含中文字符
-	for _, char := range cityName {
-		if char >= 0x4e00 && char <= 0x9fff {
+// editDistance 计算两个字符串按字符的编辑距离（Levenshtein距离）
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// normalizeCityName 规范化城市名用于匹配：去除首尾空白、转小写、去除拼音声调、分隔符和声调数字
+func normalizeCityName(name string) string {
+	name = pinyinNormalizer.Replace(strings.ToLower(strings.TrimSpace(name)))
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// stripAdminSuffix 去除行政区划后缀，如"西安市"、"长安区"；去除后不足两个字时保留原名
+func stripAdminSuffix(name string) string {
+	for stripped := true; stripped; {
+		stripped = false
+		for _, suffix := range adminSuffixes {
+			rest, ok := strings.CutSuffix(name, suffix)
+			if ok && utf8.RuneCountInString(rest) >= 2 {
+				name, stripped = rest, true
+				break
+			}
+		}
+	}
+	return name
+}
+
+// containsHan 判断是否包含汉字
+func containsHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
 			return true
 		}
 	}
 	return false
 }
+
+// GetEnglishName 获取上游查询使用的英文城市名
+func (cm *CityMapping) GetEnglishName(name string) (string, bool) {
+	m, ok := cm.Match(name)
+	if !ok {
+		return "", false
+	}
+	return m.City.Query, true
+}
+
+// KnownCities 获取所有已知的中文城市名，按数据中的顺序排列
+func (cm *CityMapping) KnownCities() []string {
+	cities := make([]string, 0, len(cm.cities))
+	for _, c := range cm.cities {
+		cities = append(cities, c.Name)
+	}
+	return cities
+}
+
+// GetChineseName 获取中文城市名，只接受精确的拼音或上游查询名（忽略大小写和声调）
+func (cm *CityMapping) GetChineseName(englishName string) (string, bool) {
+	i, exists := cm.latin.index[normalizeCityName(englishName)]
+	if !exists {
+		return "", false
+	}
+	return cm.cities[i].Name, true
+}
+
+// IsChineseCity 判断是否为中文城市名
+func (cm *CityMapping) IsChineseCity(cityName string) bool {
+	return containsHan(cityName)
+}
@@ -0,0 +1,175 @@
+package weather
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCityMappingMatch(t *testing.T) {
+	cm := NewCityMapping()
+	tests := []struct {
+		input    string
+		expected string
+		distance int
+	}{
+		// 简体、繁体和行政区划后缀
+		{"西安", "Xian", 0},
+		{"西安市", "Xian", 0},
+		{"长安区", "Xian", 0},
+		{"廈門", "Xiamen", 0},
+		{"廣州市", "Guangzhou", 0},
+		{"新疆维吾尔自治区乌鲁木齐市", "Urumqi", 8},
+		// 拼音和别名
+		{"Xi'an", "Xian", 0},
+		{"xian", "Xian", 0},
+		{"Xī'ān", "Xian", 0},
+		{"xi1 an1", "Xian", 0},
+		{"HA ER BIN", "Harbin", 0},
+		{"  shanghai  ", "Shanghai", 0},
+		// 包含已知地名
+		{"北京海淀", "Beijing", 2},
+		{"北京西城区", "Beijing", 2},
+		{"北京市海淀区", "Beijing", 3},
+		{"广东省广州市", "Guangzhou", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, ok := cm.Match(tt.input)
+			if !ok {
+				t.Fatalf("Expected %s to match %s", tt.input, tt.expected)
+			}
+			if m.City.Query != tt.expected || m.Distance != tt.distance {
+				t.Errorf("Expected %s at distance %d, got %s at distance %d (alias %s)", tt.expected, tt.distance, m.City.Query, m.Distance, m.Alias)
+			}
+		})
+	}
+
+	// 无法匹配或无法区分时原样交给上游，拉丁字母的拼写错误由 Suggest 处理
+	for _, input := range []string{"", "Paris", "London,GB", "xuzhou", "沙市", "abc", "sian", "Bejing"} {
+		if m, ok := cm.Match(input); ok {
+			t.Errorf("Expected %q not to match, got %s", input, m.City.Name)
+		}
+	}
+}
+
+func TestCityMappingSuggest(t *testing.T) {
+	cm := NewCityMapping()
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sian", "Xian"},
+		{"Bejing", "Beijing"},
+		{"Shenzen", "Shenzhen"},
+		{"Banning", "Nanning"},
+	}
+	for _, tt := range tests {
+		m, ok := cm.Suggest(tt.input)
+		if !ok || m.City.Query != tt.expected || m.Distance != 1 {
+			t.Errorf("Expected %s to suggest %s at distance 1, got %+v (ok=%v)", tt.input, tt.expected, m, ok)
+		}
+	}
+
+	// 精确匹配、中文输入和无法区分的输入没有建议
+	for _, input := range []string{"xian", "北京", "xuzhou", "Paris"} {
+		if m, ok := cm.Suggest(input); ok {
+			t.Errorf("Expected no suggestion for %q, got %s", input, m.City.Name)
+		}
+	}
+}
+
+func TestCityMappingForeignNameCollisions(t *testing.T) {
+	cm := NewCityMapping()
+	// 与中国城市旧称或中文名重名的外国地名、街道和车站不改写，交给上游按原名查询
+	inputs := []string{
+		"Canton", "Canton,US", "Peking", "Nanking", "Amoy", "Mukden", "Banning",
+		"南京路", "南京东路", "北京路", "上海街", "北京西站", "广州大学",
+	}
+	for _, input := range inputs {
+		if m, ok := cm.Match(input); ok {
+			t.Errorf("Expected %q not to match, got %s", input, m.City.Name)
+		}
+	}
+}
+
+func TestCityMappingIsDeterministic(t *testing.T) {
+	inputs := []string{"sian", "北京市海淀区", "广东省广州市", "Bejing", "xuzhou"}
+	first := NewCityMapping()
+	for i := 0; i < 20; i++ {
+		cm := NewCityMapping()
+		for _, input := range inputs {
+			a, okA := first.Match(input)
+			b, okB := cm.Match(input)
+			if okA != okB || a.City.Name != b.City.Name || a.Alias != b.Alias || a.Distance != b.Distance {
+				t.Fatalf("Expected deterministic match for %s, got %+v and %+v", input, a, b)
+			}
+		}
+	}
+}
+
+func TestLoadCityMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cities.json")
+	data := `[
+		{"name": "徐州", "query": "Xuzhou", "pinyin": "Xúzhōu", "aliases": ["彭城"]},
+		{"name": "西安", "query": "Xi'an", "pinyin": "Xī'ān"}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cm, err := LoadCityMapping(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"徐州市", "Xuzhou"},
+		{"彭城", "Xuzhou"},
+		{"xuzhou", "Xuzhou"},
+		{"西安", "Xi'an"},
+		{"北京", "Beijing"},
+	}
+	for _, tt := range tests {
+		if english, ok := cm.GetEnglishName(tt.input); !ok || english != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.input, english)
+		}
+	}
+	// 覆盖的城市使用用户数据，未提供的别名不再生效
+	if _, ok := cm.Match("长安区"); ok {
+		t.Error("Expected overridden city to use aliases from the user file")
+	}
+	if cities := cm.KnownCities(); cities[len(cities)-1] != "徐州" {
+		t.Errorf("Expected new city appended to known cities, got %v", cities[len(cities)-1])
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"name": "徐州"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCityMapping(path); err == nil {
+		t.Error("Expected error for city without query")
+	}
+	if _, err := LoadCityMapping(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestDefaultCities(t *testing.T) {
+	cities, err := parseCities(defaultCities)
+	if err != nil {
+		t.Fatalf("Expected embedded cities to be valid, got %v", err)
+	}
+	cm := NewCityMapping()
+	for _, c := range cities {
+		for _, name := range []string{c.Name, c.Traditional, c.Pinyin} {
+			if name == "" {
+				continue
+			}
+			if m, ok := cm.Match(name); !ok || m.City.Name != c.Name {
+				t.Errorf("Expected %s to match %s, got %s", name, c.Name, m.City.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package weather
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyProvider 上游API密钥提供者
+// 客户端每次请求前获取密钥，请求完成后报告结果，便于密钥池轮换和剔除失效密钥。
+type KeyProvider interface {
+	// Key 获取本次请求使用的密钥
+	Key() (string, error)
+	// Report 报告使用该密钥的请求的HTTP状态码
+	Report(key string, statusCode int)
+}
+
+// StaticKey 固定的单个密钥
+type StaticKey string
+
+// Key 实现 KeyProvider
+func (k StaticKey) Key() (string, error) {
+	return string(k), nil
+}
+
+// Report 实现 KeyProvider，单个密钥无需处理请求结果
+func (k StaticKey) Report(key string, statusCode int) {}
+
+// 密钥池的分配策略
+const (
+	StrategyRoundRobin = "round_robin"
+	StrategyLeastUsed  = "least_used"
+)
+
+const (
+	// DefaultInvalidKeyCooldown 返回401的密钥移出轮换的时长
+	// 新申请的密钥可能需要数小时才能生效，因此不永久剔除，重新加载密钥文件时也会恢复
+	DefaultInvalidKeyCooldown = time.Hour
+	// DefaultRateLimitCooldown 返回429的密钥移出轮换的时长（免费套餐按分钟限流）
+	DefaultRateLimitCooldown = time.Minute
+)
+
+// poolKey 密钥池中的单个密钥
+type poolKey struct {
+	key           string
+	requests      int
+	disabledUntil time.Time
+}
+
+// KeyPool 多个API密钥组成的密钥池
+// 按轮询或最少使用分配密钥，返回401或429的密钥在冷却期内移出轮换。
+type KeyPool struct {
+	strategy        string
+	invalidCooldown time.Duration
+	limitedCooldown time.Duration
+	now             func() time.Time
+
+	mu   sync.Mutex
+	keys []*poolKey
+	next int
+}
+
+// KeyPoolOption 密钥池配置项
+type KeyPoolOption func(*KeyPool)
+
+// WithStrategy 设置密钥分配策略
+func WithStrategy(strategy string) KeyPoolOption {
+	return func(p *KeyPool) {
+		p.strategy = strategy
+	}
+}
+
+// WithKeyCooldowns 设置密钥返回401和429后移出轮换的时长
+func WithKeyCooldowns(invalid, rateLimited time.Duration) KeyPoolOption {
+	return func(p *KeyPool) {
+		if invalid > 0 {
+			p.invalidCooldown = invalid
+		}
+		if rateLimited > 0 {
+			p.limitedCooldown = rateLimited
+		}
+	}
+}
+
+// WithKeyPoolClock 设置密钥池使用的时钟，主要用于测试
+func WithKeyPoolClock(now func() time.Time) KeyPoolOption {
+	return func(p *KeyPool) {
+		p.now = now
+	}
+}
+
+// NewKeyPool 创建密钥池
+func NewKeyPool(keys []string, opts ...KeyPoolOption) (*KeyPool, error) {
+	p := &KeyPool{
+		strategy:        StrategyRoundRobin,
+		invalidCooldown: DefaultInvalidKeyCooldown,
+		limitedCooldown: DefaultRateLimitCooldown,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.strategy != StrategyRoundRobin && p.strategy != StrategyLeastUsed {
+		return nil, fmt.Errorf("unsupported key strategy: %s (supported: %s, %s)", p.strategy, StrategyRoundRobin, StrategyLeastUsed)
+	}
+	if err := p.Replace(keys); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Replace 替换密钥池中的密钥，用于不重启进程轮换密钥
+// 保留仍在池中的密钥的使用次数，所有密钥重新加入轮换
+func (p *KeyPool) Replace(keys []string) error {
+	keys = dedupeKeys(keys)
+	if len(keys) == 0 {
+		return errors.New("at least one API key is required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous := make(map[string]int, len(p.keys))
+	for _, k := range p.keys {
+		previous[k.key] = k.requests
+	}
+	p.keys = make([]*poolKey, len(keys))
+	for i, key := range keys {
+		p.keys[i] = &poolKey{key: key, requests: previous[key]}
+	}
+	p.next = 0
+	return nil
+}
+
+// Key 实现 KeyProvider
+// 所有密钥都在冷却期时使用最早恢复的密钥，使上游的401或429错误能如实返回给调用方
+func (p *KeyPool) Key() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+
+	var chosen *poolKey
+	switch p.strategy {
+	case StrategyLeastUsed:
+		for _, k := range p.keys {
+			if k.disabledUntil.After(now) {
+				continue
+			}
+			if chosen == nil || k.requests < chosen.requests {
+				chosen = k
+			}
+		}
+	default:
+		for i := 0; i < len(p.keys); i++ {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if !k.disabledUntil.After(now) {
+				chosen = k
+				p.next = (p.next + i + 1) % len(p.keys)
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		for _, k := range p.keys {
+			if chosen == nil || k.disabledUntil.Before(chosen.disabledUntil) {
+				chosen = k
+			}
+		}
+	}
+	chosen.requests++
+	return chosen.key, nil
+}
+
+// Report 实现 KeyProvider，401和429的密钥在冷却期内移出轮换
+func (p *KeyPool) Report(key string, statusCode int) {
+	var cooldown time.Duration
+	switch statusCode {
+	case http.StatusUnauthorized:
+		cooldown = p.invalidCooldown
+	case http.StatusTooManyRequests:
+		cooldown = p.limitedCooldown
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k.key == key {
+			k.disabledUntil = p.now().Add(cooldown)
+			slog.Warn("API key removed from rotation",
+				"key", maskKey(key), "status", statusCode, "until", k.disabledUntil)
+			return
+		}
+	}
+}
+
+// Active 获取当前可用的密钥数和密钥总数
+func (p *KeyPool) Active() (active, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for _, k := range p.keys {
+		if !k.disabledUntil.After(now) {
+			active++
+		}
+	}
+	return active, len(p.keys)
+}
+
+// LoadKeyFile 加载密钥文件，每行一个密钥，忽略空行和 # 开头的注释
+func LoadKeyFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key file: %w", err)
+	}
+	defer f.Close()
+
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return keys, nil
+}
+
+// ParseKeys 解析逗号分隔的密钥列表
+func ParseKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// dedupeKeys 去除重复和空白的密钥，保持顺序
+func dedupeKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, key)
+	}
+	return result
+}
+
+// maskKey 日志中只显示密钥末4位
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -0,0 +1,228 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyPoolRoundRobin(t *testing.T) {
+	pool, err := NewKeyPool([]string{"a", "b", "c", "a"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		key, _ := pool.Key()
+		got = append(got, key)
+	}
+	expected := []string{"a", "b", "c", "a"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, got)
+	}
+}
+
+func TestKeyPoolLeastUsed(t *testing.T) {
+	pool, err := NewKeyPool([]string{"a", "b"}, WithStrategy(StrategyLeastUsed))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	pool.keys[0].requests = 3
+
+	for i := 0; i < 3; i++ {
+		if key, _ := pool.Key(); key != "b" {
+			t.Errorf("Expected least used key b on request %d, got %s", i, key)
+		}
+	}
+	if key, _ := pool.Key(); key != "a" {
+		t.Errorf("Expected key a once usage is even, got %s", key)
+	}
+}
+
+func TestKeyPoolInvalidStrategy(t *testing.T) {
+	if _, err := NewKeyPool([]string{"a"}, WithStrategy("random")); err == nil {
+		t.Error("Expected error for unsupported strategy")
+	}
+	if _, err := NewKeyPool([]string{" ", ""}); err == nil {
+		t.Error("Expected error for empty key list")
+	}
+}
+
+func TestKeyPoolCooldown(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		cooldown time.Duration
+		disabled bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, time.Hour, true},
+		{"rate limited", http.StatusTooManyRequests, time.Minute, true},
+		{"server error", http.StatusInternalServerError, 0, false},
+		{"ok", http.StatusOK, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			pool, _ := NewKeyPool([]string{"a", "b"},
+				WithKeyCooldowns(time.Hour, time.Minute),
+				WithKeyPoolClock(func() time.Time { return now }))
+
+			pool.Report("a", tt.status)
+			if active, _ := pool.Active(); (active == 1) != tt.disabled {
+				t.Errorf("Expected key disabled %v, got %d active keys", tt.disabled, active)
+			}
+			if !tt.disabled {
+				return
+			}
+			for i := 0; i < 3; i++ {
+				if key, _ := pool.Key(); key != "b" {
+					t.Errorf("Expected disabled key to be skipped, got %s", key)
+				}
+			}
+
+			now = now.Add(tt.cooldown + time.Second)
+			if active, total := pool.Active(); active != total {
+				t.Errorf("Expected all %d keys active after cooldown, got %d", total, active)
+			}
+		})
+	}
+}
+
+func TestKeyPoolAllDisabledFallback(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool, _ := NewKeyPool([]string{"a", "b"}, WithKeyPoolClock(func() time.Time { return now }))
+
+	pool.Report("a", http.StatusUnauthorized)
+	pool.Report("b", http.StatusTooManyRequests)
+
+	// b 的冷却期更短，应优先使用
+	if key, _ := pool.Key(); key != "b" {
+		t.Errorf("Expected earliest recovering key b, got %s", key)
+	}
+}
+
+func TestKeyPoolReplace(t *testing.T) {
+	pool, _ := NewKeyPool([]string{"a", "b"}, WithStrategy(StrategyLeastUsed))
+	pool.Key()
+	pool.Key()
+	pool.Report("a", http.StatusUnauthorized)
+
+	if err := pool.Replace([]string{"a", "c"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if active, total := pool.Active(); active != 2 || total != 2 {
+		t.Errorf("Expected 2/2 active keys after replace, got %d/%d", active, total)
+	}
+	// a 保留了之前的使用次数，新密钥 c 优先
+	if key, _ := pool.Key(); key != "c" {
+		t.Errorf("Expected new key c, got %s", key)
+	}
+	if err := pool.Replace(nil); err == nil {
+		t.Error("Expected error when replacing with no keys")
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	content := "# production keys\nkey-one\n\n  key-two  \n# retired: key-old\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"key-one", "key-two"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, keys)
+	}
+
+	if _, err := LoadKeyFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing key file")
+	}
+}
+
+func TestClientRotatesKeys(t *testing.T) {
+	recorder := &keyRecorder{}
+	ts := recorder.server(t)
+	pool, _ := NewKeyPool([]string{"good-1", "bad", "good-2"})
+	client := NewOpenWeatherClient("", WithKeyProvider(pool))
+	client.baseURL = ts.URL
+
+	for i := 0; i < 6; i++ {
+		client.GetWeatherByCity(context.Background(), "Paris")
+	}
+
+	expected := []string{"good-1", "bad", "good-2", "good-1", "good-2", "good-1"}
+	if !reflect.DeepEqual(recorder.keys, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, recorder.keys)
+	}
+	if active, total := pool.Active(); active != 2 || total != 3 {
+		t.Errorf("Expected 2/3 active keys, got %d/%d", active, total)
+	}
+}
+
+func TestFeatureDetectionKeepsFreeKeysInRotation(t *testing.T) {
+	recorder := &keyRecorder{}
+	ts := recorder.server(t)
+	pool, _ := NewKeyPool([]string{"free-1", "free-2"})
+	client := NewOpenWeatherClient("", WithKeyProvider(pool))
+	client.baseURL, client.oneCallURL = ts.URL, ts.URL
+
+	if features := client.Features(context.Background()); len(features) != 0 {
+		t.Errorf("Expected no paid features for free keys, got %v", features)
+	}
+	if active, total := pool.Active(); active != 2 || total != 2 {
+		t.Errorf("Expected free keys to stay in rotation after feature detection, got %d/%d", active, total)
+	}
+}
+
+func TestOneCallRequestsUseDetectedKey(t *testing.T) {
+	var mu sync.Mutex
+	var oneCallKeys []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("appid")
+		switch {
+		case r.URL.Path == "/onecall" && key != "paid":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/onecall":
+			mu.Lock()
+			oneCallKeys = append(oneCallKeys, key)
+			mu.Unlock()
+			w.Write([]byte(`{"current":{"uvi":6.5}}`))
+		default:
+			w.Write([]byte(`{"name":"Shenzhen","coord":{"lat":22.54,"lon":114.06}}`))
+		}
+	}))
+	defer upstream.Close()
+	pool, _ := NewKeyPool([]string{"paid", "free"})
+	client := NewOpenWeatherClient("", WithKeyProvider(pool))
+	client.baseURL, client.oneCallURL = upstream.URL, upstream.URL
+
+	if features := client.Features(context.Background()); len(features) != 1 || features[0] != FeatureOneCall {
+		t.Fatalf("Expected onecall to be detected with the paid key, got %v", features)
+	}
+	oneCallKeys = nil
+	for i := 0; i < 4; i++ {
+		w, err := client.GetWeatherByCity(context.Background(), "Shenzhen")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if w.Current.UVIndex == nil {
+			t.Errorf("Request %d: expected UV index regardless of the rotated key", i)
+		}
+	}
+	expected := []string{"paid", "paid", "paid", "paid"}
+	if !reflect.DeepEqual(oneCallKeys, expected) {
+		t.Errorf("Expected One Call requests to use the detected key, got %v", oneCallKeys)
+	}
+}
@@ -1,93 +1,259 @@
 package weather
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"net/url"
-	"strconv"
+	"sync"
 	"time"
 
 	"weather-mcp-server/internal/domain/weather"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ProviderName 数据源名称，用于指标等标识
+const ProviderName = "openweathermap"
+
+// tracerName 客户端追踪器名称
+const tracerName = "weather-mcp-server/internal/infrastructure/weather"
+
 // OpenWeatherClient OpenWeatherMap API客户端
 type OpenWeatherClient struct {
-	apiKey      string
+	keys        KeyProvider
 	client      *http.Client
 	baseURL     string
+	oneCallURL  string
 	cityMapping *CityMapping
+
+	featureMu     sync.Mutex
+	features      []string
+	featuresKnown bool
+	// featureKey 探测付费功能时使用的密钥，付费功能的请求固定使用该密钥
+	featureKey string
+}
+
+// ClientOption OpenWeatherMap客户端配置项
+type ClientOption func(*OpenWeatherClient)
+
+// WithTransport 设置上游请求使用的HTTP传输层，如用于统计指标的包装
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *OpenWeatherClient) {
+		c.client.Transport = transport
+	}
+}
+
+// WithKeyProvider 设置上游API密钥的提供者，如多个密钥组成的密钥池
+func WithKeyProvider(keys KeyProvider) ClientOption {
+	return func(c *OpenWeatherClient) {
+		c.keys = keys
+	}
+}
+
+// WithCityMapping 设置城市名映射，如加载了用户城市数据的映射
+func WithCityMapping(cityMapping *CityMapping) ClientOption {
+	return func(c *OpenWeatherClient) {
+		c.cityMapping = cityMapping
+	}
 }
 
 // NewOpenWeatherClient 创建新的OpenWeatherMap客户端
-func NewOpenWeatherClient(apiKey string) *OpenWeatherClient {
-	return &OpenWeatherClient{
-		apiKey:      apiKey,
+func NewOpenWeatherClient(apiKey string, opts ...ClientOption) *OpenWeatherClient {
+	c := &OpenWeatherClient{
+		keys:        StaticKey(apiKey),
 		client:      &http.Client{Timeout: 10 * time.Second},
 		baseURL:     "https://api.openweathermap.org/data/2.5",
+		oneCallURL:  "https://api.openweathermap.org/data/3.0",
 		cityMapping: NewCityMapping(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// GetCurrentWeather 获取当前天气
-func (c *OpenWeatherClient) GetCurrentWeather(lat, lon float64) (*weather.Weather, error) {
-	params := url.Values{}
-	params.Add("lat", strconv.FormatFloat(lat, 'f', -1, 64))
-	params.Add("lon", strconv.FormatFloat(lon, 'f', -1, 64))
-	params.Add("appid", c.apiKey)
-	params.Add("units", "metric")
-	params.Add("lang", "zh_cn")
-
-	resp, err := c.client.Get(fmt.Sprintf("%s/weather?%s", c.baseURL, params.Encode()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch weather data: %w", err)
+// resolveCity 将城市名（中文、拼音或别名）转换为上游查询使用的英文名，无法匹配的名称原样返回
+func (c *OpenWeatherClient) resolveCity(ctx context.Context, city string) string {
+	_, span := otel.Tracer(tracerName).Start(ctx, "geocode", trace.WithAttributes(
+		attribute.String("weather.location", city),
+		attribute.String("weather.provider", ProviderName),
+	))
+	defer span.End()
+
+	queryCity := city
+	if m, ok := c.cityMapping.Match(city); ok {
+		queryCity = m.City.Query
+		span.SetAttributes(
+			attribute.String("weather.city_match.alias", m.Alias),
+			attribute.Int("weather.city_match.distance", m.Distance),
+		)
 	}
-	defer resp.Body.Close()
+	span.SetAttributes(attribute.String("weather.query", queryCity))
+	return queryCity
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+// Probe 探测数据源的可达性和API密钥是否有效
+// 使用一次固定坐标的实时天气查询，会计入调用配额，调用方应缓存结果
+func (c *OpenWeatherClient) Probe(ctx context.Context) weather.ProviderProbe {
+	probe := weather.ProviderProbe{Provider: ProviderName, CheckedAt: time.Now()}
+	start := time.Now()
+	_, _, err := c.execute(ctx, c.baseURL, "weather", coordParams(0, 0))
+	probe.Latency = time.Since(start)
+
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		probe.Reachable = true
+		probe.KeyValid = true
+		probe.StatusCode = http.StatusOK
+	case errors.As(err, &statusErr):
+		probe.Reachable = true
+		probe.StatusCode = statusErr.StatusCode
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized:
+			probe.Error = "API key was rejected"
+		case http.StatusTooManyRequests:
+			// 被限流说明密钥本身有效
+			probe.KeyValid = true
+			probe.Error = "rate limited by provider"
+		default:
+			probe.KeyValid = true
+			probe.Error = fmt.Sprintf("unexpected status: %d", statusErr.StatusCode)
+		}
+	default:
+		probe.Error = err.Error()
 	}
+	return probe
+}
+
+// FeatureOneCall One Call API 3.0（需单独订阅）
+const FeatureOneCall = "onecall"
 
-	var apiResp OpenWeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// detectFeatures 探测API密钥可用的付费功能，返回探测结果和使用的密钥，网络错误等无法判断的情况返回错误
+// 免费套餐的密钥访问 One Call API 3.0 会返回401，探测结果不影响密钥轮换
+func (c *OpenWeatherClient) detectFeatures(ctx context.Context) ([]string, string, error) {
+	key, err := c.keys.Key()
+	if err != nil {
+		return nil, "", err
+	}
+	params := coordParams(0, 0)
+	params.Set("exclude", "minutely,hourly,daily,alerts")
+
+	var features []string
+	_, err = c.executeFeatureProbe(ctx, key, c.oneCallURL, "onecall", params)
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		features = append(features, FeatureOneCall)
+	case !errors.As(err, &statusErr):
+		return nil, "", err
 	}
+	return features, key, nil
+}
 
-	return c.convertToWeather(&apiResp), nil
+// Features 获取API密钥可用的付费功能
+// 首次调用时用密钥提供者当前的密钥探测并缓存结果，探测因网络错误失败时不缓存，下次调用重新探测。
+// 密钥池中的密钥订阅不同时，结果只代表探测所用的密钥，付费功能的请求也只使用该密钥
+func (c *OpenWeatherClient) Features(ctx context.Context) []string {
+	c.featureMu.Lock()
+	features, known := c.features, c.featuresKnown
+	c.featureMu.Unlock()
+	if known {
+		return features
+	}
+
+	features, key, err := c.detectFeatures(ctx)
+	if err != nil {
+		return nil
+	}
+	c.featureMu.Lock()
+	c.features, c.featuresKnown, c.featureKey = features, true, key
+	c.featureMu.Unlock()
+	return features
 }
 
-// GetWeatherByCity 根据城市名获取天气
-func (c *OpenWeatherClient) GetWeatherByCity(city string) (*weather.Weather, error) {
-	// 检查是否为中文城市名，如果是则转换为英文
-	queryCity := city
-	if c.cityMapping.IsChineseCity(city) {
-		if englishName, exists := c.cityMapping.GetEnglishName(city); exists {
-			queryCity = englishName
+// featureKeyFor 获取已探测到可用指定付费功能的密钥
+// 只读取缓存的探测结果，尚未探测或功能不可用时返回 false，不会为此发起请求
+func (c *OpenWeatherClient) featureKeyFor(feature string) (string, bool) {
+	c.featureMu.Lock()
+	defer c.featureMu.Unlock()
+	for _, f := range c.features {
+		if f == feature {
+			return c.featureKey, true
 		}
 	}
+	return "", false
+}
 
-	params := url.Values{}
-	params.Add("q", queryCity)
-	params.Add("appid", c.apiKey)
-	params.Add("units", "metric")
-	params.Add("lang", "zh_cn")
+// OneCallCurrentResponse One Call API 3.0 实时天气响应结构，只解析需要的字段
+type OneCallCurrentResponse struct {
+	Current struct {
+		UVI float64 `json:"uvi"`
+	} `json:"current"`
+}
 
-	resp, err := c.client.Get(fmt.Sprintf("%s/weather?%s", c.baseURL, params.Encode()))
+// withUVIndex 已探测到密钥可用 One Call API 3.0 时补充实时紫外线指数
+// 实时天气接口不提供紫外线指数；未探测或查询失败时保持为空，不影响实时天气结果。
+// One Call 请求固定使用探测通过的密钥，不随密钥池轮换；也不向密钥提供者报告状态码，订阅失效返回的401不会使密钥移出轮换
+func (c *OpenWeatherClient) withUVIndex(ctx context.Context, w *weather.Weather) *weather.Weather {
+	key, ok := c.featureKeyFor(FeatureOneCall)
+	if !ok {
+		return w
+	}
+	params := coordParams(w.Location.Lat, w.Location.Lon)
+	params.Set("exclude", "minutely,hourly,daily,alerts")
+	body, _, err := c.request(ctx, key, c.oneCallURL, "onecall", params, false)
+	if err == nil {
+		var resp OneCallCurrentResponse
+		if err = json.Unmarshal(body, &resp); err == nil {
+			uv := resp.Current.UVI
+			w.Current.UVIndex = &uv
+			return w
+		}
+	}
+	slog.DebugContext(ctx, "failed to fetch UV index", "provider", ProviderName, "error", err)
+	return w
+}
+
+// GetCurrentWeather 获取当前天气
+func (c *OpenWeatherClient) GetCurrentWeather(ctx context.Context, lat, lon float64) (*weather.Weather, error) {
+	resp, err := fetch[OpenWeatherResponse](ctx, c, c.baseURL, "weather", localized(coordParams(lat, lon)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch weather data: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return c.withUVIndex(ctx, c.convertToWeather(resp)), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+// GetWeatherByCity 根据城市名获取天气
+func (c *OpenWeatherClient) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	resp, err := fetchCity[OpenWeatherResponse](ctx, c, "weather", city)
+	if err != nil {
+		return nil, err
 	}
+	return c.withUVIndex(ctx, c.convertToWeather(resp)), nil
+}
 
-	var apiResp OpenWeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// GetWeatherByCityID 根据城市ID获取天气
+func (c *OpenWeatherClient) GetWeatherByCityID(ctx context.Context, id int64) (*weather.Weather, error) {
+	resp, err := fetch[OpenWeatherResponse](ctx, c, c.baseURL, "weather", localized(cityIDParams(id)))
+	if err != nil {
+		return nil, err
 	}
+	return c.withUVIndex(ctx, c.convertToWeather(resp)), nil
+}
 
-	return c.convertToWeather(&apiResp), nil
+// GetWeatherByPostalCode 根据邮政编码获取天气
+func (c *OpenWeatherClient) GetWeatherByPostalCode(ctx context.Context, code weather.PostalCode) (*weather.Weather, error) {
+	resp, err := fetch[OpenWeatherResponse](ctx, c, c.baseURL, "weather", localized(postalCodeParams(code)))
+	if err != nil {
+		return nil, err
+	}
+	return c.withUVIndex(ctx, c.convertToWeather(resp)), nil
 }
 
 // OpenWeatherResponse OpenWeatherMap API响应结构
@@ -116,16 +282,20 @@ type OpenWeatherResponse struct {
 	Sys struct {
 		Country string `json:"country"`
 	} `json:"sys"`
-	Name string `json:"name"`
-	Dt   int64  `json:"dt"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Dt       int64  `json:"dt"`
+	Timezone int    `json:"timezone"`
 }
 
 // ForecastAPIResponse OpenWeatherMap 预报API响应结构
 type ForecastAPIResponse struct {
 	City struct {
-		Name    string `json:"name"`
-		Country string `json:"country"`
-		Coord   struct {
+		ID       int64  `json:"id"`
+		Name     string `json:"name"`
+		Country  string `json:"country"`
+		Timezone int    `json:"timezone"`
+		Coord    struct {
 			Lat float64 `json:"lat"`
 			Lon float64 `json:"lon"`
 		} `json:"coord"`
@@ -146,7 +316,23 @@ type ForecastAPIResponse struct {
 			Speed float64 `json:"speed"`
 			Deg   int     `json:"deg"`
 		} `json:"wind"`
+		Visibility *int    `json:"visibility"` // 部分预报时段不返回
+		Pop        float64 `json:"pop"`
+		Rain       struct {
+			ThreeHour float64 `json:"3h"`
+		} `json:"rain"`
+		Snow struct {
+			ThreeHour float64 `json:"3h"`
+		} `json:"snow"`
 	} `json:"list"`
+
+	// responseTime 收到响应的时间，预报接口不返回数据计算时间，以此作为更新时间
+	responseTime time.Time
+}
+
+// setResponseTime 实现 timedResponse
+func (r *ForecastAPIResponse) setResponseTime(t time.Time) {
+	r.responseTime = t
 }
 
 // convertToWeather 将API响应转换为领域模型
@@ -158,14 +344,17 @@ func (c *OpenWeatherClient) convertToWeather(resp *OpenWeatherResponse) *weather
 	}
 
 	windDir := getWindDirection(resp.Wind.Deg)
+	location := weather.Location{
+		ID:        resp.ID,
+		City:      resp.Name,
+		Country:   resp.Sys.Country,
+		Lat:       resp.Coord.Lat,
+		Lon:       resp.Coord.Lon,
+		UTCOffset: resp.Timezone,
+	}
 
 	return &weather.Weather{
-		Location: weather.Location{
-			City:    resp.Name,
-			Country: resp.Sys.Country,
-			Lat:     resp.Coord.Lat,
-			Lon:     resp.Coord.Lon,
-		},
+		Location: location,
 		Current: weather.CurrentWeather{
 			Temperature: resp.Main.Temp,
 			FeelsLike:   resp.Main.FeelsLike,
@@ -176,109 +365,52 @@ func (c *OpenWeatherClient) convertToWeather(resp *OpenWeatherResponse) *weather
 			Description: description,
 			Icon:        icon,
 		},
-		LastUpdated: time.Unix(resp.Dt, 0),
+		LastUpdated: location.LocalTime(time.Unix(resp.Dt, 0)),
 	}
 }
 
 // GetHourlyWeatherByCoords 获取未来小时天气预报（经纬度）
 // 注意：OpenWeatherMap 的 /forecast API 返回的是3小时间隔的数据
 // 例如：请求3小时会返回 [当前+3h, 当前+6h, 当前+9h] 的数据
-func (c *OpenWeatherClient) GetHourlyWeatherByCoords(lat, lon float64, hours int) (*weather.HourlyWeatherResult, error) {
-	params := url.Values{}
-	params.Add("lat", strconv.FormatFloat(lat, 'f', -1, 64))
-	params.Add("lon", strconv.FormatFloat(lon, 'f', -1, 64))
-	params.Add("appid", c.apiKey)
-	params.Add("units", "metric")
-	params.Add("lang", "zh_cn")
-
-	resp, err := c.client.Get(fmt.Sprintf("%s/forecast?%s", c.baseURL, params.Encode()))
+func (c *OpenWeatherClient) GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*weather.HourlyWeatherResult, error) {
+	resp, err := fetch[ForecastAPIResponse](ctx, c, c.baseURL, "forecast", localized(coordParams(lat, lon)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch forecast data: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, err
 	}
-
-	var apiResp ForecastAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode forecast response: %w", err)
-	}
-
-	// 计算需要多少个3小时间隔的数据点
-	// 例如：请求6小时 = 需要2个数据点 (6/3=2)
-	dataPoints := (hours + 2) / 3 // 向上取整
-	if dataPoints > len(apiResp.List) {
-		dataPoints = len(apiResp.List)
-	}
-
-	hourly := make([]weather.HourlyWeather, 0, dataPoints)
-	for i := 0; i < dataPoints; i++ {
-		item := apiResp.List[i]
-		var desc, icon string
-		if len(item.Weather) > 0 {
-			desc = item.Weather[0].Description
-			icon = item.Weather[0].Icon
-		}
-		windDir := getWindDirection(item.Wind.Deg)
-		hourly = append(hourly, weather.HourlyWeather{
-			Date:        time.Unix(item.Dt, 0),
-			Temperature: item.Main.Temp,
-			FeelsLike:   item.Main.FeelsLike,
-			Humidity:    item.Main.Humidity,
-			Pressure:    item.Main.Pressure,
-			WindSpeed:   item.Wind.Speed,
-			WindDir:     windDir,
-			Description: desc,
-			Icon:        icon,
-		})
-	}
-
-	return &weather.HourlyWeatherResult{
-		Location: weather.Location{
-			City:    apiResp.City.Name,
-			Country: apiResp.City.Country,
-			Lat:     apiResp.City.Coord.Lat,
-			Lon:     apiResp.City.Coord.Lon,
-		},
-		Hourly:      hourly,
-		LastUpdated: time.Now(),
-	}, nil
+	return convertForecast(resp, hours), nil
 }
 
 // GetHourlyWeatherByCity 获取未来小时天气预报（城市名）
 // 注意：OpenWeatherMap 的 /forecast API 返回的是3小时间隔的数据
 // 例如：请求3小时会返回 [当前+3h, 当前+6h, 当前+9h] 的数据
-func (c *OpenWeatherClient) GetHourlyWeatherByCity(city string, hours int) (*weather.HourlyWeatherResult, error) {
-	// 检查是否为中文城市名，如果是则转换为英文
-	queryCity := city
-	if c.cityMapping.IsChineseCity(city) {
-		if englishName, exists := c.cityMapping.GetEnglishName(city); exists {
-			queryCity = englishName
-		}
-	}
-	params := url.Values{}
-	params.Add("q", queryCity)
-	params.Add("appid", c.apiKey)
-	params.Add("units", "metric")
-	params.Add("lang", "zh_cn")
-
-	resp, err := c.client.Get(fmt.Sprintf("%s/forecast?%s", c.baseURL, params.Encode()))
+func (c *OpenWeatherClient) GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*weather.HourlyWeatherResult, error) {
+	resp, err := fetchCity[ForecastAPIResponse](ctx, c, "forecast", city)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch forecast data: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return convertForecast(resp, hours), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+// GetHourlyWeatherByCityID 获取未来小时天气预报（城市ID）
+func (c *OpenWeatherClient) GetHourlyWeatherByCityID(ctx context.Context, id int64, hours int) (*weather.HourlyWeatherResult, error) {
+	resp, err := fetch[ForecastAPIResponse](ctx, c, c.baseURL, "forecast", localized(cityIDParams(id)))
+	if err != nil {
+		return nil, err
 	}
+	return convertForecast(resp, hours), nil
+}
 
-	var apiResp ForecastAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode forecast response: %w", err)
+// GetHourlyWeatherByPostalCode 获取未来小时天气预报（邮政编码）
+func (c *OpenWeatherClient) GetHourlyWeatherByPostalCode(ctx context.Context, code weather.PostalCode, hours int) (*weather.HourlyWeatherResult, error) {
+	resp, err := fetch[ForecastAPIResponse](ctx, c, c.baseURL, "forecast", localized(postalCodeParams(code)))
+	if err != nil {
+		return nil, err
 	}
+	return convertForecast(resp, hours), nil
+}
 
+// convertForecast 将预报API响应转换为领域模型，取覆盖请求小时数的数据点
+func convertForecast(apiResp *ForecastAPIResponse, hours int) *weather.HourlyWeatherResult {
 	// 计算需要多少个3小时间隔的数据点
 	// 例如：请求6小时 = 需要2个数据点 (6/3=2)
 	dataPoints := (hours + 2) / 3 // 向上取整
@@ -286,6 +418,15 @@ func (c *OpenWeatherClient) GetHourlyWeatherByCity(city string, hours int) (*wea
 		dataPoints = len(apiResp.List)
 	}
 
+	location := weather.Location{
+		ID:        apiResp.City.ID,
+		City:      apiResp.City.Name,
+		Country:   apiResp.City.Country,
+		Lat:       apiResp.City.Coord.Lat,
+		Lon:       apiResp.City.Coord.Lon,
+		UTCOffset: apiResp.City.Timezone,
+	}
+
 	hourly := make([]weather.HourlyWeather, 0, dataPoints)
 	for i := 0; i < dataPoints; i++ {
 		item := apiResp.List[i]
@@ -295,34 +436,99 @@ func (c *OpenWeatherClient) GetHourlyWeatherByCity(city string, hours int) (*wea
 			icon = item.Weather[0].Icon
 		}
 		windDir := getWindDirection(item.Wind.Deg)
+		precipAmount, precipType := getPrecipitation(item.Rain.ThreeHour, item.Snow.ThreeHour)
 		hourly = append(hourly, weather.HourlyWeather{
-			Date:        time.Unix(item.Dt, 0),
-			Temperature: item.Main.Temp,
-			FeelsLike:   item.Main.FeelsLike,
-			Humidity:    item.Main.Humidity,
-			Pressure:    item.Main.Pressure,
-			WindSpeed:   item.Wind.Speed,
-			WindDir:     windDir,
-			Description: desc,
-			Icon:        icon,
+			Date:              location.LocalTime(time.Unix(item.Dt, 0)),
+			Temperature:       item.Main.Temp,
+			FeelsLike:         item.Main.FeelsLike,
+			Humidity:          item.Main.Humidity,
+			Pressure:          item.Main.Pressure,
+			WindSpeed:         item.Wind.Speed,
+			WindDir:           windDir,
+			Description:       desc,
+			Icon:              icon,
+			PrecipProbability: item.Pop,
+			PrecipAmount:      precipAmount,
+			PrecipType:        precipType,
+			Visibility:        item.Visibility,
 		})
 	}
 
+	// 预报接口不返回数据计算时间，使用响应时间并转换到位置所在时区
+	lastUpdated := apiResp.responseTime
+	if lastUpdated.IsZero() {
+		lastUpdated = time.Now()
+	}
 	return &weather.HourlyWeatherResult{
-		Location: weather.Location{
-			City:    apiResp.City.Name,
-			Country: apiResp.City.Country,
-			Lat:     apiResp.City.Coord.Lat,
-			Lon:     apiResp.City.Coord.Lon,
-		},
+		Location:    location,
 		Hourly:      hourly,
-		LastUpdated: time.Now(),
+		LastUpdated: location.LocalTime(lastUpdated),
+	}
+}
+
+// AirPollutionAPIResponse OpenWeatherMap 空气污染API响应结构
+type AirPollutionAPIResponse struct {
+	List []struct {
+		Dt   int64 `json:"dt"`
+		Main struct {
+			AQI int `json:"aqi"`
+		} `json:"main"`
+		Components struct {
+			CO   float64 `json:"co"`
+			NO2  float64 `json:"no2"`
+			O3   float64 `json:"o3"`
+			SO2  float64 `json:"so2"`
+			PM25 float64 `json:"pm2_5"`
+			PM10 float64 `json:"pm10"`
+		} `json:"components"`
+	} `json:"list"`
+}
+
+// GetAirQuality 获取当前空气质量（经纬度）
+func (c *OpenWeatherClient) GetAirQuality(ctx context.Context, lat, lon float64) (*weather.AirQuality, error) {
+	apiResp, err := fetch[AirPollutionAPIResponse](ctx, c, c.baseURL, "air_pollution", coordParams(lat, lon))
+	if err != nil {
+		return nil, err
+	}
+	if len(apiResp.List) == 0 {
+		return nil, fmt.Errorf("no air pollution data available")
+	}
+
+	item := apiResp.List[0]
+	return &weather.AirQuality{
+		AQI:        item.Main.AQI,
+		PM25:       item.Components.PM25,
+		PM10:       item.Components.PM10,
+		O3:         item.Components.O3,
+		NO2:        item.Components.NO2,
+		SO2:        item.Components.SO2,
+		CO:         item.Components.CO,
+		MeasuredAt: time.Unix(item.Dt, 0).UTC(),
 	}, nil
 }
 
+// KnownLocations 获取内置城市映射中的已知位置
+func (c *OpenWeatherClient) KnownLocations() []string {
+	return c.cityMapping.KnownCities()
+}
+
 // getWindDirection 根据角度获取风向
 func getWindDirection(deg int) string {
 	directions := []string{"北", "东北", "东", "东南", "南", "西南", "西", "西北"}
 	index := (deg + 22) / 45 % 8
 	return directions[index]
 }
+
+// getPrecipitation 根据降雨量和降雪量计算总降水量及降水类型
+func getPrecipitation(rain, snow float64) (float64, weather.PrecipitationType) {
+	switch {
+	case rain > 0 && snow > 0:
+		return rain + snow, weather.PrecipitationMixed
+	case rain > 0:
+		return rain, weather.PrecipitationRain
+	case snow > 0:
+		return snow, weather.PrecipitationSnow
+	default:
+		return 0, weather.PrecipitationNone
+	}
+}
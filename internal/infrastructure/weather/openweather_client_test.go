@@ -1,16 +1,26 @@
 package weather
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/httpfixture"
 )
 
 func TestNewOpenWeatherClient(t *testing.T) {
 	apiKey := "test_api_key"
 	client := NewOpenWeatherClient(apiKey)
 
-	if client.apiKey != apiKey {
-		t.Errorf("Expected API key %s, got %s", apiKey, client.apiKey)
+	if key, _ := client.keys.Key(); key != apiKey {
+		t.Errorf("Expected API key %s, got %s", apiKey, key)
 	}
 
 	if client.baseURL != "https://api.openweathermap.org/data/2.5" {
@@ -93,8 +103,9 @@ func TestConvertToWeather(t *testing.T) {
 		}{
 			Country: "CN",
 		},
-		Name: "北京",
-		Dt:   1642248600, // 2022-01-15 14:30:00 UTC
+		Name:     "北京",
+		Dt:       1642248600, // 2022-01-15 14:30:00 UTC
+		Timezone: 28800,
 	}
 
 	weather := client.convertToWeather(resp)
@@ -144,4 +155,515 @@ func TestConvertToWeather(t *testing.T) {
 	if !weather.LastUpdated.Equal(expectedTime) {
 		t.Errorf("Expected time %v, got %v", expectedTime, weather.LastUpdated)
 	}
+	if weather.Location.UTCOffset != 28800 {
+		t.Errorf("Expected UTC offset %d, got %d", 28800, weather.Location.UTCOffset)
+	}
+	if got := weather.LastUpdated.Format(time.RFC3339); got != "2022-01-15T20:10:00+08:00" {
+		t.Errorf("Expected local time %s, got %s", "2022-01-15T20:10:00+08:00", got)
+	}
+}
+
+func TestGetPrecipitation(t *testing.T) {
+	tests := []struct {
+		rain           float64
+		snow           float64
+		expectedAmount float64
+		expectedType   weather.PrecipitationType
+	}{
+		{0, 0, 0, weather.PrecipitationNone},
+		{1.5, 0, 1.5, weather.PrecipitationRain},
+		{0, 0.75, 0.75, weather.PrecipitationSnow},
+		{1.5, 0.5, 2, weather.PrecipitationMixed},
+	}
+
+	for _, test := range tests {
+		amount, precipType := getPrecipitation(test.rain, test.snow)
+		if amount != test.expectedAmount {
+			t.Errorf("For rain %f snow %f, expected amount %f, got %f", test.rain, test.snow, test.expectedAmount, amount)
+		}
+		if precipType != test.expectedType {
+			t.Errorf("For rain %f snow %f, expected type %s, got %s", test.rain, test.snow, test.expectedType, precipType)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		reachable bool
+		keyValid  bool
+	}{
+		{"ok", http.StatusOK, true, true},
+		{"invalid key", http.StatusUnauthorized, true, false},
+		{"rate limited", http.StatusTooManyRequests, true, true},
+		{"unreachable", 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("appid") != "test-key" {
+					t.Errorf("Expected API key in probe request")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			client := NewOpenWeatherClient("test-key")
+			client.baseURL = upstream.URL
+			if tt.status == 0 {
+				upstream.Close()
+			} else {
+				defer upstream.Close()
+			}
+
+			probe := client.Probe(context.Background())
+			if probe.Reachable != tt.reachable || probe.KeyValid != tt.keyValid {
+				t.Errorf("Expected reachable=%v keyValid=%v, got %+v", tt.reachable, tt.keyValid, probe)
+			}
+			if strings.Contains(probe.Error, "test-key") {
+				t.Errorf("Expected API key to be redacted from probe error, got %s", probe.Error)
+			}
+		})
+	}
+}
+
+func TestCityQueryPrefersUpstreamOverSpellingSuggestion(t *testing.T) {
+	// 上游只认识 Banning 和 Beijing
+	var queries []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		queries = append(queries, q)
+		if q != "Banning" && q != "Beijing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"cod":"404","message":"city not found"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"` + q + `","weather":[{"description":"晴"}],"list":[]}`))
+	}))
+	defer upstream.Close()
+	client := NewOpenWeatherClient("test-key")
+	client.baseURL = upstream.URL
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"Banning", []string{"Banning"}},
+		{"Bejing", []string{"Bejing", "Beijing"}},
+		{"Atlantis", []string{"Atlantis"}},
+	}
+	for _, tt := range tests {
+		queries = nil
+		_, err := client.GetWeatherByCity(context.Background(), tt.input)
+		if tt.input == "Atlantis" {
+			if err == nil || !strings.Contains(err.Error(), "status: 404") {
+				t.Errorf("Expected 404 for unknown city, got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+		}
+		if strings.Join(queries, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("%s: expected upstream queries %v, got %v", tt.input, tt.expected, queries)
+		}
+	}
+
+	queries = nil
+	if _, err := client.GetHourlyWeatherByCity(context.Background(), "Bejing", 3); err != nil {
+		t.Errorf("Unexpected forecast error: %v", err)
+	}
+	if strings.Join(queries, ",") != "Bejing,Beijing" {
+		t.Errorf("Expected forecast to retry with the suggested city, got %v", queries)
+	}
+}
+
+func TestCurrentWeatherUVIndexFromOneCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		detect   bool
+		expected *float64
+	}{
+		{"paid key after detection", "paid", true, floatPtr(6.5)},
+		{"paid key before detection", "paid", false, nil},
+		{"free key", "free", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var oneCalls int
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/onecall" && r.URL.Query().Get("appid") != "paid":
+					w.WriteHeader(http.StatusUnauthorized)
+				case r.URL.Path == "/onecall":
+					oneCalls++
+					w.Write([]byte(`{"current":{"uvi":6.5}}`))
+				default:
+					w.Write([]byte(`{"name":"Shenzhen","coord":{"lat":22.54,"lon":114.06}}`))
+				}
+			}))
+			defer upstream.Close()
+			client := NewOpenWeatherClient(tt.key)
+			client.baseURL, client.oneCallURL = upstream.URL, upstream.URL
+
+			if tt.detect {
+				client.Features(context.Background())
+			}
+			oneCalls = 0
+			w, err := client.GetWeatherByCity(context.Background(), "Shenzhen")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			switch {
+			case tt.expected == nil && w.Current.UVIndex != nil:
+				t.Errorf("Expected no UV index, got %v", *w.Current.UVIndex)
+			case tt.expected != nil && (w.Current.UVIndex == nil || *w.Current.UVIndex != *tt.expected):
+				t.Errorf("Expected UV index %v, got %v", *tt.expected, w.Current.UVIndex)
+			}
+			if tt.expected == nil && oneCalls != 0 {
+				t.Errorf("Expected no One Call request, got %d", oneCalls)
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// recordFixturesEnv 设置后向真实API重新录制夹具，需要同时提供 OPENWEATHER_API_KEY
+const recordFixturesEnv = "WEATHER_RECORD_FIXTURES"
+
+// fixtureClient 创建使用录制夹具的客户端
+// 设置 WEATHER_RECORD_FIXTURES=1 时访问真实API并覆盖夹具文件，否则离线回放
+func fixtureClient(t *testing.T, name string) *OpenWeatherClient {
+	t.Helper()
+	if os.Getenv(recordFixturesEnv) == "" {
+		return replayClient(t, name)
+	}
+	apiKey := os.Getenv("OPENWEATHER_API_KEY")
+	if apiKey == "" {
+		t.Skipf("%s requires OPENWEATHER_API_KEY", recordFixturesEnv)
+	}
+	recorder, err := httpfixture.New(fixturePath(name), httpfixture.WithMode(httpfixture.ModeRecord))
+	if err != nil {
+		t.Fatalf("Expected no error creating recorder, got %v", err)
+	}
+	t.Cleanup(func() {
+		if err := recorder.Save(); err != nil {
+			t.Errorf("Expected no error saving fixture, got %v", err)
+		}
+	})
+	return NewOpenWeatherClient(apiKey, WithTransport(recorder))
+}
+
+// replayClient 创建回放夹具的客户端，用于无法从真实API录制的人工构造夹具
+// 测试结束时检查夹具中的请求都已被使用，确保请求构造与夹具一致
+func replayClient(t *testing.T, name string) *OpenWeatherClient {
+	t.Helper()
+	replayer, err := httpfixture.New(fixturePath(name))
+	if err != nil {
+		t.Fatalf("Expected no error loading fixture, got %v", err)
+	}
+	t.Cleanup(func() {
+		if unused := replayer.Unused(); len(unused) != 0 {
+			t.Errorf("Expected all recorded requests to be made, unused: %v", unused)
+		}
+	})
+	return NewOpenWeatherClient("test-key", WithTransport(replayer))
+}
+
+func fixturePath(name string) string {
+	return filepath.Join("testdata", "fixtures", name+".json")
+}
+
+// 夹具中使用的位置
+const (
+	fixtureLat  = 22.5431
+	fixtureLon  = 114.0579
+	fixtureCity = "深圳"
+)
+
+// repositoryCalls 依次调用 WeatherRepository 的四个方法，顺序与人工构造夹具中的请求一致
+var repositoryCalls = []struct {
+	name string
+	call func(ctx context.Context, c *OpenWeatherClient) error
+}{
+	{"GetCurrentWeather", func(ctx context.Context, c *OpenWeatherClient) error {
+		_, err := c.GetCurrentWeather(ctx, fixtureLat, fixtureLon)
+		return err
+	}},
+	{"GetWeatherByCity", func(ctx context.Context, c *OpenWeatherClient) error {
+		_, err := c.GetWeatherByCity(ctx, fixtureCity)
+		return err
+	}},
+	{"GetHourlyWeatherByCoords", func(ctx context.Context, c *OpenWeatherClient) error {
+		_, err := c.GetHourlyWeatherByCoords(ctx, fixtureLat, fixtureLon, 24)
+		return err
+	}},
+	{"GetHourlyWeatherByCity", func(ctx context.Context, c *OpenWeatherClient) error {
+		_, err := c.GetHourlyWeatherByCity(ctx, fixtureCity, 24)
+		return err
+	}},
+}
+
+func TestCurrentWeatherFixture(t *testing.T) {
+	client := fixtureClient(t, "current_weather")
+	ctx := context.Background()
+
+	byCoords, err := client.GetCurrentWeather(ctx, fixtureLat, fixtureLon)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	byCity, err := client.GetWeatherByCity(ctx, fixtureCity)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, w := range []*weather.Weather{byCoords, byCity} {
+		if w.Location.City != "Shenzhen" || w.Location.Country != "CN" {
+			t.Errorf("Expected Shenzhen, CN, got %s, %s", w.Location.City, w.Location.Country)
+		}
+		if w.Location.Lat != fixtureLat || w.Location.Lon != fixtureLon {
+			t.Errorf("Expected coordinates %v,%v, got %v,%v", fixtureLat, fixtureLon, w.Location.Lat, w.Location.Lon)
+		}
+		if w.Location.UTCOffset != 8*3600 {
+			t.Errorf("Expected UTC offset %d, got %d", 8*3600, w.Location.UTCOffset)
+		}
+		if w.Current.Temperature != 28.97 || w.Current.FeelsLike != 33.6 {
+			t.Errorf("Expected temperature 28.97 feels like 33.6, got %v feels like %v", w.Current.Temperature, w.Current.FeelsLike)
+		}
+		if w.Current.Humidity != 74 || w.Current.Pressure != 1008 {
+			t.Errorf("Expected humidity 74 pressure 1008, got %d %d", w.Current.Humidity, w.Current.Pressure)
+		}
+		if w.Current.WindSpeed != 4.12 || w.Current.WindDir != "东南" {
+			t.Errorf("Expected wind 4.12 东南, got %v %s", w.Current.WindSpeed, w.Current.WindDir)
+		}
+		if w.Current.Description != "多云" || w.Current.Icon != "04d" {
+			t.Errorf("Expected description 多云 icon 04d, got %s %s", w.Current.Description, w.Current.Icon)
+		}
+		expectedUpdated := time.Unix(1718604000, 0)
+		if !w.LastUpdated.Equal(expectedUpdated) {
+			t.Errorf("Expected last updated %v, got %v", expectedUpdated, w.LastUpdated)
+		}
+		if _, offset := w.LastUpdated.Zone(); offset != 8*3600 {
+			t.Errorf("Expected last updated in location time zone, got offset %d", offset)
+		}
+	}
+}
+
+func TestForecastFixture(t *testing.T) {
+	client := fixtureClient(t, "forecast")
+	ctx := context.Background()
+
+	byCoords, err := client.GetHourlyWeatherByCoords(ctx, fixtureLat, fixtureLon, 24)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// 请求超过预报范围的小时数时返回全部数据点
+	byCity, err := client.GetHourlyWeatherByCity(ctx, fixtureCity, 200)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		result   *weather.HourlyWeatherResult
+		expected int
+	}{
+		{"by coords", byCoords, 8},
+		{"by city", byCity, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hw := tt.result
+			if len(hw.Hourly) != tt.expected {
+				t.Fatalf("Expected %d forecast points, got %d", tt.expected, len(hw.Hourly))
+			}
+			if hw.Location.City != "Shenzhen" || hw.Location.UTCOffset != 8*3600 {
+				t.Errorf("Expected Shenzhen at UTC+8, got %s at %d", hw.Location.City, hw.Location.UTCOffset)
+			}
+
+			first := hw.Hourly[0]
+			expectedDate := time.Unix(1718614800, 0)
+			if !first.Date.Equal(expectedDate) {
+				t.Errorf("Expected first point at %v, got %v", expectedDate, first.Date)
+			}
+			if first.Temperature != 27.5 || first.Humidity != 70 || first.Pressure != 1007 {
+				t.Errorf("Expected 27.5°C 70%% 1007hPa, got %v°C %d%% %dhPa", first.Temperature, first.Humidity, first.Pressure)
+			}
+			if first.Description != "小雨" || first.WindDir != "东南" {
+				t.Errorf("Expected 小雨 with 东南 wind, got %s with %s wind", first.Description, first.WindDir)
+			}
+			if first.PrecipType != weather.PrecipitationRain || first.PrecipAmount != 0.45 || first.PrecipProbability != 0.2 {
+				t.Errorf("Expected rain 0.45mm at 20%%, got %s %vmm at %v", first.PrecipType, first.PrecipAmount, first.PrecipProbability)
+			}
+
+			// 多云时段没有降水
+			cloudy := hw.Hourly[6]
+			if cloudy.Description != "多云" || cloudy.PrecipType != weather.PrecipitationNone || cloudy.PrecipAmount != 0 {
+				t.Errorf("Expected 多云 without precipitation, got %s %s %v", cloudy.Description, cloudy.PrecipType, cloudy.PrecipAmount)
+			}
+			for i := 1; i < len(hw.Hourly); i++ {
+				if gap := hw.Hourly[i].Date.Sub(hw.Hourly[i-1].Date); gap != 3*time.Hour {
+					t.Errorf("Expected 3h between forecast points, got %v at index %d", gap, i)
+				}
+			}
+		})
+	}
+}
+
+func TestForecastLastUpdatedFromResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Mon, 17 Jun 2024 06:00:00 GMT")
+		w.Write([]byte(`{"city":{"name":"Shenzhen","timezone":28800},"list":[{"dt":1718614800}]}`))
+	}))
+	defer upstream.Close()
+	client := NewOpenWeatherClient("key")
+	client.baseURL = upstream.URL
+
+	hw, err := client.GetHourlyWeatherByCoords(context.Background(), 22.54, 114.06, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := time.Date(2024, 6, 17, 6, 0, 0, 0, time.UTC)
+	if !hw.LastUpdated.Equal(expected) {
+		t.Errorf("Expected last updated %v from the response Date header, got %v", expected, hw.LastUpdated)
+	}
+	if _, offset := hw.LastUpdated.Zone(); offset != 8*3600 {
+		t.Errorf("Expected last updated in location time zone, got offset %d", offset)
+	}
+}
+
+func TestConvertForecastMissingVisibility(t *testing.T) {
+	var resp ForecastAPIResponse
+	body := `{"city":{"name":"Shenzhen","timezone":28800},"list":[{"dt":1718614800,"visibility":8000},{"dt":1718625600}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	hw := convertForecast(&resp, 6)
+	if v := hw.Hourly[0].Visibility; v == nil || *v != 8000 {
+		t.Errorf("Expected visibility 8000m, got %v", v)
+	}
+	if v := hw.Hourly[1].Visibility; v != nil {
+		t.Errorf("Expected missing visibility to stay empty, got %d", *v)
+	}
+}
+
+func TestPlaceLookupFixture(t *testing.T) {
+	client := replayClient(t, "place_lookup")
+	ctx := context.Background()
+	code := weather.PostalCode{Code: "518000", Country: "CN"}
+
+	byID, err := client.GetWeatherByCityID(ctx, 1795565)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	hourlyByID, err := client.GetHourlyWeatherByCityID(ctx, 1795565, 6)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	byCode, err := client.GetWeatherByPostalCode(ctx, code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	hourlyByCode, err := client.GetHourlyWeatherByPostalCode(ctx, code, 6)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, loc := range []weather.Location{byID.Location, hourlyByID.Location, byCode.Location, hourlyByCode.Location} {
+		if loc.ID != 1795565 || loc.City != "Shenzhen" {
+			t.Errorf("Expected Shenzhen with ID 1795565, got %s with ID %d", loc.City, loc.ID)
+		}
+	}
+	if len(hourlyByID.Hourly) != 2 || len(hourlyByCode.Hourly) != 2 {
+		t.Errorf("Expected 2 forecast points, got %d and %d", len(hourlyByID.Hourly), len(hourlyByCode.Hourly))
+	}
+}
+
+func TestRepositoryErrorStatuses(t *testing.T) {
+	tests := []struct {
+		fixture string
+		status  string
+	}{
+		{"status_unauthorized", "status: 401"},
+		{"status_not_found", "status: 404"},
+		{"status_rate_limited", "status: 429"},
+		{"status_server_error", "status: 502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			client := replayClient(t, tt.fixture)
+			for _, rc := range repositoryCalls {
+				err := rc.call(context.Background(), client)
+				if err == nil {
+					t.Errorf("%s: expected error for %s", rc.name, tt.status)
+					continue
+				}
+				if !strings.Contains(err.Error(), tt.status) {
+					t.Errorf("%s: expected error containing %q, got %v", rc.name, tt.status, err)
+				}
+				if strings.Contains(err.Error(), "test-key") {
+					t.Errorf("%s: expected API key to be redacted from error, got %v", rc.name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryMalformedJSON(t *testing.T) {
+	client := replayClient(t, "malformed_json")
+	for _, rc := range repositoryCalls {
+		err := rc.call(context.Background(), client)
+		if err == nil {
+			t.Errorf("%s: expected error for malformed response", rc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "failed to decode") {
+			t.Errorf("%s: expected decode error, got %v", rc.name, err)
+		}
+	}
+}
+
+func TestRepositoryTruncatedLists(t *testing.T) {
+	client := replayClient(t, "truncated_lists")
+	ctx := context.Background()
+
+	// 天气状况列表为空时描述和图标留空
+	for _, fetch := range []func() (*weather.Weather, error){
+		func() (*weather.Weather, error) { return client.GetCurrentWeather(ctx, fixtureLat, fixtureLon) },
+		func() (*weather.Weather, error) { return client.GetWeatherByCity(ctx, fixtureCity) },
+	} {
+		w, err := fetch()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if w.Current.Description != "" || w.Current.Icon != "" {
+			t.Errorf("Expected empty description and icon, got %q %q", w.Current.Description, w.Current.Icon)
+		}
+		if w.Current.Temperature != 28.97 {
+			t.Errorf("Expected temperature 28.97, got %v", w.Current.Temperature)
+		}
+	}
+
+	// 预报数据点少于请求的小时数时返回已有的数据点
+	hw, err := client.GetHourlyWeatherByCoords(ctx, fixtureLat, fixtureLon, 24)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(hw.Hourly) != 2 {
+		t.Errorf("Expected 2 forecast points, got %d", len(hw.Hourly))
+	}
+
+	hw, err = client.GetHourlyWeatherByCity(ctx, fixtureCity, 24)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(hw.Hourly) != 0 {
+		t.Errorf("Expected no forecast points, got %d", len(hw.Hourly))
+	}
+	if hw.Location.City != "Shenzhen" {
+		t.Errorf("Expected location Shenzhen, got %s", hw.Location.City)
+	}
 }
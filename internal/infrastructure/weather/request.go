@@ -0,0 +1,191 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+	"weather-mcp-server/internal/infrastructure/logging"
+)
+
+// maxResponseBytes 上游响应体的最大字节数，防止异常响应耗尽内存
+// 5天预报约16KB，留有充足余量
+const maxResponseBytes = 1 << 20
+
+// StatusError 上游接口返回了非200状态码
+type StatusError struct {
+	StatusCode int
+	// Message 上游错误响应中的说明，无法解析时为空
+	Message string
+}
+
+// Error 实现 error
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("API request failed with status: %d (%s)", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("API request failed with status: %d", e.StatusCode)
+}
+
+// newStatusError 根据错误响应体构造状态码错误
+// OpenWeatherMap 的错误响应形如 {"cod":401,"message":"Invalid API key..."}，其他格式的响应体忽略
+func newStatusError(statusCode int, body []byte) *StatusError {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+	return &StatusError{StatusCode: statusCode, Message: logging.RedactString(apiErr.Message)}
+}
+
+// coordParams 按经纬度查询的公共参数
+func coordParams(lat, lon float64) url.Values {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	return params
+}
+
+// cityIDParams 按城市ID查询的参数
+func cityIDParams(id int64) url.Values {
+	return url.Values{"id": {strconv.FormatInt(id, 10)}}
+}
+
+// postalCodeParams 按邮政编码查询的参数，形如 zip=518000,CN
+func postalCodeParams(code weather.PostalCode) url.Values {
+	return url.Values{"zip": {code.String()}}
+}
+
+// localized 添加公制单位和中文描述参数
+func localized(params url.Values) url.Values {
+	params.Set("units", "metric")
+	params.Set("lang", "zh_cn")
+	return params
+}
+
+// fetch 请求上游接口并将JSON响应解码为 T
+// 所有接口共用同一请求流程：构造URL、注入API密钥、读取限定大小的响应体、处理错误状态码和解码
+// T 实现 timedResponse 时同时记录响应时间
+func fetch[T any](ctx context.Context, c *OpenWeatherClient, baseURL, endpoint string, params url.Values) (*T, error) {
+	body, date, err := c.execute(ctx, baseURL, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", endpoint, err)
+	}
+	if r, ok := any(&result).(timedResponse); ok {
+		r.setResponseTime(date)
+	}
+	return &result, nil
+}
+
+// timedResponse 需要记录响应时间的响应结构，如不返回数据计算时间的预报接口
+type timedResponse interface {
+	setResponseTime(t time.Time)
+}
+
+// fetchCity 按城市名请求上游接口并将JSON响应解码为 T
+// 上游按原名查不到（404）时，若城市名是拼音的拼写错误（如"Bejing"），改用拼写最接近的城市重试；
+// 先按原名查询使真实存在的外国地名（如"Banning"）不会被改写为拼写相近的中国城市
+func fetchCity[T any](ctx context.Context, c *OpenWeatherClient, endpoint, city string) (*T, error) {
+	resp, err := fetch[T](ctx, c, c.baseURL, endpoint, localized(url.Values{"q": {c.resolveCity(ctx, city)}}))
+	var statusErr *StatusError
+	if err == nil || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		return resp, err
+	}
+	m, ok := c.cityMapping.Suggest(city)
+	if !ok {
+		return nil, err
+	}
+	slog.DebugContext(ctx, "city not found, retrying with closest match",
+		"provider", ProviderName, "location", city, "query", m.City.Query, "distance", m.Distance)
+	return fetch[T](ctx, c, c.baseURL, endpoint, localized(url.Values{"q": {m.City.Query}}))
+}
+
+// execute 请求上游接口并返回响应体和响应时间，非200状态码返回 *StatusError
+// 使用密钥提供者当前的密钥，请求后报告响应状态码
+func (c *OpenWeatherClient) execute(ctx context.Context, baseURL, endpoint string, params url.Values) ([]byte, time.Time, error) {
+	key, err := c.keys.Key()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch %s data: %w", endpoint, err)
+	}
+	return c.request(ctx, key, baseURL, endpoint, params, true)
+}
+
+// executeFeatureProbe 与 execute 相同，但使用指定的密钥且不向密钥提供者报告响应状态码
+// 未订阅付费功能的密钥访问对应接口会返回401，但对免费接口仍然有效，不应因此移出轮换
+func (c *OpenWeatherClient) executeFeatureProbe(ctx context.Context, key, baseURL, endpoint string, params url.Values) ([]byte, error) {
+	body, _, err := c.request(ctx, key, baseURL, endpoint, params, false)
+	return body, err
+}
+
+// request 使用指定的密钥构造URL并请求上游接口，report 为 true 时向密钥提供者报告响应状态码
+// 响应时间取自响应的 Date 头，缺失或无法解析时使用收到响应的时间
+func (c *OpenWeatherClient) request(ctx context.Context, key, baseURL, endpoint string, params url.Values, report bool) ([]byte, time.Time, error) {
+	rawURL := baseURL + "/" + endpoint
+	if len(params) > 0 {
+		rawURL += "?" + params.Encode()
+	}
+	resp, err := c.get(ctx, rawURL, key, report)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch %s data: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		date = time.Now()
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read %s response: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, newStatusError(resp.StatusCode, body)
+	}
+	if len(body) > maxResponseBytes {
+		return nil, time.Time{}, fmt.Errorf("%s response exceeds %d bytes", endpoint, maxResponseBytes)
+	}
+	return body, date, nil
+}
+
+// get 发送携带上下文的GET请求并记录请求日志
+// API密钥加入查询参数，report 为 true 时请求后向密钥提供者报告响应状态码；
+// 日志和返回的错误中的URL均已去除API密钥
+func (c *OpenWeatherClient) get(ctx context.Context, rawURL, key string, report bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	query := req.URL.Query()
+	query.Set("appid", key)
+	req.URL.RawQuery = query.Encode()
+	redactedURL := logging.RedactURL(req.URL)
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactedURL
+		}
+		slog.WarnContext(ctx, "upstream request failed",
+			"provider", ProviderName, "url", redactedURL, "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+	if report {
+		c.keys.Report(key, resp.StatusCode)
+	}
+	slog.DebugContext(ctx, "upstream request",
+		"provider", ProviderName, "url", redactedURL, "status", resp.StatusCode, "duration", time.Since(start))
+	return resp, nil
+}
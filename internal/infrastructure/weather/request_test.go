@@ -0,0 +1,75 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewStatusError(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"owm message", `{"cod":404,"message":"city not found"}`, "API request failed with status: 404 (city not found)"},
+		{"empty body", ``, "API request failed with status: 404"},
+		{"html body", `<html>Bad Gateway</html>`, "API request failed with status: 404"},
+		{"key in message", `{"message":"invalid appid=test-key"}`, "API request failed with status: 404 (invalid appid=REDACTED)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newStatusError(http.StatusNotFound, []byte(tt.body))
+			if err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"ok", http.StatusOK, `{"name":"Shenzhen"}`, ""},
+		{"status error", http.StatusUnauthorized, `{"message":"Invalid API key"}`, "status: 401 (Invalid API key)"},
+		{"oversized body", http.StatusOK, strings.Repeat("x", maxResponseBytes+1), "exceeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/weather" || r.URL.Query().Get("q") != "Shenzhen" || r.URL.Query().Get("appid") != "test-key" {
+					t.Errorf("Unexpected request URL: %s", r.URL)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+			client := NewOpenWeatherClient("test-key")
+
+			resp, err := fetch[OpenWeatherResponse](context.Background(), client, upstream.URL, "weather", map[string][]string{"q": {"Shenzhen"}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if resp.Name != "Shenzhen" {
+					t.Errorf("Expected decoded name Shenzhen, got %s", resp.Name)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) != (tt.status != http.StatusOK) {
+				t.Errorf("Expected *StatusError only for non-200 responses, got %T", err)
+			}
+		})
+	}
+}
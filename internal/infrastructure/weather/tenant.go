@@ -0,0 +1,283 @@
+package weather
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"weather-mcp-server/internal/domain/weather"
+)
+
+// TenantHeader 客户端自带上游API密钥时使用的HTTP请求头
+const TenantHeader = "X-OpenWeather-API-Key"
+
+const (
+	// DefaultTenantRateLimit 每个租户每分钟的默认上游请求数（与免费套餐的限制一致）
+	DefaultTenantRateLimit = 60
+	// DefaultMaxTenants 默认最多同时保留的租户客户端数
+	DefaultMaxTenants = 100
+)
+
+// ErrTenantRateLimited 租户超出每分钟请求数限制
+var ErrTenantRateLimited = errors.New("tenant rate limit exceeded, please retry in a minute")
+
+// apiKeyKey 上下文中租户API密钥的键
+type apiKeyKey struct{}
+
+// WithAPIKey 在上下文中设置请求使用的上游API密钥
+func WithAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, apiKey)
+}
+
+// APIKeyFromContext 获取上下文中的上游API密钥，未设置时返回空字符串
+func APIKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(apiKeyKey{}).(string)
+	return key
+}
+
+// TenantID 由API密钥派生租户标识，日志和缓存中不出现密钥本身
+func TenantID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "tenant-" + hex.EncodeToString(sum[:6])
+}
+
+// tenant 单个租户的客户端和限流状态
+type tenant struct {
+	client      *OpenWeatherClient
+	windowStart time.Time
+	requests    int
+	lastUsed    time.Time
+}
+
+// TenantClients 按请求上下文中的API密钥路由到租户客户端的天气仓储
+// 未携带密钥的请求使用服务器默认客户端；每个租户有独立的客户端、限流和功能探测结果。
+type TenantClients struct {
+	defaultClient *OpenWeatherClient
+	newClient     func(apiKey string) *OpenWeatherClient
+	rateLimit     int
+	maxTenants    int
+	now           func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*tenant
+}
+
+// TenantOption 租户客户端配置项
+type TenantOption func(*TenantClients)
+
+// WithTenantRateLimit 设置每个租户每分钟的上游请求数，0表示不限制
+func WithTenantRateLimit(n int) TenantOption {
+	return func(t *TenantClients) {
+		if n >= 0 {
+			t.rateLimit = n
+		}
+	}
+}
+
+// WithMaxTenants 设置最多同时保留的租户客户端数，超出时淘汰最久未使用的租户
+func WithMaxTenants(n int) TenantOption {
+	return func(t *TenantClients) {
+		if n > 0 {
+			t.maxTenants = n
+		}
+	}
+}
+
+// WithTenantClock 设置租户限流使用的时钟，主要用于测试
+func WithTenantClock(now func() time.Time) TenantOption {
+	return func(t *TenantClients) {
+		t.now = now
+	}
+}
+
+// NewTenantClients 创建按租户路由的天气仓储
+// newClient 用租户的API密钥创建客户端，通常与默认客户端共享传输层以便统计指标
+func NewTenantClients(defaultClient *OpenWeatherClient, newClient func(apiKey string) *OpenWeatherClient, opts ...TenantOption) *TenantClients {
+	t := &TenantClients{
+		defaultClient: defaultClient,
+		newClient:     newClient,
+		rateLimit:     DefaultTenantRateLimit,
+		maxTenants:    DefaultMaxTenants,
+		now:           time.Now,
+		tenants:       make(map[string]*tenant),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// tenantFor 获取或创建请求所属的租户，未携带密钥时返回 nil
+// 调用方需持有锁
+func (t *TenantClients) tenantFor(ctx context.Context) *tenant {
+	apiKey := APIKeyFromContext(ctx)
+	if apiKey == "" {
+		return nil
+	}
+	id := TenantID(apiKey)
+	tn, ok := t.tenants[id]
+	if !ok {
+		for len(t.tenants) >= t.maxTenants {
+			var oldest string
+			for name, other := range t.tenants {
+				if oldest == "" || other.lastUsed.Before(t.tenants[oldest].lastUsed) {
+					oldest = name
+				}
+			}
+			delete(t.tenants, oldest)
+		}
+		tn = &tenant{client: t.newClient(apiKey)}
+		t.tenants[id] = tn
+	}
+	tn.lastUsed = t.now()
+	return tn
+}
+
+// clientFor 获取请求使用的客户端，并对租户请求计入每分钟限流
+func (t *TenantClients) clientFor(ctx context.Context) (*OpenWeatherClient, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tn := t.tenantFor(ctx)
+	if tn == nil {
+		return t.defaultClient, nil
+	}
+	if t.rateLimit > 0 {
+		now := t.now()
+		if now.Sub(tn.windowStart) >= time.Minute {
+			tn.windowStart, tn.requests = now, 0
+		}
+		if tn.requests >= t.rateLimit {
+			return nil, ErrTenantRateLimited
+		}
+		tn.requests++
+	}
+	return tn.client, nil
+}
+
+// currentClientFor 获取查询实时天气使用的客户端
+// 租户客户端在首次查询时探测付费功能，使自带 One Call 密钥的租户无需先调用 server_status 即可获得紫外线指数
+func (t *TenantClients) currentClientFor(ctx context.Context) (*OpenWeatherClient, error) {
+	c, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c != t.defaultClient {
+		c.Features(ctx)
+	}
+	return c, nil
+}
+
+// Tenants 获取当前保留的租户数
+func (t *TenantClients) Tenants() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.tenants)
+}
+
+// GetCurrentWeather 实现 weather.WeatherRepository
+func (t *TenantClients) GetCurrentWeather(ctx context.Context, lat, lon float64) (*weather.Weather, error) {
+	c, err := t.currentClientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetCurrentWeather(ctx, lat, lon)
+}
+
+// GetWeatherByCity 实现 weather.WeatherRepository
+func (t *TenantClients) GetWeatherByCity(ctx context.Context, city string) (*weather.Weather, error) {
+	c, err := t.currentClientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetWeatherByCity(ctx, city)
+}
+
+// GetHourlyWeatherByCoords 实现 weather.WeatherRepository
+func (t *TenantClients) GetHourlyWeatherByCoords(ctx context.Context, lat, lon float64, hours int) (*weather.HourlyWeatherResult, error) {
+	c, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetHourlyWeatherByCoords(ctx, lat, lon, hours)
+}
+
+// GetHourlyWeatherByCity 实现 weather.WeatherRepository
+func (t *TenantClients) GetHourlyWeatherByCity(ctx context.Context, city string, hours int) (*weather.HourlyWeatherResult, error) {
+	c, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetHourlyWeatherByCity(ctx, city, hours)
+}
+
+// GetWeatherByCityID 实现 weather.PlaceLookupRepository
+func (t *TenantClients) GetWeatherByCityID(ctx context.Context, id int64) (*weather.Weather, error) {
+	c, err := t.currentClientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetWeatherByCityID(ctx, id)
+}
+
+// GetHourlyWeatherByCityID 实现 weather.PlaceLookupRepository
+func (t *TenantClients) GetHourlyWeatherByCityID(ctx context.Context, id int64, hours int) (*weather.HourlyWeatherResult, error) {
+	c, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetHourlyWeatherByCityID(ctx, id, hours)
+}
+
+// GetWeatherByPostalCode 实现 weather.PlaceLookupRepository
+func (t *TenantClients) GetWeatherByPostalCode(ctx context.Context, code weather.PostalCode) (*weather.Weather, error) {
+	c, err := t.currentClientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetWeatherByPostalCode(ctx, code)
+}
+
+// GetHourlyWeatherByPostalCode 实现 weather.PlaceLookupRepository
+func (t *TenantClients) GetHourlyWeatherByPostalCode(ctx context.Context, code weather.PostalCode, hours int) (*weather.HourlyWeatherResult, error) {
+	c, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetHourlyWeatherByPostalCode(ctx, code, hours)
+}
+
+// GetAirQuality 实现 weather.AirQualityRepository
+func (t *TenantClients) GetAirQuality(ctx context.Context, lat, lon float64) (*weather.AirQuality, error) {
+	c, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetAirQuality(ctx, lat, lon)
+}
+
+// KnownLocations 实现 weather.LocationCatalog，内置位置与密钥无关
+func (t *TenantClients) KnownLocations() []string {
+	return t.defaultClient.KnownLocations()
+}
+
+// Probe 实现 weather.ProviderProber，探测请求所属租户（或默认）的密钥
+// 密钥有效时同时报告该密钥可用的付费功能，功能探测结果由各客户端缓存
+func (t *TenantClients) Probe(ctx context.Context) weather.ProviderProbe {
+	t.mu.Lock()
+	c := t.defaultClient
+	if tn := t.tenantFor(ctx); tn != nil {
+		c = tn.client
+	}
+	t.mu.Unlock()
+
+	probe := c.Probe(ctx)
+	if !probe.KeyValid {
+		return probe
+	}
+	features := c.Features(ctx)
+	probe.Features = features
+	return probe
+}
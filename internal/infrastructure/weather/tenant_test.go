@@ -0,0 +1,177 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keyRecorder 记录上游请求使用的API密钥的测试服务器
+type keyRecorder struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *keyRecorder) server(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := req.URL.Query().Get("appid")
+		r.mu.Lock()
+		r.keys = append(r.keys, key)
+		r.mu.Unlock()
+		switch {
+		case key == "bad":
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasSuffix(req.URL.Path, "/onecall") && key != "paid":
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasSuffix(req.URL.Path, "/weather"):
+			w.Write([]byte(`{"name":"Paris","sys":{"country":"FR"},"weather":[{"description":"晴"}]}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newTestTenantClients(ts *httptest.Server, opts ...TenantOption) *TenantClients {
+	newClient := func(key string) *OpenWeatherClient {
+		c := NewOpenWeatherClient(key)
+		c.baseURL, c.oneCallURL = ts.URL, ts.URL
+		return c
+	}
+	return NewTenantClients(newClient("server-key"), newClient, opts...)
+}
+
+func TestTenantClientsRouteByAPIKey(t *testing.T) {
+	recorder := &keyRecorder{}
+	clients := newTestTenantClients(recorder.server(t))
+
+	if _, err := clients.GetWeatherByCity(context.Background(), "Paris"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := WithAPIKey(context.Background(), "alice-key")
+	if _, err := clients.GetWeatherByCity(ctx, "Paris"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	clients.GetWeatherByCity(ctx, "Paris")
+
+	// 租户首次查询实时天气前探测一次付费功能
+	expected := "server-key,alice-key,alice-key,alice-key"
+	if got := strings.Join(recorder.keys, ","); got != expected {
+		t.Errorf("Expected upstream keys %s, got %s", expected, got)
+	}
+	if clients.Tenants() != 1 {
+		t.Errorf("Expected 1 tenant client, got %d", clients.Tenants())
+	}
+}
+
+func TestTenantClientsRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recorder := &keyRecorder{}
+	clients := newTestTenantClients(recorder.server(t),
+		WithTenantRateLimit(2),
+		WithTenantClock(func() time.Time { return now }),
+	)
+	alice := WithAPIKey(context.Background(), "alice-key")
+	bob := WithAPIKey(context.Background(), "bob-key")
+
+	clients.GetWeatherByCity(alice, "Paris")
+	clients.GetWeatherByCity(alice, "Paris")
+	if _, err := clients.GetWeatherByCity(alice, "Paris"); !errors.Is(err, ErrTenantRateLimited) {
+		t.Errorf("Expected rate limit error, got %v", err)
+	}
+	if _, err := clients.GetWeatherByCity(bob, "Paris"); err != nil {
+		t.Errorf("Expected other tenant to be unaffected, got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := clients.GetWeatherByCity(context.Background(), "Paris"); err != nil {
+			t.Fatalf("Expected default client not to be rate limited, got %v", err)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := clients.GetWeatherByCity(alice, "Paris"); err != nil {
+		t.Errorf("Expected rate limit window to reset, got %v", err)
+	}
+}
+
+func TestTenantClientsEvictLeastRecentlyUsed(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recorder := &keyRecorder{}
+	clients := newTestTenantClients(recorder.server(t),
+		WithMaxTenants(2),
+		WithTenantClock(func() time.Time { now = now.Add(time.Second); return now }),
+	)
+	for _, key := range []string{"a", "b", "a", "c"} {
+		clients.GetWeatherByCity(WithAPIKey(context.Background(), key), "Paris")
+	}
+	if clients.Tenants() != 2 {
+		t.Fatalf("Expected 2 tenants, got %d", clients.Tenants())
+	}
+	if _, ok := clients.tenants[TenantID("b")]; ok {
+		t.Error("Expected least recently used tenant b to be evicted")
+	}
+}
+
+func TestTenantClientsProbeDetectsFeatures(t *testing.T) {
+	recorder := &keyRecorder{}
+	clients := newTestTenantClients(recorder.server(t))
+
+	paid := clients.Probe(WithAPIKey(context.Background(), "paid"))
+	if !paid.KeyValid || len(paid.Features) != 1 || paid.Features[0] != FeatureOneCall {
+		t.Errorf("Expected paid key to have onecall, got %+v", paid)
+	}
+	free := clients.Probe(context.Background())
+	if !free.KeyValid || len(free.Features) != 0 {
+		t.Errorf("Expected server key without paid features, got %+v", free)
+	}
+	bad := clients.Probe(WithAPIKey(context.Background(), "bad"))
+	if bad.KeyValid {
+		t.Errorf("Expected invalid tenant key, got %+v", bad)
+	}
+
+	// 功能探测结果按密钥缓存
+	recorder.keys = nil
+	clients.Probe(WithAPIKey(context.Background(), "paid"))
+	if len(recorder.keys) != 1 {
+		t.Errorf("Expected only the probe request on repeated probe, got %v", recorder.keys)
+	}
+}
+
+func TestTenantClientsDetectFeaturesOnFirstQuery(t *testing.T) {
+	recorder := &keyRecorder{}
+	clients := newTestTenantClients(recorder.server(t))
+	paid := WithAPIKey(context.Background(), "paid")
+
+	for i := 0; i < 2; i++ {
+		w, err := clients.GetWeatherByCity(paid, "Paris")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if w.Current.UVIndex == nil {
+			t.Errorf("Query %d: expected UV index for a tenant with One Call", i)
+		}
+	}
+	if w, _ := clients.GetWeatherByCity(context.Background(), "Paris"); w.Current.UVIndex != nil {
+		t.Errorf("Expected default client not to probe features on demand, got UV index %v", *w.Current.UVIndex)
+	}
+	// 探测1次，两次查询各请求实时天气和 One Call，默认客户端请求1次
+	if len(recorder.keys) != 6 {
+		t.Errorf("Expected feature detection to run once, got requests %v", recorder.keys)
+	}
+}
+
+func TestTenantID(t *testing.T) {
+	id := TenantID("secret-key")
+	if id != TenantID("secret-key") || id == TenantID("other-key") {
+		t.Error("Expected tenant ID to be stable and distinct per key")
+	}
+	if strings.Contains(id, "secret") {
+		t.Errorf("Expected tenant ID not to contain the key, got %s", id)
+	}
+}